@@ -0,0 +1,546 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/hash"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+)
+
+// Exit codes of the client, so cron jobs can tell a failed run from a misconfigured one.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// Output formats of the results printed to standard output. Progress is logged to standard error.
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+)
+
+// command is a subcommand of the client. run parses the arguments left after the command's flags,
+// and connects the session only once they are valid.
+type command struct {
+	name    string
+	args    string
+	summary string
+	flags   func(fs *flag.FlagSet) func(s *session, args []string) error
+}
+
+var commands = []command{
+	{
+		name:    "load",
+		args:    "[-records n] [-data dir]",
+		summary: "ship oil batches built from the data files through every stage",
+		flags:   loadCommand,
+	},
+	{
+		name:    "get",
+		args:    "-stage n -id assetID",
+		summary: "read an asset of a stage",
+		flags:   getCommand,
+	},
+	{
+		name:    "list",
+		args:    "-stage n [-from date] [-to date] [-carrier name] [-consumer name] [-page-size n]",
+		summary: "list the assets of a stage, page by page",
+		flags:   listCommand,
+	},
+	{
+		name:    "trace",
+		args:    "oilBatchID",
+		summary: "trace an oil batch across every stage",
+		flags:   traceCommand,
+	},
+	{
+		name:    "metadata",
+		summary: "save the contract metadata of every stage to " + metadataDir,
+		flags:   metadataCommand,
+	},
+	{
+		name:    "settle",
+		args:    "-stage n -batch oilBatchID",
+		summary: "pay and settle the bill of an oil batch's handover",
+		flags:   settleCommand,
+	},
+	{
+		name:    "reconcile",
+		summary: "keep the main chain summaries in step with the stages until interrupted",
+		flags:   reconcileCommand,
+	},
+}
+
+// usageError reports a command line the client cannot run.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...any) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(arguments []string) int {
+	global := flag.NewFlagSet("oilchain", flag.ExitOnError)
+	global.Usage = func() { printUsage(global) }
+	configPath := global.String("config", envOr(configEnv, defaultConfigPath), "config file with the connection profiles, YAML or JSON (env "+configEnv+")")
+	profileName := global.String("profile", os.Getenv(profileEnv), "connection profile to use, defaultProfile of the config file if empty (env "+profileEnv+")")
+	output := global.String("output", envOr(outputEnv, outputJSON), "output format, json or table (env "+outputEnv+")")
+	global.Parse(arguments)
+
+	if *output != outputJSON && *output != outputTable {
+		fmt.Fprintf(os.Stderr, "oilchain: unknown output format %q\n", *output)
+		return exitUsage
+	}
+	if global.NArg() == 0 {
+		printUsage(global)
+		return exitUsage
+	}
+	cmd, ok := findCommand(global.Arg(0))
+	if !ok {
+		fmt.Fprintf(os.Stderr, "oilchain: unknown command %q\n", global.Arg(0))
+		printUsage(global)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("oilchain "+cmd.name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oilchain %s %s\n\n%s.\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	runCommand := cmd.flags(fs)
+	fs.Parse(global.Args()[1:])
+
+	profile, err := loadProfile(*configPath, *profileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	channelNameFormat = profile.ChannelName
+	chaincodeNameFormat = profile.ChaincodeName
+
+	s := &session{profile: profile, output: *output, stdout: os.Stdout}
+	defer s.close()
+	err = runCommand(s, fs.Args())
+
+	var usage *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usage):
+		fmt.Fprintf(os.Stderr, "oilchain %s: %s\n", cmd.name, usage.message)
+		fs.Usage()
+		return exitUsage
+	default:
+		fmt.Fprintf(os.Stderr, "Error: %s\n", errorMessage(err))
+		return exitError
+	}
+}
+
+func printUsage(global *flag.FlagSet) {
+	out := global.Output()
+	fmt.Fprintf(out, "Usage: oilchain [flags] <command> [command flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	global.PrintDefaults()
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func envOr(name string, fallback string) string {
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// session holds the Gateway connection of a command and prints its results.
+type session struct {
+	profile *Profile
+	output  string
+	stdout  io.Writer
+	gw      *client.Gateway
+	// adminGw is signed by the org admin of the profile, or is gw when the profile names none.
+	adminGw        *client.Gateway
+	sign           identity.Sign
+	certificatePEM []byte
+	// signerID is the key ID the certificate is registered under with the stage contracts.
+	signerID string
+	closers  []func() error
+}
+
+// connect opens the Gateway connection of the profile, signed with its identity, and the Gateway connection
+// of its org admin.
+func (s *session) connect() error {
+	// The gRPC client connection should be shared by all Gateway connections to this endpoint
+	clientConnection, err := newGrpcConnection(s.profile)
+	if err != nil {
+		return err
+	}
+	s.closers = append(s.closers, clientConnection.Close)
+
+	s.certificatePEM, err = readFirstFile(s.profile.CertPath)
+	if err != nil {
+		return fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	s.signerID, err = signerIDOf(s.profile, s.certificatePEM)
+	if err != nil {
+		return err
+	}
+	s.sign, err = newSign(s.profile.KeyPath)
+	if err != nil {
+		return err
+	}
+	s.gw, err = s.newGateway(clientConnection, s.profile.CertPath, s.sign)
+	if err != nil {
+		return err
+	}
+
+	s.adminGw = s.gw
+	if s.profile.AdminCertPath != "" {
+		adminSign, err := newSign(s.profile.AdminKeyPath)
+		if err != nil {
+			return err
+		}
+		s.adminGw, err = s.newGateway(clientConnection, s.profile.AdminCertPath, adminSign)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newGateway opens a Gateway connection for the identity of the certificate in certPath, signed with sign.
+func (s *session) newGateway(clientConnection *grpc.ClientConn, certPath string, sign identity.Sign) (*client.Gateway, error) {
+	id, err := newIdentity(s.profile.MSPID, certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Gateway connection for a specific client identity
+	gw, err := client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithHash(hash.SHA256),
+		client.WithClientConnection(clientConnection),
+		// Default timeouts for different gRPC calls
+		client.WithEvaluateTimeout(5*time.Second),
+		client.WithEndorseTimeout(15*time.Second),
+		client.WithSubmitTimeout(5*time.Second),
+		client.WithCommitStatusTimeout(1*time.Minute),
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.closers = append(s.closers, gw.Close)
+	return gw, nil
+}
+
+func (s *session) close() {
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		s.closers[i]()
+	}
+}
+
+// print writes the result as indented JSON, or as the table the table function writes.
+func (s *session) print(result any, table func(w io.Writer)) error {
+	if s.output == outputTable {
+		w := tabwriter.NewWriter(s.stdout, 0, 4, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	_, err = fmt.Fprintf(s.stdout, "%s\n", resultJSON)
+	return err
+}
+
+// stageFlag declares the -stage flag of a command that reads stage contracts, numbered 1 to 6.
+func stageFlag(fs *flag.FlagSet) *int {
+	return fs.Int("stage", 0, "stage channel, 1 to 5, or 6 for the main chain")
+}
+
+func checkStage(stage int, last int) error {
+	if stage < 1 || stage > last {
+		return usagef("-stage must be between 1 and %d", last)
+	}
+	return nil
+}
+
+func checkNoArgs(args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments %s", strings.Join(args, " "))
+	}
+	return nil
+}
+
+// LoadSummary is the outcome of a load. The outcome of each record is in the report file.
+type LoadSummary struct {
+	Started   time.Time `json:"Started"`
+	Finished  time.Time `json:"Finished"`
+	Succeeded int       `json:"Succeeded"`
+	Failed    int       `json:"Failed"`
+	Skipped   int       `json:"Skipped"`
+	Report    string    `json:"Report"`
+}
+
+func loadCommand(fs *flag.FlagSet) func(s *session, args []string) error {
+	nums := fs.Int("records", 1000, "number of oil batches to ship")
+	dataDir := fs.String("data", ".", "directory of the "+drillDataFile+", "+refinDataFile+", "+storDataFile+" and "+pumpDataFile+" data files")
+	return func(s *session, args []string) error {
+		if err := checkNoArgs(args); err != nil {
+			return err
+		}
+		if *nums < 1 {
+			return usagef("-records must be at least 1")
+		}
+		if err := s.connect(); err != nil {
+			return err
+		}
+
+		report, err := loadSupplyChain(s.gw, s.adminGw, s.sign, s.certificatePEM, s.signerID, s.profile.MSPID, *dataDir, *nums)
+		if err != nil {
+			return err
+		}
+		summary := LoadSummary{
+			Started:   report.Started,
+			Finished:  report.Finished,
+			Succeeded: report.Succeeded,
+			Failed:    report.Failed,
+			Skipped:   report.Skipped,
+			Report:    loadReportPath,
+		}
+		err = s.print(summary, func(w io.Writer) {
+			fmt.Fprintln(w, "SUCCEEDED\tFAILED\tSKIPPED\tDURATION\tREPORT")
+			fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\n", summary.Succeeded, summary.Failed, summary.Skipped, summary.Finished.Sub(summary.Started).Round(time.Millisecond), summary.Report)
+		})
+		if err != nil {
+			return err
+		}
+		if summary.Failed > 0 {
+			return fmt.Errorf("%d records failed, see %s", summary.Failed, loadReportPath)
+		}
+		return nil
+	}
+}
+
+func getCommand(fs *flag.FlagSet) func(s *session, args []string) error {
+	stage := stageFlag(fs)
+	assetID := fs.String("id", "", "asset ID")
+	return func(s *session, args []string) error {
+		if err := checkNoArgs(args); err != nil {
+			return err
+		}
+		if err := checkStage(*stage, 6); err != nil {
+			return err
+		}
+		if *assetID == "" {
+			return usagef("-id is required")
+		}
+		if err := s.connect(); err != nil {
+			return err
+		}
+
+		evaluateResult, err := stageContract(s.gw, *stage).EvaluateTransaction("ReadAsset", *assetID)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate transaction: %w", err)
+		}
+		return s.print(json.RawMessage(evaluateResult), func(w io.Writer) {
+			writeFields(w, evaluateResult)
+		})
+	}
+}
+
+func listCommand(fs *flag.FlagSet) func(s *session, args []string) error {
+	stage := stageFlag(fs)
+	pageSize := fs.Int("page-size", assetPageSize, "number of assets read per transaction")
+	var filter assetFilter
+	fs.StringVar(&filter.From, "from", "", "only assets dated on or after this day, as 2006-01-02")
+	fs.StringVar(&filter.To, "to", "", "only assets dated on or before this day, as 2006-01-02")
+	fs.StringVar(&filter.Carrier, "carrier", "", "only assets whose bill names this carrier")
+	fs.StringVar(&filter.Consumer, "consumer", "", "only assets handed over to this party")
+	return func(s *session, args []string) error {
+		if err := checkNoArgs(args); err != nil {
+			return err
+		}
+		if err := checkStage(*stage, 6); err != nil {
+			return err
+		}
+		if *pageSize < 1 {
+			return usagef("-page-size must be at least 1")
+		}
+		if err := s.connect(); err != nil {
+			return err
+		}
+
+		// Read every page before printing, so a failure part way does not leave a truncated list.
+		records := []json.RawMessage{}
+		var assets []stageRecord
+		err := forEachAssetPage(stageContract(s.gw, *stage), *pageSize, filter, func(page *assetPage) error {
+			var pageRecords []json.RawMessage
+			if err := json.Unmarshal(page.Records, &pageRecords); err != nil {
+				return fmt.Errorf("failed to parse assets: %w", err)
+			}
+			pageAssets, err := parseStageAssets(*stage, page.Records)
+			if err != nil {
+				return err
+			}
+			records = append(records, pageRecords...)
+			assets = append(assets, pageAssets...)
+			log.Printf("*** Read %d assets of %s", len(records), channelName(*stage))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return s.print(records, func(w io.Writer) {
+			fmt.Fprintln(w, "ID\tOIL BATCH\tQUANTITY\tQUALITY CERTIFICATE")
+			for _, asset := range assets {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", asset.assetID(), asset.oilBatchID(), asset.quantity(), asset.qualityCertificate())
+			}
+		})
+	}
+}
+
+func traceCommand(fs *flag.FlagSet) func(s *session, args []string) error {
+	return func(s *session, args []string) error {
+		if len(args) != 1 {
+			return usagef("expected one oil batch ID")
+		}
+		if err := s.connect(); err != nil {
+			return err
+		}
+
+		lineage, err := traceOilBatch(s.gw, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to trace oil batch: %w", err)
+		}
+		return s.print(lineage, func(w io.Writer) {
+			fmt.Fprintln(w, "STAGE\tCHANNELS\tFOUND\tQUANTITY\tQUALITY CERTIFICATE\tFLAGS")
+			for _, stage := range lineage.Stages {
+				fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\n", stage.Stage, strings.Join(stage.Channels, ","), stage.Found, stage.OilQuantity, stage.OilQualityCerti, strings.Join(stage.Flags, "; "))
+			}
+		})
+	}
+}
+
+func metadataCommand(fs *flag.FlagSet) func(s *session, args []string) error {
+	return func(s *session, args []string) error {
+		if err := checkNoArgs(args); err != nil {
+			return err
+		}
+		if err := s.connect(); err != nil {
+			return err
+		}
+
+		paths, err := saveContractMetadata(s.gw)
+		if err != nil {
+			return err
+		}
+		return s.print(paths, func(w io.Writer) {
+			fmt.Fprintln(w, "FILE")
+			for _, path := range paths {
+				fmt.Fprintln(w, path)
+			}
+		})
+	}
+}
+
+func settleCommand(fs *flag.FlagSet) func(s *session, args []string) error {
+	stage := fs.Int("stage", 0, "stage channel of the handover, 1 to 5")
+	oilID := fs.String("batch", "", "oil batch ID")
+	return func(s *session, args []string) error {
+		if err := checkNoArgs(args); err != nil {
+			return err
+		}
+		if err := checkStage(*stage, 5); err != nil {
+			return err
+		}
+		if *oilID == "" {
+			return usagef("-batch is required")
+		}
+		if err := s.connect(); err != nil {
+			return err
+		}
+
+		settlement, err := settleBill(s.gw, *stage, *oilID)
+		if err != nil {
+			return err
+		}
+		return s.print(settlement, func(w io.Writer) {
+			fmt.Fprintln(w, "CHANNEL\tOIL BATCH\tBILL\tPAYEE\tAMOUNT\tPAYMENT TX")
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", settlement.Channel, settlement.OilID, settlement.BillNumber, settlement.Payee, formatAmount(settlement.Amount), settlement.PaymentTxID)
+		})
+	}
+}
+
+func reconcileCommand(fs *flag.FlagSet) func(s *session, args []string) error {
+	return func(s *session, args []string) error {
+		if err := checkNoArgs(args); err != nil {
+			return err
+		}
+		if err := s.connect(); err != nil {
+			return err
+		}
+		return runReconciler(s.gw, s.adminGw, s.sign, s.certificatePEM, s.signerID)
+	}
+}
+
+// writeFields writes the fields of a JSON object as rows, in name order. Nested values are written as JSON.
+func writeFields(w io.Writer, objectJSON []byte) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(objectJSON, &fields); err != nil {
+		fmt.Fprintf(w, "%s\n", objectJSON)
+		return
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "FIELD\tVALUE")
+	for _, name := range names {
+		var text string
+		if err := json.Unmarshal(fields[name], &text); err != nil {
+			text = string(fields[name])
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, text)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables that select the config file, profile and output format when the flags are not given.
+const (
+	configEnv  = "OILCHAIN_CONFIG"
+	profileEnv = "OILCHAIN_PROFILE"
+	outputEnv  = "OILCHAIN_OUTPUT"
+)
+
+const defaultConfigPath = "oilchain.yaml"
+
+// Config holds a connection profile for each environment the client runs against.
+type Config struct {
+	// DefaultProfile is used when neither -profile nor OILCHAIN_PROFILE selects one.
+	DefaultProfile string              `yaml:"defaultProfile"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile describes the identity the client signs with, the gateway peer it connects to and how the stage
+// channels and chaincodes are named. Relative paths are resolved against CryptoPath, which is itself
+// resolved against the directory of the config file.
+type Profile struct {
+	MSPID        string `yaml:"mspID"`
+	CryptoPath   string `yaml:"cryptoPath"`
+	CertPath     string `yaml:"certPath"`
+	KeyPath      string `yaml:"keyPath"`
+	TLSCertPath  string `yaml:"tlsCertPath"`
+	PeerEndpoint string `yaml:"peerEndpoint"`
+	GatewayPeer  string `yaml:"gatewayPeer"`
+	// ChannelName and ChaincodeName format the channel and chaincode of a stage from its number, 1 to 6.
+	ChannelName   string `yaml:"channelName"`
+	ChaincodeName string `yaml:"chaincodeName"`
+	// AdminCertPath and AdminKeyPath name an admin of the same org. Only org admins can register device and
+	// party keys with the stage contracts; when they are empty, the client identity registers them itself.
+	AdminCertPath string `yaml:"adminCertPath"`
+	AdminKeyPath  string `yaml:"adminKeyPath"`
+	// SignerID is the key ID the client certificate is registered under and that the handovers it signs
+	// name. When it is empty, it is the MSP ID and the common name of the certificate, as
+	// Org1MSP/User1@org1.example.com.
+	SignerID string `yaml:"signerID"`
+}
+
+// profileEnvOverrides lists the environment variables that override a field of the selected profile, so a
+// cron job can point the same config at another peer or identity.
+var profileEnvOverrides = map[string]func(*Profile) *string{
+	"OILCHAIN_MSP_ID":          func(p *Profile) *string { return &p.MSPID },
+	"OILCHAIN_CRYPTO_PATH":     func(p *Profile) *string { return &p.CryptoPath },
+	"OILCHAIN_CERT_PATH":       func(p *Profile) *string { return &p.CertPath },
+	"OILCHAIN_KEY_PATH":        func(p *Profile) *string { return &p.KeyPath },
+	"OILCHAIN_TLS_CERT_PATH":   func(p *Profile) *string { return &p.TLSCertPath },
+	"OILCHAIN_PEER_ENDPOINT":   func(p *Profile) *string { return &p.PeerEndpoint },
+	"OILCHAIN_GATEWAY_PEER":    func(p *Profile) *string { return &p.GatewayPeer },
+	"OILCHAIN_ADMIN_CERT_PATH": func(p *Profile) *string { return &p.AdminCertPath },
+	"OILCHAIN_ADMIN_KEY_PATH":  func(p *Profile) *string { return &p.AdminKeyPath },
+	"OILCHAIN_SIGNER_ID":       func(p *Profile) *string { return &p.SignerID },
+}
+
+// loadProfile reads the config file, which may be YAML or JSON, and returns the named profile, or the
+// default profile when name is empty, with the environment overrides applied.
+func loadProfile(filename string, name string) (*Profile, error) {
+	configYAML, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var config Config
+	if err := yaml.Unmarshal(configYAML, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
+	}
+
+	if name == "" {
+		name = config.DefaultProfile
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no profile selected, set -profile, %s or defaultProfile", profileEnv)
+	}
+	profile, ok := config.Profiles[name]
+	if !ok || profile == nil {
+		return nil, fmt.Errorf("unknown profile %s, the config file has %s", name, strings.Join(config.profileNames(), ", "))
+	}
+
+	for env, field := range profileEnvOverrides {
+		if value, ok := os.LookupEnv(env); ok {
+			*field(profile) = value
+		}
+	}
+	if profile.ChannelName == "" {
+		profile.ChannelName = channelNameFormat
+	}
+	if profile.ChaincodeName == "" {
+		profile.ChaincodeName = chaincodeNameFormat
+	}
+	if strings.Count(profile.ChannelName, "%d") != 1 || strings.Count(profile.ChaincodeName, "%d") != 1 {
+		return nil, fmt.Errorf("the channelName and chaincodeName of profile %s must contain %%d once, for the stage number", name)
+	}
+	if profile.MSPID == "" || profile.PeerEndpoint == "" || profile.GatewayPeer == "" || profile.CertPath == "" || profile.KeyPath == "" || profile.TLSCertPath == "" {
+		return nil, fmt.Errorf("profile %s needs an mspID, peerEndpoint, gatewayPeer, certPath, keyPath and tlsCertPath", name)
+	}
+	if (profile.AdminCertPath == "") != (profile.AdminKeyPath == "") {
+		return nil, fmt.Errorf("profile %s needs both or neither of adminCertPath and adminKeyPath", name)
+	}
+
+	profile.CryptoPath = resolvePath(filepath.Dir(filename), profile.CryptoPath)
+	profile.CertPath = resolvePath(profile.CryptoPath, profile.CertPath)
+	profile.KeyPath = resolvePath(profile.CryptoPath, profile.KeyPath)
+	profile.TLSCertPath = resolvePath(profile.CryptoPath, profile.TLSCertPath)
+	if profile.AdminCertPath != "" {
+		profile.AdminCertPath = resolvePath(profile.CryptoPath, profile.AdminCertPath)
+		profile.AdminKeyPath = resolvePath(profile.CryptoPath, profile.AdminKeyPath)
+	}
+	return profile, nil
+}
+
+func (config *Config) profileNames() []string {
+	names := make([]string, 0, len(config.Profiles))
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func resolvePath(base string, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(base, name)
+}
+
+// The formats of the channel and chaincode names of the stages. main sets them from the profile.
+var (
+	channelNameFormat   = "channel%d"
+	chaincodeNameFormat = "basic_channel%d"
+)
+
+// channelName returns the name of the channel of a stage, numbered 1 to 6.
+func channelName(channel int) string {
+	return fmt.Sprintf(channelNameFormat, channel)
+}
+
+// chaincodeName returns the name of the chaincode of a stage, numbered 1 to 6.
+func chaincodeName(channel int) string {
+	return fmt.Sprintf(chaincodeNameFormat, channel)
+}
@@ -0,0 +1,381 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// loadBatchSize is the number of records written by one batch transaction.
+	loadBatchSize = 50
+	// loadConcurrency is the number of transactions the loader has in flight at once.
+	loadConcurrency = 8
+	// loadMaxAttempts bounds how often a transaction is submitted again after an MVCC conflict.
+	loadMaxAttempts = 5
+	loadRetryDelay  = 200 * time.Millisecond
+	loadReportPath  = "load-report.json"
+)
+
+// Outcomes of a record in the load report.
+const (
+	loadSucceeded = "Succeeded"
+	loadFailed    = "Failed"
+	loadSkipped   = "Skipped"
+)
+
+// LoadRecord is the outcome of one transaction for one record.
+type LoadRecord struct {
+	Channel     string `json:"Channel"`
+	Transaction string `json:"Transaction"`
+	ID          string `json:"ID"`
+	OilID       string `json:"Oil_Batch_ID"`
+	Status      string `json:"Status"`
+	Attempts    int    `json:"Attempts"`
+	Error       string `json:"Error,omitempty"`
+}
+
+// LoadReport lists the outcome of every record of a load, so a load that failed part way can be resumed
+// from the records that did not succeed.
+type LoadReport struct {
+	Started   time.Time    `json:"Started"`
+	Finished  time.Time    `json:"Finished"`
+	Succeeded int          `json:"Succeeded"`
+	Failed    int          `json:"Failed"`
+	Skipped   int          `json:"Skipped"`
+	Records   []LoadRecord `json:"Records"`
+}
+
+// loadItem is a record to create on a stage contract, with the keys it is reported under.
+type loadItem struct {
+	id     string
+	oilID  string
+	record any
+}
+
+// loader submits records to the stage contracts with bounded concurrency. An oil batch that fails on one
+// stage is skipped on the stages after it, as their contracts would refuse it anyway.
+type loader struct {
+	gw     *client.Gateway
+	mu     sync.Mutex
+	report LoadReport
+	failed map[string]bool
+}
+
+func newLoader(gw *client.Gateway) *loader {
+	return &loader{
+		gw:     gw,
+		report: LoadReport{Started: time.Now()},
+		failed: make(map[string]bool),
+	}
+}
+
+// oilBatchItems returns the items of a lifecycle transaction, which takes the oil batch IDs themselves.
+func oilBatchItems(oilIDs []string) []loadItem {
+	items := make([]loadItem, len(oilIDs))
+	for i, oilID := range oilIDs {
+		items[i] = loadItem{id: oilID, oilID: oilID, record: oilID}
+	}
+	return items
+}
+
+// submitBatches submits the items to a batch transaction of the stage contract, such as CreateAssets or
+// AcceptHandovers, loadBatchSize items per transaction.
+func (l *loader) submitBatches(channel int, name string, items []loadItem) {
+	contract := stageContract(l.gw, channel)
+	var pending []loadItem
+	for _, item := range items {
+		if l.isFailed(item.oilID) {
+			l.add(LoadRecord{Channel: channelName(channel), Transaction: name, ID: item.id, OilID: item.oilID, Status: loadSkipped})
+			continue
+		}
+		pending = append(pending, item)
+	}
+
+	var tasks []func()
+	for start := 0; start < len(pending); start += loadBatchSize {
+		batch := pending[start:min(start+loadBatchSize, len(pending))]
+		tasks = append(tasks, func() {
+			l.submitBatch(channel, contract, name, batch)
+		})
+	}
+	runBounded(tasks)
+}
+
+// submitBatch submits one batch transaction. The contract rejects a batch as a whole, so when it does the
+// items are submitted one by one to find out which of them failed.
+func (l *loader) submitBatch(channel int, contract *client.Contract, name string, batch []loadItem) {
+	records := make([]any, len(batch))
+	for i, item := range batch {
+		records[i] = item.record
+	}
+	recordsJSON, err := json.Marshal(records)
+	if err != nil {
+		panic(fmt.Errorf("failed to marshal batch: %w", err))
+	}
+
+	attempts, err := submitWithRetry(contract, name, string(recordsJSON))
+	if err != nil && len(batch) > 1 {
+		for _, item := range batch {
+			l.submitBatch(channel, contract, name, []loadItem{item})
+		}
+		return
+	}
+	for _, item := range batch {
+		record := LoadRecord{Channel: channelName(channel), Transaction: name, ID: item.id, OilID: item.oilID}
+		l.add(withOutcome(record, attempts, err))
+	}
+}
+
+func (l *loader) add(record LoadRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.report.Records = append(l.report.Records, record)
+	switch record.Status {
+	case loadSucceeded:
+		l.report.Succeeded++
+	case loadFailed:
+		l.report.Failed++
+		l.failed[record.OilID] = true
+	case loadSkipped:
+		l.report.Skipped++
+	}
+}
+
+func (l *loader) isFailed(oilID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.failed[oilID]
+}
+
+// finish completes the report and writes it to loadReportPath.
+func (l *loader) finish() (*LoadReport, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.report.Finished = time.Now()
+	reportJSON, err := json.MarshalIndent(l.report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal load report: %w", err)
+	}
+	if err := os.WriteFile(loadReportPath, reportJSON, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write load report: %w", err)
+	}
+	return &l.report, nil
+}
+
+func withOutcome(record LoadRecord, attempts int, err error) LoadRecord {
+	record.Attempts = attempts
+	record.Status = loadSucceeded
+	if err != nil {
+		record.Status = loadFailed
+		record.Error = errorMessage(err)
+	}
+	return record
+}
+
+// runBounded runs the tasks with at most loadConcurrency of them at once and waits for all of them.
+func runBounded(tasks []func()) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, loadConcurrency)
+	for _, task := range tasks {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			task()
+		}()
+	}
+	wg.Wait()
+}
+
+// submitWithRetry submits the transaction, and submits it again when it failed validation with an MVCC
+// conflict because a concurrent transaction changed a key it read. It returns the number of attempts.
+func submitWithRetry(contract *client.Contract, name string, args ...string) (int, error) {
+	for attempt := 1; ; attempt++ {
+		_, err := contract.SubmitTransaction(name, args...)
+		if err == nil || !isMVCCConflict(err) || attempt == loadMaxAttempts {
+			return attempt, err
+		}
+		time.Sleep(time.Duration(attempt) * loadRetryDelay)
+	}
+}
+
+func isMVCCConflict(err error) bool {
+	var commitErr *client.CommitError
+	if !errors.As(err, &commitErr) {
+		return false
+	}
+	return commitErr.Code == peer.TxValidationCode_MVCC_READ_CONFLICT || commitErr.Code == peer.TxValidationCode_PHANTOM_READ_CONFLICT
+}
+
+// errorMessage returns the message of the error together with the messages the endorsing peers returned.
+func errorMessage(err error) string {
+	message := err.Error()
+	for _, detail := range status.Convert(err).Details() {
+		if errorDetail, ok := detail.(*gateway.ErrorDetail); ok {
+			message += fmt.Sprintf("; %s (%s): %s", errorDetail.GetAddress(), errorDetail.GetMspId(), errorDetail.GetMessage())
+		}
+	}
+	return message
+}
+
+// The records the load is built from, one per stage, ten of each.
+const (
+	drillDataFile = "DrillToRefin.json"
+	refinDataFile = "RefinToStor.json"
+	storDataFile  = "StorToConsu.json"
+	pumpDataFile  = "PumpToCust.json"
+)
+
+// loadSupplyChain ships nums oil batches through every stage, built from the records in dataDir, and
+// writes the outcome of each record to loadReportPath. certificatePEM is the certificate of sign, which is
+// registered through adminGw, signed by an org admin, as the key of the devices and of the party signerID the
+// loader signs for. The loader accepts every handover itself, so each one names its own org, receiver, as the
+// receiving org.
+func loadSupplyChain(gw *client.Gateway, adminGw *client.Gateway, sign identity.Sign, certificatePEM []byte, signerID string, receiver string, dataDir string, nums int) (*LoadReport, error) {
+	drillValue, err := readDataFile[DrillToRefin](dataDir, drillDataFile)
+	if err != nil {
+		return nil, err
+	}
+	refinValue, err := readDataFile[RefToStorage](dataDir, refinDataFile)
+	if err != nil {
+		return nil, err
+	}
+	storValue, err := readDataFile[StorToConsu](dataDir, storDataFile)
+	if err != nil {
+		return nil, err
+	}
+	pumpCustom, err := readDataFile[PumpToCustom](dataDir, pumpDataFile)
+	if err != nil {
+		return nil, err
+	}
+	records := min(len(drillValue), len(refinValue), len(storValue), len(pumpCustom))
+	if records == 0 {
+		return nil, fmt.Errorf("the data files in %s hold no records", dataDir)
+	}
+
+	// The loader signs on behalf of the devices and of the handing over party, so register its key for each of them.
+	drillDevices, refinDevices, storDevices, pumpDevices := []string{}, []string{}, []string{}, []string{}
+	for i := range drillValue {
+		drillValue[i].IoTData.signReading(sign)
+		drillDevices = append(drillDevices, drillValue[i].IoTData.DeviceID)
+	}
+	for i := range refinValue {
+		refinValue[i].IoTData.signReading(sign)
+		refinDevices = append(refinDevices, refinValue[i].IoTData.DeviceID)
+	}
+	for i := range storValue {
+		storValue[i].IotData.signReading(sign)
+		storDevices = append(storDevices, storValue[i].IotData.DeviceID)
+	}
+	for i := range pumpCustom {
+		pumpCustom[i].IotData.signReading(sign)
+		pumpDevices = append(pumpDevices, pumpCustom[i].IotData.DeviceID)
+	}
+	signingKeys := map[int][]string{
+		1: append([]string{signerID}, drillDevices...),
+		2: append([]string{signerID}, refinDevices...),
+		3: storDevices,
+		4: storDevices,
+		5: pumpDevices,
+		// The main chain summary carries the readings of every stage; it is built by the reconciler.
+		6: append(append(append(append([]string{signerID}, drillDevices...), refinDevices...), storDevices...), pumpDevices...),
+	}
+	for channel := 1; channel <= 6; channel++ {
+		if err := registerSigningKeys(stageContract(adminGw, channel), certificatePEM, signingKeys[channel]...); err != nil {
+			return nil, err
+		}
+	}
+
+	var oilIDs, factoryOilIDs, pumpOilIDs []string
+	var drills, refins, dispatches, factories, storPumps, customers []loadItem
+	for j := 0; j < nums; j++ {
+		i := j % records
+		// Each stage accepts a single handover per oil batch, so every record ships a batch of its own.
+		oilID := fmt.Sprintf("%s-%d", drillValue[i].OilID, j)
+
+		drill := drillValue[i]
+		drill.ID = fmt.Sprintf("%s%d", drill.ID, j)
+		drill.OilID = oilID
+		drill.Receiver = receiver
+		drill.signHandover(sign, signerID)
+
+		refin := refinValue[i]
+		refin.ID = fmt.Sprintf("%s%d", refin.ID, j)
+		refin.OilID = oilID
+		refin.Receiver = receiver
+		refin.signHandover(sign, signerID)
+
+		stor := storValue[i]
+		stor.ID = fmt.Sprintf("%s%d", stor.ID, j)
+		stor.OilId = oilID
+		stor.Receiver = receiver
+
+		pump := pumpCustom[i]
+		pump.ID = fmt.Sprintf("%s%d", pump.ID, j)
+		pump.OilId = oilID
+		pump.Receiver = receiver
+
+		oilIDs = append(oilIDs, oilID)
+		drills = append(drills, loadItem{id: drill.ID, oilID: oilID, record: drill})
+		refins = append(refins, loadItem{id: refin.ID, oilID: oilID, record: refin})
+		// Storage to Factory, or Storage to Pump and Pump to Customer
+		if i%2 == 0 {
+			factoryOilIDs = append(factoryOilIDs, oilID)
+			dispatches = append(dispatches, loadItem{id: oilID, oilID: oilID, record: Dispatch{OilID: oilID, Destination: destinationFactory}})
+			factories = append(factories, loadItem{id: stor.ID, oilID: oilID, record: stor.toFactory()})
+		} else {
+			pumpOilIDs = append(pumpOilIDs, oilID)
+			dispatches = append(dispatches, loadItem{id: oilID, oilID: oilID, record: Dispatch{OilID: oilID, Destination: destinationPump}})
+			storPumps = append(storPumps, loadItem{id: stor.ID, oilID: oilID, record: stor.toPump()})
+			customers = append(customers, loadItem{id: pump.ID, oilID: oilID, record: pump})
+		}
+	}
+
+	// The stages run in order, as each stage refuses a handover before the previous one is accepted.
+	l := newLoader(gw)
+	l.submitBatches(1, "DrillBatches", oilBatchItems(oilIDs))
+	l.submitBatches(1, "CreateAssets", drills)
+	l.submitBatches(1, "AcceptHandovers", oilBatchItems(oilIDs))
+	l.submitBatches(2, "CreateAssets", refins)
+	l.submitBatches(2, "AcceptHandovers", oilBatchItems(oilIDs))
+	l.submitBatches(2, "DispatchBatches", dispatches)
+	l.submitBatches(3, "CreateAssets", factories)
+	l.submitBatches(3, "AcceptHandovers", oilBatchItems(factoryOilIDs))
+	l.submitBatches(4, "CreateAssets", storPumps)
+	l.submitBatches(4, "AcceptHandovers", oilBatchItems(pumpOilIDs))
+	l.submitBatches(5, "CreateAssets", customers)
+	l.submitBatches(5, "AcceptHandovers", oilBatchItems(pumpOilIDs))
+	return l.finish()
+}
+
+// readDataFile reads the JSON array of records in the named file of dataDir.
+func readDataFile[T any](dataDir string, name string) ([]T, error) {
+	path := filepath.Join(dataDir, name)
+	recordsJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+	var records []T
+	if err := json.Unmarshal(recordsJSON, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return records, nil
+}
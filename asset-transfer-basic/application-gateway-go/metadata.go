@@ -0,0 +1,40 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// metadataDir holds the contract metadata of every stage, from which typed client bindings can be generated.
+const metadataDir = "contract-metadata"
+
+// saveContractMetadata writes the metadata every stage contract publishes, including the JSON schema of
+// the documents its transactions accept, to metadataDir/<channel>.json. It returns the files written.
+func saveContractMetadata(gw *client.Gateway) ([]string, error) {
+	if err := os.MkdirAll(metadataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", metadataDir, err)
+	}
+	var paths []string
+	for channel := 1; channel <= 6; channel++ {
+		evaluateResult, err := stageContract(gw, channel).EvaluateTransaction("org.hyperledger.fabric:GetMetadata")
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate transaction: %w", err)
+		}
+
+		path := filepath.Join(metadataDir, channelName(channel)+".json")
+		if err := os.WriteFile(path, []byte(formatJSON(evaluateResult)), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		log.Printf("*** Metadata of %s written to %s", chaincodeName(channel), path)
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,56 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// assetPageSize is the number of assets read per GetAssetsPage call, well within the gRPC message size limit.
+const assetPageSize = 200
+
+// assetPage is a page of assets as returned by GetAssetsPage. Records is the JSON array of the assets,
+// whose shape differs per stage.
+type assetPage struct {
+	Records             json.RawMessage `json:"Records"`
+	FetchedRecordsCount int32           `json:"Fetched_Records_Count"`
+	Bookmark            string          `json:"Bookmark"`
+}
+
+// assetFilter narrows GetAssetsPage to the assets dated between From and To, written as 2006-01-02, whose
+// bill names Carrier and that are handed over to Consumer. Empty fields match every asset.
+type assetFilter struct {
+	From     string
+	To       string
+	Carrier  string
+	Consumer string
+}
+
+// forEachAssetPage evaluates GetAssetsPage on the contract and passes each page to fn, until every asset
+// that matches the filter has been read.
+func forEachAssetPage(contract *client.Contract, pageSize int, filter assetFilter, fn func(page *assetPage) error) error {
+	bookmark := ""
+	for {
+		evaluateResult, err := contract.EvaluateTransaction("GetAssetsPage", strconv.Itoa(pageSize), bookmark, filter.From, filter.To, filter.Carrier, filter.Consumer)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate transaction: %w", err)
+		}
+		var page assetPage
+		if err := json.Unmarshal(evaluateResult, &page); err != nil {
+			return fmt.Errorf("failed to parse page of assets: %w", err)
+		}
+		if err := fn(&page); err != nil {
+			return err
+		}
+		if page.Bookmark == "" {
+			return nil
+		}
+		bookmark = page.Bookmark
+	}
+}
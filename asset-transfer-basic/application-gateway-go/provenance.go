@@ -0,0 +1,281 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Lineage is the provenance of a single oil batch, ordered from drilling to the main chain summary.
+type Lineage struct {
+	OilID    string         `json:"Oil_Batch_ID"`
+	Complete bool           `json:"Complete"`
+	Stages   []LineageStage `json:"Stages"`
+}
+
+// LineageStage holds the records of one stage contract that belong to the oil batch, together with
+// any disagreement found against the stage before it.
+type LineageStage struct {
+	Stage           string            `json:"Stage"`
+	Channels        []string          `json:"Channels"`
+	Found           bool              `json:"Found"`
+	OilQuantity     string            `json:"Oil_Quantity,omitempty"`
+	OilQualityCerti string            `json:"Oil_Quality_Certificate,omitempty"`
+	Records         []json.RawMessage `json:"Records,omitempty"`
+	Flags           []string          `json:"Flags,omitempty"`
+}
+
+// stageRecord is implemented by every stage asset so the lineage can be compared across stages.
+type stageRecord interface {
+	assetID() string
+	oilBatchID() string
+	quantity() string
+	qualityCertificate() string
+}
+
+func (a DrillToRefin) assetID() string            { return a.ID }
+func (a DrillToRefin) oilBatchID() string         { return a.OilID }
+func (a DrillToRefin) quantity() string           { return a.IoTData.Quantity.String() }
+func (a DrillToRefin) qualityCertificate() string { return a.OilQualityCerti }
+
+func (a RefToStorage) assetID() string            { return a.ID }
+func (a RefToStorage) oilBatchID() string         { return a.OilID }
+func (a RefToStorage) quantity() string           { return a.IoTData.Quantity.String() }
+func (a RefToStorage) qualityCertificate() string { return a.OilQualityCerti }
+
+func (a StorToConsu) assetID() string            { return a.ID }
+func (a StorToConsu) oilBatchID() string         { return a.OilId }
+func (a StorToConsu) quantity() string           { return a.OilQuantity }
+func (a StorToConsu) qualityCertificate() string { return a.OilQualityCerti }
+
+func (a PumpToCustom) assetID() string            { return a.ID }
+func (a PumpToCustom) oilBatchID() string         { return a.OilId }
+func (a PumpToCustom) quantity() string           { return a.OilQuantity }
+func (a PumpToCustom) qualityCertificate() string { return a.OilQualityCerti }
+
+func (a MainChain) assetID() string            { return a.ID }
+func (a MainChain) oilBatchID() string         { return a.OilId }
+func (a MainChain) quantity() string           { return a.OilQuantity }
+func (a MainChain) qualityCertificate() string { return a.OilQualityCerti }
+
+// provenanceStage describes where the records of one stage live and how to read them.
+type provenanceStage struct {
+	name     string
+	channels []int
+	query    func(contract *client.Contract, oilID string) ([]stageRecord, error)
+}
+
+// provenanceStages lists the stage contracts in the order an oil batch passes through them. Storage
+// hands over either to a factory (channel 3) or to an oil pump (channel 4), so both count as one stage.
+var provenanceStages = []provenanceStage{
+	{name: "DrillToRefin", channels: []int{1}, query: queryIndexedStageRecords[DrillToRefin]},
+	{name: "RefToStorage", channels: []int{2}, query: queryIndexedStageRecords[RefToStorage]},
+	{name: "StorToConsu", channels: []int{3, 4}, query: queryStageRecords[StorToConsu]},
+	{name: "PumpToCustom", channels: []int{5}, query: queryStageRecords[PumpToCustom]},
+	{name: "MainChain", channels: []int{6}, query: queryStageRecords[MainChain]},
+}
+
+// stageContract returns the contract deployed for the stage on the given channel number.
+func stageContract(gw *client.Gateway, channel int) *client.Contract {
+	network := gw.GetNetwork(channelName(channel))
+	return network.GetContract(chaincodeName(channel))
+}
+
+// traceOilBatch queries every stage contract for the oil batch and stitches the results into one lineage.
+func traceOilBatch(gw *client.Gateway, oilID string) (*Lineage, error) {
+	lineage := &Lineage{OilID: oilID}
+	for _, stage := range provenanceStages {
+		var records []stageRecord
+		var channels []string
+		for _, channel := range stage.channels {
+			found, err := stage.query(stageContract(gw, channel), oilID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to query %s on %s: %w", stage.name, channelName(channel), err)
+			}
+			records = append(records, found...)
+			channels = append(channels, channelName(channel))
+		}
+
+		lineageStage, err := newLineageStage(stage.name, channels, records)
+		if err != nil {
+			return nil, err
+		}
+		lineage.Stages = append(lineage.Stages, lineageStage)
+	}
+
+	checkLineage(lineage)
+	return lineage, nil
+}
+
+// queryIndexedStageRecords evaluates QueryAssetsByOilBatch on a contract that indexes its assets by oil batch.
+func queryIndexedStageRecords[T stageRecord](contract *client.Contract, oilID string) ([]stageRecord, error) {
+	evaluateResult, err := contract.EvaluateTransaction("QueryAssetsByOilBatch", oilID)
+	if err != nil {
+		return nil, err
+	}
+	return parseStageRecords[T](evaluateResult, oilID)
+}
+
+// queryStageRecords pages through the assets of the contract and keeps the records of the oil batch.
+func queryStageRecords[T stageRecord](contract *client.Contract, oilID string) ([]stageRecord, error) {
+	var records []stageRecord
+	err := forEachAssetPage(contract, assetPageSize, assetFilter{}, func(page *assetPage) error {
+		found, err := parseStageRecords[T](page.Records, oilID)
+		records = append(records, found...)
+		return err
+	})
+	return records, err
+}
+
+func parseStageRecords[T stageRecord](evaluateResult []byte, oilID string) ([]stageRecord, error) {
+	assets, err := parseAssets[T](evaluateResult)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []stageRecord
+	for _, asset := range assets {
+		if asset.oilBatchID() == oilID {
+			records = append(records, asset)
+		}
+	}
+	return records, nil
+}
+
+// parseAssets parses the assets a stage contract returned, such as the result of GetAllAssets.
+func parseAssets[T stageRecord](evaluateResult []byte) ([]stageRecord, error) {
+	if len(evaluateResult) == 0 {
+		return nil, nil
+	}
+
+	var assets []T
+	if err := json.Unmarshal(evaluateResult, &assets); err != nil {
+		return nil, fmt.Errorf("failed to parse assets: %w", err)
+	}
+
+	records := make([]stageRecord, len(assets))
+	for i, asset := range assets {
+		records[i] = asset
+	}
+	return records, nil
+}
+
+// parseStageAssets parses the assets of the stage contract on the given channel number.
+func parseStageAssets(channel int, evaluateResult []byte) ([]stageRecord, error) {
+	switch channel {
+	case 1:
+		return parseAssets[DrillToRefin](evaluateResult)
+	case 2:
+		return parseAssets[RefToStorage](evaluateResult)
+	case 3, 4:
+		return parseAssets[StorToConsu](evaluateResult)
+	case 5:
+		return parseAssets[PumpToCustom](evaluateResult)
+	default:
+		return parseAssets[MainChain](evaluateResult)
+	}
+}
+
+func newLineageStage(name string, channels []string, records []stageRecord) (LineageStage, error) {
+	stage := LineageStage{
+		Stage:    name,
+		Channels: channels,
+		Found:    len(records) > 0,
+	}
+	if !stage.Found {
+		return stage, nil
+	}
+
+	stage.OilQuantity = records[0].quantity()
+	stage.OilQualityCerti = records[0].qualityCertificate()
+	for _, record := range records {
+		recordJSON, err := json.Marshal(record)
+		if err != nil {
+			return stage, err
+		}
+		stage.Records = append(stage.Records, recordJSON)
+
+		if !sameQuantity(record.quantity(), stage.OilQuantity) {
+			stage.Flags = append(stage.Flags, fmt.Sprintf("records disagree on quantity: %s and %s", stage.OilQuantity, record.quantity()))
+		}
+		if record.qualityCertificate() != stage.OilQualityCerti {
+			stage.Flags = append(stage.Flags, fmt.Sprintf("records disagree on quality certificate: %s and %s", stage.OilQualityCerti, record.qualityCertificate()))
+		}
+	}
+	return stage, nil
+}
+
+// checkLineage flags every missing stage and every stage whose quantity or quality certificate
+// disagrees with the closest stage before it that was found.
+func checkLineage(lineage *Lineage) {
+	lineage.Complete = true
+	var previous *LineageStage
+	for i := range lineage.Stages {
+		stage := &lineage.Stages[i]
+		if !stage.Found {
+			stage.Flags = append(stage.Flags, "stage is missing")
+			lineage.Complete = false
+			continue
+		}
+
+		if previous != nil {
+			if !sameQuantity(previous.OilQuantity, stage.OilQuantity) {
+				stage.Flags = append(stage.Flags, fmt.Sprintf("quantity %s disagrees with %s quantity %s", stage.OilQuantity, previous.Stage, previous.OilQuantity))
+			}
+			if previous.OilQualityCerti != stage.OilQualityCerti {
+				stage.Flags = append(stage.Flags, fmt.Sprintf("quality certificate %s disagrees with %s quality certificate %s", stage.OilQualityCerti, previous.Stage, previous.OilQualityCerti))
+			}
+		}
+		previous = stage
+	}
+}
+
+// quantityUnits holds the factor that converts a quantity in the given unit to barrels. It holds the units
+// the stage contracts accept for telemetry, and the spelled out barrels the stage records use.
+var quantityUnits = map[string]float64{
+	"bbl":     1,
+	"barrel":  1,
+	"barrels": 1,
+	"L":       1 / 158.987294928,
+	"m3":      6.289810770432105,
+}
+
+// sameQuantity compares quantities such as "10,000 barrels" and "1590 m3" in barrels. A quantity that is
+// not a number followed by a known unit never matches, except that two empty quantities do.
+func sameQuantity(a string, b string) bool {
+	if strings.TrimSpace(a) == "" && strings.TrimSpace(b) == "" {
+		return true
+	}
+	x, okA := parseQuantity(a)
+	y, okB := parseQuantity(b)
+	if !okA || !okB {
+		return false
+	}
+	// Converting between units leaves rounding errors, so quantities within a millionth of each other match.
+	return math.Abs(x-y) <= 1e-6*math.Max(math.Abs(x), math.Abs(y))
+}
+
+// parseQuantity returns the quantity in barrels, and whether it is a number followed by a known unit.
+func parseQuantity(quantity string) (float64, bool) {
+	fields := strings.Fields(quantity)
+	if len(fields) != 2 {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(fields[0], ",", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	factor, ok := quantityUnits[fields[1]]
+	if !ok {
+		return 0, false
+	}
+	return value * factor, true
+}
@@ -0,0 +1,136 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSameQuantity(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		same bool
+	}{
+		{a: "10,000 barrels", b: "10000 bbl", same: true},
+		{a: "1,000 bbl", b: "1000 barrels", same: true},
+		{a: "158.987294928 L", b: "1 bbl", same: true},
+		{a: "1 m3", b: "6.289810770432105 bbl", same: true},
+		{a: "1000 bbl", b: "1000 L"},
+		{a: "1000 bbl", b: "1001 bbl"},
+		{a: "1000 gal", b: "1000 gal"},
+		{a: "1000", b: "1000"},
+		{a: "1000 bbl", b: ""},
+		{a: "", b: "", same: true},
+	}
+
+	for _, tt := range tests {
+		if same := sameQuantity(tt.a, tt.b); same != tt.same {
+			t.Errorf("sameQuantity(%q, %q) = %v, want %v", tt.a, tt.b, same, tt.same)
+		}
+	}
+}
+
+func TestCheckLineage(t *testing.T) {
+	found := func(name string, quantity string, certificate string) LineageStage {
+		return LineageStage{Stage: name, Found: true, OilQuantity: quantity, OilQualityCerti: certificate}
+	}
+	tests := []struct {
+		name     string
+		stages   []LineageStage
+		complete bool
+		flags    [][]string
+	}{
+		{
+			name: "complete",
+			stages: []LineageStage{
+				found("DrillToRefin", "10000 bbl", "QC-1"),
+				found("RefToStorage", "10,000 barrels", "QC-1"),
+			},
+			complete: true,
+			flags:    [][]string{nil, nil},
+		},
+		{
+			name: "missing stage",
+			stages: []LineageStage{
+				found("DrillToRefin", "10000 bbl", "QC-1"),
+				{Stage: "RefToStorage"},
+				found("StorToConsu", "10,000 barrels", "QC-1"),
+			},
+			flags: [][]string{nil, {"stage is missing"}, nil},
+		},
+		{
+			name: "quantity mismatch",
+			stages: []LineageStage{
+				found("DrillToRefin", "10000 bbl", "QC-1"),
+				found("RefToStorage", "10000 L", "QC-1"),
+			},
+			complete: true,
+			flags:    [][]string{nil, {"quantity 10000 L disagrees with DrillToRefin quantity 10000 bbl"}},
+		},
+		{
+			name: "unknown unit",
+			stages: []LineageStage{
+				found("DrillToRefin", "10000 bbl", "QC-1"),
+				found("RefToStorage", "10000 gal", "QC-1"),
+			},
+			complete: true,
+			flags:    [][]string{nil, {"quantity 10000 gal disagrees with DrillToRefin quantity 10000 bbl"}},
+		},
+		{
+			name: "certificate mismatch",
+			stages: []LineageStage{
+				found("DrillToRefin", "10000 bbl", "QC-1"),
+				found("RefToStorage", "10000 bbl", "QC-2"),
+			},
+			complete: true,
+			flags:    [][]string{nil, {"quality certificate QC-2 disagrees with DrillToRefin quality certificate QC-1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lineage := &Lineage{OilID: "OIL-1234", Stages: tt.stages}
+			checkLineage(lineage)
+			if lineage.Complete != tt.complete {
+				t.Errorf("Complete = %v, want %v", lineage.Complete, tt.complete)
+			}
+			for i, stage := range lineage.Stages {
+				if !reflect.DeepEqual(stage.Flags, tt.flags[i]) {
+					t.Errorf("flags of %s = %q, want %q", stage.Stage, stage.Flags, tt.flags[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewLineageStage(t *testing.T) {
+	records := []stageRecord{
+		StorToConsu{ID: "asset1", OilId: "OIL-1234", OilQuantity: "10,000 barrels", OilQualityCerti: "QC-1"},
+		StorToConsu{ID: "asset2", OilId: "OIL-1234", OilQuantity: "10000 bbl", OilQualityCerti: "QC-1"},
+		StorToConsu{ID: "asset3", OilId: "OIL-1234", OilQuantity: "9000 bbl", OilQualityCerti: "QC-2"},
+	}
+
+	stage, err := newLineageStage("StorToConsu", []string{"channel3", "channel4"}, records)
+	if err != nil {
+		t.Fatalf("newLineageStage() error = %v", err)
+	}
+	if !stage.Found || stage.OilQuantity != "10,000 barrels" || len(stage.Records) != 3 {
+		t.Errorf("newLineageStage() = %+v, want the three records with the quantity of the first", stage)
+	}
+	want := []string{
+		"records disagree on quantity: 10,000 barrels and 9000 bbl",
+		"records disagree on quality certificate: QC-1 and QC-2",
+	}
+	if !reflect.DeepEqual(stage.Flags, want) {
+		t.Errorf("flags = %q, want %q", stage.Flags, want)
+	}
+
+	stage, err = newLineageStage("PumpToCustom", []string{"channel5"}, nil)
+	if err != nil || stage.Found || stage.Flags != nil {
+		t.Errorf("newLineageStage() = %+v, %v, want a stage that is not found", stage, err)
+	}
+}
@@ -0,0 +1,362 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+const (
+	// The stage contracts emit this event when a receiver accepts the handover of oil batches.
+	handoverAcceptedEvent = "HandoverAccepted"
+	// Directory the reconciler keeps the last event it processed on each channel in, so a restart resumes there.
+	reconcilerCheckpointDir = "reconciler-checkpoints"
+	summaryIDPrefix         = "MAIN-"
+)
+
+// stageBatch is the lifecycle state of an oil batch on a stage contract, as returned by GetBatchState and
+// carried in the HandoverAccepted event.
+type stageBatch struct {
+	OilID        string `json:"Oil_Batch_ID"`
+	State        string `json:"State"`
+	AssetID      string `json:"Asset_ID"`
+	HandedOverAt string `json:"Handed_Over_At"`
+	AcceptedAt   string `json:"Accepted_At"`
+}
+
+// TelemetrySummary aggregates the readings of a stage asset, as returned by GetTelemetrySummary.
+type TelemetrySummary struct {
+	Count   int              `json:"Count"`
+	Metrics []*MetricSummary `json:"Metrics"`
+}
+
+// MetricSummary aggregates one metric of a telemetry series, in degrees Celsius, bar or barrels.
+type MetricSummary struct {
+	Metric  string  `json:"Metric"`
+	Unit    string  `json:"Unit"`
+	Min     float64 `json:"Min"`
+	Max     float64 `json:"Max"`
+	Average float64 `json:"Average"`
+}
+
+// reconciler keeps the main chain summary of each oil batch in step with the stage contracts.
+type reconciler struct {
+	gw       *client.Gateway
+	sign     identity.Sign
+	signerID string
+	// Listeners on different channels may see the same oil batch at once, so summaries are built one at a time.
+	mu sync.Mutex
+}
+
+// runReconciler listens for accepted handovers on every stage channel and rebuilds the main chain summary of
+// each oil batch named in them from the stage contracts, until interrupted. The key of sign, whose certificate
+// is certificatePEM, is registered as signerID through adminGw, signed by an org admin.
+func runReconciler(gw *client.Gateway, adminGw *client.Gateway, sign identity.Sign, certificatePEM []byte, signerID string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := os.MkdirAll(reconcilerCheckpointDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	if err := registerSigningKeys(stageContract(adminGw, 6), certificatePEM, signerID); err != nil {
+		return err
+	}
+
+	// A listener that fails stops the others, so the reconciler exits rather than run with a channel missing.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r := &reconciler{gw: gw, sign: sign, signerID: signerID}
+	var wg sync.WaitGroup
+	var failOnce sync.Once
+	var failure error
+	for channel := 1; channel <= 5; channel++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.listen(ctx, channel); err != nil {
+				failOnce.Do(func() {
+					failure = fmt.Errorf("reconciler on %s stopped: %w", channelName(channel), err)
+				})
+				cancel()
+			}
+		}()
+	}
+	log.Println("*** Reconciling the main chain, press Ctrl+C to stop")
+	wg.Wait()
+	return failure
+}
+
+// listen reconciles the oil batches of the HandoverAccepted events on one stage channel. The event stream
+// starts after the checkpointed event, so events missed while the reconciler was down are replayed. An event
+// is only checkpointed once all of its oil batches are reconciled: the last stage of a batch has no later
+// handover to rebuild its summary, so listen stops on a failure and the event is replayed on restart.
+func (r *reconciler) listen(ctx context.Context, channel int) error {
+	checkpointer, err := client.NewFileCheckpointer(filepath.Join(reconcilerCheckpointDir, channelName(channel)+".json"))
+	if err != nil {
+		return err
+	}
+	defer checkpointer.Close()
+
+	network := r.gw.GetNetwork(channelName(channel))
+	events, err := network.ChaincodeEvents(ctx, chaincodeName(channel), client.WithCheckpoint(checkpointer), client.WithStartBlock(0))
+	if err != nil {
+		return fmt.Errorf("failed to start chaincode event listening: %w", err)
+	}
+
+	for event := range events {
+		if event.EventName == handoverAcceptedEvent {
+			var batches []stageBatch
+			if err := json.Unmarshal(event.Payload, &batches); err != nil {
+				return fmt.Errorf("failed to parse %s event of transaction %s: %w", event.EventName, event.TransactionID, err)
+			}
+			for _, batch := range batches {
+				if err := r.reconcile(batch.OilID); err != nil {
+					return fmt.Errorf("failed to reconcile oil batch %s of transaction %s: %s", batch.OilID, event.TransactionID, errorMessage(err))
+				}
+			}
+		}
+		if err := checkpointer.CheckpointChaincodeEvent(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcile builds the main chain summary of the oil batch from the stages it has passed and submits it.
+func (r *reconciler) reconcile(oilID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	summary, err := buildSummary(r.gw, oilID)
+	if err != nil {
+		return err
+	}
+	summary.signHandover(r.sign, r.signerID)
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	if _, err := submitWithRetry(stageContract(r.gw, 6), "UpdateSummary", string(summaryJSON)); err != nil {
+		return err
+	}
+	log.Printf("*** Main chain summary %s of oil batch %s updated", summary.ID, oilID)
+	return nil
+}
+
+// buildSummary reads the records of the oil batch on each stage it has reached and summarizes them. The
+// time to complete runs from the handover of the drilled batch to the latest handover accepted since.
+func buildSummary(gw *client.Gateway, oilID string) (*MainChain, error) {
+	summary := &MainChain{ID: summaryIDPrefix + oilID, OilId: oilID, IotData: []Telemetry{}}
+	var bills []Bills
+
+	var drill DrillToRefin
+	drillBatch, err := readStageRecord(gw, 1, oilID, &drill)
+	if err != nil {
+		return nil, err
+	}
+	if drillBatch == nil {
+		return nil, fmt.Errorf("the oil batch %s has not been handed over by the driller", oilID)
+	}
+	summary.Driller = Drilling{Name: drill.Driller_Name, Payment: drill.Bill.TotalPayment, Date: drill.Date}
+	summary.OilQualityCerti = drill.OilQualityCerti
+	summary.OilQuantity = quantityReading(drill.IoTData)
+	summary.IotData = append(summary.IotData, drill.IoTData)
+	bills = append(bills, drill.Bill)
+	batches := []*stageBatch{drillBatch}
+
+	var refin RefToStorage
+	refinBatch, err := readStageRecord(gw, 2, oilID, &refin)
+	if err != nil {
+		return nil, err
+	}
+	if refinBatch != nil {
+		drillTelemetry, err := realTimeSummary(gw, 1, drillBatch)
+		if err != nil {
+			return nil, err
+		}
+		summary.Refinery = Refineries{Name: refin.Name, Payment: refin.Bill.TotalPayment, Date: refin.Bill.Date, RealTimeSum: drillTelemetry}
+		summary.OilQualityCerti = refin.OilQualityCerti
+		summary.OilQuantity = quantityReading(refin.IoTData)
+		summary.IotData = append(summary.IotData, refin.IoTData)
+		bills = append(bills, refin.Bill)
+		batches = append(batches, refinBatch)
+
+		var factory StorToFactory
+		factoryBatch, err := readStageRecord(gw, 3, oilID, &factory)
+		if err != nil {
+			return nil, err
+		}
+		var storPump StorToPump
+		storPumpBatch, err := readStageRecord(gw, 4, oilID, &storPump)
+		if err != nil {
+			return nil, err
+		}
+
+		refinTelemetry, err := realTimeSummary(gw, 2, refinBatch)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case factoryBatch != nil:
+			factoryTelemetry, err := realTimeSummary(gw, 3, factoryBatch)
+			if err != nil {
+				return nil, err
+			}
+			summary.Storage = Storages{Name: refin.FacilityName, Payment: factory.Bill.TotalPayment, Date: factory.Bill.Date, RealTimeSum: refinTelemetry}
+			summary.Consumer = Consumers{Name: factory.PumpName, Date: factory.Bill.Date, RealTimeSum: factoryTelemetry}
+			summary.OilQuantity = quantityReading(factory.IotData)
+			summary.ComplianceReport = complianceReport(factory.Compliance)
+			summary.IotData = append(summary.IotData, factory.IotData)
+			bills = append(bills, factory.Bill)
+			batches = append(batches, factoryBatch)
+		case storPumpBatch != nil:
+			storPumpTelemetry, err := realTimeSummary(gw, 4, storPumpBatch)
+			if err != nil {
+				return nil, err
+			}
+			summary.Storage = Storages{Name: refin.FacilityName, Payment: storPump.Bill.TotalPayment, Date: storPump.Bill.Date, RealTimeSum: refinTelemetry}
+			summary.Consumer = Consumers{Name: storPump.FacilityName, Date: storPump.Bill.Date, RealTimeSum: storPumpTelemetry}
+			summary.OilQuantity = quantityReading(storPump.IotData)
+			summary.ComplianceReport = complianceReport(storPump.Compliance)
+			summary.IotData = append(summary.IotData, storPump.IotData)
+			bills = append(bills, storPump.Bill)
+			batches = append(batches, storPumpBatch)
+
+			var pump PumpToCustom
+			pumpBatch, err := readStageRecord(gw, 5, oilID, &pump)
+			if err != nil {
+				return nil, err
+			}
+			if pumpBatch != nil {
+				pumpTelemetry, err := realTimeSummary(gw, 5, pumpBatch)
+				if err != nil {
+					return nil, err
+				}
+				summary.Consumer = Consumers{Name: pump.ConsumerName, Payment: pump.Bill.TotalPayment, Date: pump.Bill.Date, RealTimeSum: pumpTelemetry}
+				summary.OilQuantity = quantityReading(pump.IotData)
+				summary.IotData = append(summary.IotData, pump.IotData)
+				bills = append(bills, pump.Bill)
+				batches = append(batches, pumpBatch)
+			}
+		}
+	}
+
+	total := 0
+	for _, bill := range bills {
+		amount, err := billAmount(bill.TotalPayment)
+		if err != nil {
+			return nil, err
+		}
+		total += amount
+	}
+	summary.Payment = formatAmount(total)
+
+	timeToComplete, err := timeToComplete(batches)
+	if err != nil {
+		return nil, err
+	}
+	summary.Time = timeToComplete
+	return summary, nil
+}
+
+// readStageRecord reads the lifecycle state of the oil batch on a stage and the asset handed over with it
+// into record. It returns nil when the oil batch has not reached the stage.
+func readStageRecord(gw *client.Gateway, channel int, oilID string, record any) (*stageBatch, error) {
+	contract := stageContract(gw, channel)
+	batchJSON, err := contract.EvaluateTransaction("GetBatchState", oilID)
+	if err != nil {
+		if strings.Contains(errorMessage(err), "does not exist") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read oil batch %s on %s: %w", oilID, channelName(channel), err)
+	}
+	var batch stageBatch
+	if err := json.Unmarshal(batchJSON, &batch); err != nil {
+		return nil, fmt.Errorf("failed to parse batch state: %w", err)
+	}
+	if batch.AssetID == "" {
+		return nil, nil
+	}
+
+	assetJSON, err := contract.EvaluateTransaction("ReadAsset", batch.AssetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset %s on %s: %w", batch.AssetID, channelName(channel), err)
+	}
+	if err := json.Unmarshal(assetJSON, record); err != nil {
+		return nil, fmt.Errorf("failed to parse asset: %w", err)
+	}
+	return &batch, nil
+}
+
+// realTimeSummary summarizes the readings taken while the asset handed over with the oil batch on a stage was
+// shipped, as the range and average of each metric. It is empty when no reading was taken.
+func realTimeSummary(gw *client.Gateway, channel int, batch *stageBatch) (string, error) {
+	summaryJSON, err := stageContract(gw, channel).EvaluateTransaction("GetTelemetrySummary", batch.AssetID, "", "")
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize the readings of asset %s on %s: %w", batch.AssetID, channelName(channel), err)
+	}
+	var summary TelemetrySummary
+	if err := json.Unmarshal(summaryJSON, &summary); err != nil {
+		return "", fmt.Errorf("failed to parse telemetry summary: %w", err)
+	}
+	if summary.Count == 0 {
+		return "", nil
+	}
+
+	metrics := make([]string, 0, len(summary.Metrics))
+	for _, metric := range summary.Metrics {
+		metrics = append(metrics, fmt.Sprintf("%s %.4g to %.4g %s, average %.4g", metric.Metric, metric.Min, metric.Max, metric.Unit, metric.Average))
+	}
+	return fmt.Sprintf("%s over %d readings", strings.Join(metrics, "; "), summary.Count), nil
+}
+
+// quantityReading returns the quantity measured by the reading, or an empty string when it has none.
+func quantityReading(telemetry Telemetry) string {
+	if telemetry.Quantity.Unit == "" {
+		return ""
+	}
+	return telemetry.Quantity.String()
+}
+
+// timeToComplete returns the time from the first handover of the stages to the latest accepted one.
+func timeToComplete(batches []*stageBatch) (string, error) {
+	start, err := time.Parse(time.RFC3339, batches[0].HandedOverAt)
+	if err != nil {
+		return "", fmt.Errorf("the oil batch %s has no handover time: %w", batches[0].OilID, err)
+	}
+	end := start
+	for _, batch := range batches {
+		if batch.AcceptedAt == "" {
+			continue
+		}
+		acceptedAt, err := time.Parse(time.RFC3339, batch.AcceptedAt)
+		if err != nil {
+			return "", fmt.Errorf("the oil batch %s has an invalid acceptance time: %w", batch.OilID, err)
+		}
+		if acceptedAt.After(end) {
+			end = acceptedAt
+		}
+	}
+	return end.Sub(start).String(), nil
+}
+
+func complianceReport(compliance Env) string {
+	return fmt.Sprintf("Temperature: %s, Pressure: %s", compliance.Temperature, compliance.Pressure)
+}
@@ -0,0 +1,110 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// The token-erc-20 contract bills are paid with. It is deployed on the main chain channel.
+const tokenChaincode = "token_erc20"
+
+// Settlement is the payment that settled the bill of an oil batch's handover on a stage.
+type Settlement struct {
+	Channel     string `json:"Channel"`
+	OilID       string `json:"Oil_Batch_ID"`
+	BillNumber  string `json:"Bill_Number"`
+	Payee       string `json:"Payee"`
+	Amount      int    `json:"Amount"`
+	PaymentTxID string `json:"Payment_Tx_ID"`
+}
+
+// settleBill pays the bill of the oil batch's handover on a stage with the token contract, and then
+// settles the bill on the stage contract with the payment. The client must be the receiver that accepted
+// the handover.
+func settleBill(gw *client.Gateway, channel int, oilID string) (*Settlement, error) {
+	contract := stageContract(gw, channel)
+
+	batchJSON, err := contract.EvaluateTransaction("GetBatchState", oilID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+	var batch struct {
+		AssetID string `json:"Asset_ID"`
+	}
+	if err := json.Unmarshal(batchJSON, &batch); err != nil {
+		return nil, fmt.Errorf("failed to parse batch state: %w", err)
+	}
+
+	assetJSON, err := contract.EvaluateTransaction("ReadAsset", batch.AssetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+	var asset struct {
+		Bill Bills `json:"Bill"`
+	}
+	if err := json.Unmarshal(assetJSON, &asset); err != nil {
+		return nil, fmt.Errorf("failed to parse asset: %w", err)
+	}
+	amount, err := billAmount(asset.Bill.TotalPayment)
+	if err != nil {
+		return nil, err
+	}
+
+	token := gw.GetNetwork(channelName(6)).GetContract(tokenChaincode)
+	_, commit, err := token.SubmitAsync("Pay", client.WithArguments(asset.Bill.Payee, strconv.Itoa(amount), asset.Bill.BillNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit transaction: %w", err)
+	}
+	status, err := commit.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit status: %w", err)
+	}
+	if !status.Successful {
+		return nil, fmt.Errorf("transaction %s failed to commit with status: %d", status.TransactionID, int32(status.Code))
+	}
+	log.Printf("*** Paid %d for bill %s in transaction %s", amount, asset.Bill.BillNumber, status.TransactionID)
+
+	_, err = contract.SubmitTransaction("SettleBill", oilID, status.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit transaction: %w", err)
+	}
+	return &Settlement{
+		Channel:     channelName(channel),
+		OilID:       oilID,
+		BillNumber:  asset.Bill.BillNumber,
+		Payee:       asset.Bill.Payee,
+		Amount:      amount,
+		PaymentTxID: status.TransactionID,
+	}, nil
+}
+
+// billAmount returns a bill total, written like "$ 110,000", as a whole number of tokens.
+func billAmount(totalPayment string) (int, error) {
+	amount, err := strconv.Atoi(strings.NewReplacer("$", "", ",", "", " ", "").Replace(totalPayment))
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("the bill total %q is not a whole number of tokens", totalPayment)
+	}
+	return amount, nil
+}
+
+// formatAmount writes a number of tokens the way bill totals are written, like "$ 110,000".
+func formatAmount(amount int) string {
+	digits := strconv.Itoa(amount)
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	return "$ " + grouped.String()
+}
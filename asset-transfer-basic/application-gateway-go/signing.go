@@ -0,0 +1,100 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// signerIDOf returns the key ID under which this client's certificate is registered with the stage contracts:
+// the signerID of the profile, or the MSP ID and common name of the certificate. The loader simulates the IoT
+// devices, so the device keys are registered with the same certificate.
+func signerIDOf(profile *Profile, certificatePEM []byte) (string, error) {
+	if profile.SignerID != "" {
+		return profile.SignerID, nil
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	if certificate.Subject.CommonName == "" {
+		return "", fmt.Errorf("the certificate has no common name, set the signerID of the profile")
+	}
+	return profile.MSPID + "/" + certificate.Subject.CommonName, nil
+}
+
+// signPayload returns the base64 encoded ECDSA signature over the SHA-256 digest of the payload's JSON.
+func signPayload(sign identity.Sign, payload any) string {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		panic(fmt.Errorf("failed to marshal signed payload: %w", err))
+	}
+	digest := sha256.Sum256(payloadJSON)
+	signature, err := sign(digest[:])
+	if err != nil {
+		panic(fmt.Errorf("failed to sign payload: %w", err))
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+// signReading signs the reading as its device: the signature covers the reading without its signature.
+func (t *Telemetry) signReading(sign identity.Sign) {
+	t.Signature = ""
+	t.Signature = signPayload(sign, *t)
+}
+
+// signHandover signs the handover document, which is the asset without its digital signature and IoT reading,
+// as signerID.
+func (a *DrillToRefin) signHandover(sign identity.Sign, signerID string) {
+	a.SignerID = signerID
+	a.DigitalSignature = ""
+	payload := *a
+	payload.IoTData = Telemetry{}
+	a.DigitalSignature = signPayload(sign, payload)
+}
+
+func (a *RefToStorage) signHandover(sign identity.Sign, signerID string) {
+	a.SignerID = signerID
+	a.DigitalSignature = ""
+	payload := *a
+	payload.IoTData = Telemetry{}
+	a.DigitalSignature = signPayload(sign, payload)
+}
+
+func (a *MainChain) signHandover(sign identity.Sign, signerID string) {
+	a.SignerID = signerID
+	a.DigitalSignature = ""
+	payload := *a
+	payload.IotData = nil
+	a.DigitalSignature = signPayload(sign, payload)
+}
+
+// registerSigningKeys registers this client's certificate for every key ID that is not yet known to the contract.
+// Only org admins can register keys, so the contract must be connected with an admin identity.
+func registerSigningKeys(contract *client.Contract, certificatePEM []byte, ids ...string) error {
+	registered := make(map[string]bool)
+	for _, id := range ids {
+		if registered[id] {
+			continue
+		}
+		registered[id] = true
+
+		if _, err := contract.EvaluateTransaction("ReadDevice", id); err == nil {
+			continue
+		}
+		log.Printf("--> Submit Transaction: RegisterDevice, registers signing key %s", id)
+		if _, err := contract.SubmitTransaction("RegisterDevice", id, string(certificatePEM)); err != nil {
+			return fmt.Errorf("failed to register signing key %s: %w", id, err)
+		}
+	}
+	return nil
+}
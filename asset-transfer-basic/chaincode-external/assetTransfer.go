@@ -40,6 +40,13 @@ type QueryResult struct {
 	Record *Asset
 }
 
+// PaginatedQueryResult structure used for returning paginated query results and metadata
+type PaginatedQueryResult struct {
+	Records             []QueryResult `json:"records"`
+	FetchedRecordsCount int32         `json:"fetchedRecordsCount"`
+	Bookmark            string        `json:"bookmark"`
+}
+
 // InitLedger adds a base set of cars to the ledger
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
 	assets := []Asset{
@@ -215,6 +222,41 @@ func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface
 	return results, nil
 }
 
+// GetAssetsPage returns a page of at most pageSize assets found in world state, starting at the bookmark
+// of the previous page. An empty bookmark in the result means every asset has been read.
+// Paginated queries are only valid for read only transactions.
+func (s *SmartContract) GetAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	if pageSize < 1 {
+		return nil, fmt.Errorf("the page size must be at least 1")
+	}
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", int32(pageSize), bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	results := []QueryResult{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var asset Asset
+		err = json.Unmarshal(queryResponse.Value, &asset)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, QueryResult{Key: queryResponse.Key, Record: &asset})
+	}
+
+	return &PaginatedQueryResult{
+		Records:             results,
+		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
+		Bookmark:            responseMetadata.Bookmark,
+	}, nil
+}
+
 func main() {
 	// See chaincode.env.example
 	config := serverConfig{
@@ -16,6 +16,10 @@ func main() {
 	if err != nil {
 		log.Panicf("Error creating asset-transfer-basic chaincode: %v", err)
 	}
+	// Published with the contract metadata, which clients read through org.hyperledger.fabric:GetMetadata.
+	assetChaincode.Info.Title = "basic_channel1"
+	assetChaincode.Info.Description = "Drill to refinery stage of the oil supply chain"
+	assetChaincode.Info.Version = "1.0.0"
 
 	if err := assetChaincode.Start(); err != nil {
 		log.Panicf("Error starting asset-transfer-basic chaincode: %v", err)
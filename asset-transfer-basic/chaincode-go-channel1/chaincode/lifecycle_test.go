@@ -0,0 +1,98 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrillBatch(t *testing.T) {
+	transactionContext := newTransactionContext(newWorldStateStub(map[string][]byte{}), myOrg1Msp)
+
+	assetTransfer := SmartContract{}
+	_, err := assetTransfer.GetBatchState(transactionContext, "OIL-1234")
+	require.EqualError(t, err, "the oil batch OIL-1234 does not exist")
+
+	err = assetTransfer.DrillBatch(transactionContext, "OIL-1234")
+	require.NoError(t, err)
+
+	batch, err := assetTransfer.GetBatchState(transactionContext, "OIL-1234")
+	require.NoError(t, err)
+	require.Equal(t, &BatchState{OilID: "OIL-1234", State: "Drilled", Custodian: myOrg1Msp}, batch)
+
+	err = assetTransfer.DrillBatch(transactionContext, "OIL-1234")
+	require.EqualError(t, err, "the oil batch OIL-1234 already exists")
+}
+
+func TestHandOver(t *testing.T) {
+	chaincodeStub := newWorldStateStub(map[string][]byte{})
+	transactionContext := newTransactionContext(chaincodeStub, myOrg1Msp)
+
+	err := handOver(transactionContext, "OIL-1234", "asset1", myOrg2Msp)
+	require.EqualError(t, err, "the oil batch OIL-1234 does not exist")
+
+	assetTransfer := SmartContract{}
+	require.NoError(t, assetTransfer.DrillBatch(transactionContext, "OIL-1234"))
+
+	err = handOver(newTransactionContext(chaincodeStub, myOrg2Msp), "OIL-1234", "asset1", myOrg2Msp)
+	require.EqualError(t, err, "client from Org2Testmsp is not the custodian of oil batch OIL-1234, Org1Testmsp is")
+
+	err = handOver(transactionContext, "OIL-1234", "asset1", "")
+	require.EqualError(t, err, "the receiver of oil batch OIL-1234 is not set")
+
+	err = handOver(transactionContext, "OIL-1234", "asset1", myOrg2Msp)
+	require.NoError(t, err)
+
+	batch, err := assetTransfer.GetBatchState(transactionContext, "OIL-1234")
+	require.NoError(t, err)
+	require.Equal(t, &BatchState{OilID: "OIL-1234", State: "InTransitToRefinery", Custodian: myOrg1Msp, AssetID: "asset1", Receiver: myOrg2Msp, HandedOverAt: txTimestamp}, batch)
+
+	err = handOver(transactionContext, "OIL-1234", "asset2", myOrg2Msp)
+	require.EqualError(t, err, "the oil batch OIL-1234 is InTransitToRefinery, not Drilled")
+}
+
+func TestAcceptHandover(t *testing.T) {
+	chaincodeStub := newWorldStateStub(map[string][]byte{})
+	transactionContext := newTransactionContext(chaincodeStub, myOrg1Msp)
+
+	assetTransfer := SmartContract{}
+	require.NoError(t, assetTransfer.DrillBatch(transactionContext, "OIL-1234"))
+
+	err := assetTransfer.AcceptHandover(transactionContext, "OIL-1234")
+	require.EqualError(t, err, "the oil batch OIL-1234 is Drilled, not InTransitToRefinery")
+
+	require.NoError(t, handOver(transactionContext, "OIL-1234", "asset1", myOrg2Msp))
+	err = assetTransfer.AcceptHandover(transactionContext, "OIL-1234")
+	require.EqualError(t, err, "client from Org1Testmsp is not the receiver of oil batch OIL-1234, Org2Testmsp is")
+
+	err = assetTransfer.AcceptHandover(newTransactionContext(chaincodeStub, myOrg2Msp), "OIL-1234")
+	require.NoError(t, err)
+
+	batch, err := assetTransfer.GetBatchState(transactionContext, "OIL-1234")
+	require.NoError(t, err)
+	require.Equal(t, &BatchState{OilID: "OIL-1234", State: "Refined", Custodian: myOrg2Msp, AssetID: "asset1", Receiver: myOrg2Msp, HandedOverAt: txTimestamp, AcceptedAt: txTimestamp}, batch)
+
+	eventName, eventPayload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "HandoverAccepted", eventName)
+	var accepted []*BatchState
+	require.NoError(t, json.Unmarshal(eventPayload, &accepted))
+	require.Equal(t, []*BatchState{batch}, accepted)
+}
+
+func TestDrillBatches(t *testing.T) {
+	transactionContext := newTransactionContext(newWorldStateStub(map[string][]byte{}), myOrg1Msp)
+
+	assetTransfer := SmartContract{}
+	err := assetTransfer.DrillBatches(transactionContext, []string{})
+	require.EqualError(t, err, "the batch is empty")
+
+	err = assetTransfer.DrillBatches(transactionContext, []string{"OIL-1234", "OIL-1234"})
+	require.EqualError(t, err, "oil batch 1: the oil batch OIL-1234 appears more than once in the batch")
+
+	err = assetTransfer.DrillBatches(transactionContext, []string{"OIL-5678", "OIL-9012"})
+	require.NoError(t, err)
+
+	err = assetTransfer.DrillBatches(transactionContext, []string{"OIL-3456", "OIL-9012"})
+	require.EqualError(t, err, "oil batch 1 (OIL-9012): the oil batch OIL-9012 already exists")
+}
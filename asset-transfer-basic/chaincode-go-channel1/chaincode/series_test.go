@@ -0,0 +1,117 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// newSeriesIterator returns an iterator over the given readings of asset1, keyed as in world state.
+func newSeriesIterator(t *testing.T, readings ...Telemetry) *mocks.StateQueryIterator {
+	iterator := &mocks.StateQueryIterator{}
+	for i, reading := range readings {
+		timestamp, err := timestampKey(reading.Timestamp)
+		require.NoError(t, err)
+		key, err := shim.CreateCompositeKey(telemetryIndex, []string{"asset1", timestamp, reading.DeviceID})
+		require.NoError(t, err)
+		value, err := json.Marshal(reading)
+		require.NoError(t, err)
+
+		iterator.HasNextReturnsOnCall(i, true)
+		iterator.NextReturnsOnCall(i, &queryresult.KV{Key: key, Value: value}, nil)
+	}
+	iterator.HasNextReturnsOnCall(len(readings), false)
+	return iterator
+}
+
+func newSeriesStub() *mocks.ChaincodeStub {
+	chaincodeStub := &mocks.ChaincodeStub{}
+	chaincodeStub.CreateCompositeKeyStub = shim.CreateCompositeKey
+	chaincodeStub.SplitCompositeKeyStub = (&shim.ChaincodeStub{}).SplitCompositeKey
+	return chaincodeStub
+}
+
+func newReading(timestamp string, temperature Reading) Telemetry {
+	telemetry := newTelemetry()
+	telemetry.Timestamp = timestamp
+	telemetry.Temperature = temperature
+	return telemetry
+}
+
+func TestGetTelemetry(t *testing.T) {
+	first := newReading("2024-12-13T08:00:00Z", Reading{Value: 20, Unit: "C"})
+	second := newReading("2024-12-13T09:00:00Z", Reading{Value: 25, Unit: "C"})
+	third := newReading("2024-12-13T10:00:00Z", Reading{Value: 30, Unit: "C"})
+
+	chaincodeStub := newSeriesStub()
+	chaincodeStub.GetStateByPartialCompositeKeyWithPaginationReturns(newSeriesIterator(t, first, second), &peer.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "next"}, nil)
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	assetTransfer := SmartContract{}
+	page, err := assetTransfer.GetTelemetry(transactionContext, "asset1", "", "", 2, "")
+	require.NoError(t, err)
+	require.Equal(t, &TelemetryPage{Records: []*Telemetry{&first, &second}, FetchedRecordsCount: 2, Bookmark: "next"}, page)
+
+	index, attributes, pageSize, bookmark := chaincodeStub.GetStateByPartialCompositeKeyWithPaginationArgsForCall(0)
+	require.Equal(t, "telemetry~asset~timestamp~device", index)
+	require.Equal(t, []string{"asset1"}, attributes)
+	require.Equal(t, int32(2), pageSize)
+	require.Equal(t, "", bookmark)
+
+	chaincodeStub.GetStateByPartialCompositeKeyWithPaginationReturns(newSeriesIterator(t, second, third), &peer.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "next"}, nil)
+	page, err = assetTransfer.GetTelemetry(transactionContext, "asset1", "2024-12-13T09:00:00+00:00", "2024-12-13T09:30:00Z", 2, "")
+	require.NoError(t, err)
+	require.Equal(t, &TelemetryPage{Records: []*Telemetry{&second}, FetchedRecordsCount: 1, Bookmark: ""}, page)
+
+	fromKey, err := shim.CreateCompositeKey("telemetry~asset~timestamp~device", []string{"asset1", "2024-12-13T09:00:00.000000000Z"})
+	require.NoError(t, err)
+	_, _, _, bookmark = chaincodeStub.GetStateByPartialCompositeKeyWithPaginationArgsForCall(1)
+	require.Equal(t, fromKey, bookmark)
+
+	_, err = assetTransfer.GetTelemetry(transactionContext, "asset1", "yesterday", "", 2, "")
+	require.ErrorContains(t, err, `from "yesterday" is not RFC 3339`)
+
+	_, err = assetTransfer.GetTelemetry(transactionContext, "asset1", "2024-12-13T10:00:00Z", "2024-12-13T09:00:00Z", 2, "")
+	require.EqualError(t, err, "from 2024-12-13T10:00:00Z is after to 2024-12-13T09:00:00Z")
+
+	chaincodeStub.GetStateByPartialCompositeKeyWithPaginationReturns(nil, nil, fmt.Errorf("failed retrieving readings"))
+	_, err = assetTransfer.GetTelemetry(transactionContext, "asset1", "", "", 2, "")
+	require.EqualError(t, err, "failed retrieving readings")
+}
+
+func TestGetTelemetrySummary(t *testing.T) {
+	first := newReading("2024-12-13T08:00:00Z", Reading{Value: 68, Unit: "F"})
+	first.Pressure = Reading{Value: 1000, Unit: "kPa"}
+	second := newReading("2024-12-13T09:00:00Z", Reading{Value: 30, Unit: "C"})
+	second.Quantity = Reading{Value: 0, Unit: "L"}
+
+	chaincodeStub := newSeriesStub()
+	chaincodeStub.GetStateByPartialCompositeKeyWithPaginationReturnsOnCall(0, newSeriesIterator(t, first), &peer.QueryResponseMetadata{FetchedRecordsCount: 1, Bookmark: "next"}, nil)
+	chaincodeStub.GetStateByPartialCompositeKeyWithPaginationReturnsOnCall(1, newSeriesIterator(t, second), &peer.QueryResponseMetadata{FetchedRecordsCount: 1}, nil)
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	assetTransfer := SmartContract{}
+	summary, err := assetTransfer.GetTelemetrySummary(transactionContext, "asset1", "", "")
+	require.NoError(t, err)
+	require.Equal(t, 2, summary.Count)
+	require.Len(t, summary.Metrics, 3)
+	require.Equal(t, MetricSummary{Metric: "Temperature", Unit: "C", Min: 20, Max: 30, Average: 25}, *summary.Metrics[0])
+	require.Equal(t, MetricSummary{Metric: "Pressure", Unit: "bar", Min: 10, Max: 10, Average: 10}, *summary.Metrics[1])
+	require.Equal(t, MetricSummary{Metric: "Quantity", Unit: "bbl", Min: 0, Max: 10000, Average: 5000}, *summary.Metrics[2])
+
+	_, _, _, bookmark := chaincodeStub.GetStateByPartialCompositeKeyWithPaginationArgsForCall(1)
+	require.Equal(t, "next", bookmark)
+
+	chaincodeStub.GetStateByPartialCompositeKeyWithPaginationReturnsOnCall(2, newSeriesIterator(t), &peer.QueryResponseMetadata{}, nil)
+	summary, err = assetTransfer.GetTelemetrySummary(transactionContext, "asset1", "", "")
+	require.NoError(t, err)
+	require.Equal(t, &TelemetrySummary{AssetID: "asset1", Metrics: []*MetricSummary{}}, summary)
+}
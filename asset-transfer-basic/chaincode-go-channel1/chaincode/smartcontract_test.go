@@ -1,12 +1,21 @@
 package chaincode_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
 	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-contract-api-go/v2/metadata"
 	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
 	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
 	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
@@ -28,65 +37,297 @@ type stateQueryIterator interface {
 	shim.StateQueryIteratorInterface
 }
 
-func TestInitLedger(t *testing.T) {
+//go:generate counterfeiter -o mocks/clientIdentity.go -fake-name ClientIdentity . clientIdentity
+type clientIdentity interface {
+	cid.ClientIdentity
+}
+
+func newChaincodeStub() *mocks.ChaincodeStub {
 	chaincodeStub := &mocks.ChaincodeStub{}
-	transactionContext := &mocks.TransactionContext{}
-	transactionContext.GetStubReturns(chaincodeStub)
+	chaincodeStub.CreateCompositeKeyStub = shim.CreateCompositeKey
+	chaincodeStub.SplitCompositeKeyStub = func(key string) (string, []string, error) {
+		return (&shim.ChaincodeStub{}).SplitCompositeKey(key)
+	}
+	return chaincodeStub
+}
 
-	assetTransfer := chaincode.SmartContract{}
-	err := assetTransfer.InitLedger(transactionContext)
+func newTelemetry() chaincode.Telemetry {
+	return chaincode.Telemetry{
+		DeviceID:    "IOT-D001",
+		Timestamp:   "2024-12-13T08:00:00Z",
+		Temperature: chaincode.Reading{Value: 25, Unit: "C"},
+		Pressure:    chaincode.Reading{Value: 10, Unit: "bar"},
+		Quantity:    chaincode.Reading{Value: 10000, Unit: "bbl"},
+		Quality:     "Grade A",
+		Coordinates: chaincode.Coordinates{Latitude: 29.7604, Longitude: -95.3698},
+	}
+}
+
+// registerSigningKey stores a freshly generated key for the device in the world state and returns its private key.
+func registerSigningKey(t *testing.T, state map[string][]byte, deviceID string) *ecdsa.PrivateKey {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	require.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+
+	device := chaincode.Device{
+		ID:         deviceID,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})),
+		Owner:      "Org1MSP",
+		KeyVersion: 1,
+	}
+	deviceJSON, err := json.Marshal(device)
+	require.NoError(t, err)
+	deviceKey, err := shim.CreateCompositeKey("device", []string{deviceID})
+	require.NoError(t, err)
+	state[deviceKey] = deviceJSON
+	return privateKey
+}
+
+// sign returns the base64 encoded signature over the JSON of the payload.
+func sign(t *testing.T, privateKey *ecdsa.PrivateKey, payload any) string {
+	payloadJSON, err := json.Marshal(payload)
+	require.NoError(t, err)
+	digest := sha256.Sum256(payloadJSON)
+	signature, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func newSignedTelemetry(t *testing.T, privateKey *ecdsa.PrivateKey) chaincode.Telemetry {
+	telemetry := newTelemetry()
+	telemetry.Signature = sign(t, privateKey, telemetry)
+	return telemetry
+}
 
-	chaincodeStub.PutStateReturns(fmt.Errorf("failed inserting key"))
-	err = assetTransfer.InitLedger(transactionContext)
-	require.EqualError(t, err, "failed to put to world state. failed inserting key")
+// drillBatch records a drilled oil batch of Org1MSP in the world state.
+func drillBatch(t *testing.T, state map[string][]byte, oilID string) {
+	batchJSON, err := json.Marshal(chaincode.BatchState{OilID: oilID, State: "Drilled", Custodian: "Org1MSP"})
+	require.NoError(t, err)
+	batchKey, err := shim.CreateCompositeKey("batch", []string{oilID})
+	require.NoError(t, err)
+	state[batchKey] = batchJSON
 }
 
 func TestCreateAsset(t *testing.T) {
-	chaincodeStub := &mocks.ChaincodeStub{}
+	state := map[string][]byte{}
+	chaincodeStub := newChaincodeStub()
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns("Org1MSP", nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+
+	deviceKey := registerSigningKey(t, state, "IOT-D001")
+	partyKey := registerSigningKey(t, state, "party1")
+	drillBatch(t, state, "OIL-1234")
+	telemetry := newSignedTelemetry(t, deviceKey)
+	handover := sign(t, partyKey, chaincode.Asset{ID: "asset1", OilID: "OIL-1234", Bill: chaincode.Bills{CarrierName: "Fast Transport Co."}, Receiver: "Org2MSP", SignerID: "party1"})
 
 	assetTransfer := chaincode.SmartContract{}
-	err := assetTransfer.CreateAsset(transactionContext, "", "", 0, "", 0)
+	err := assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset1", OilID: "OIL-1234", Bill: chaincode.Bills{CarrierName: "Fast Transport Co."}, Receiver: "Org2MSP", SignerID: "party1", DigitalSignature: handover, IoTData: telemetry})
+	require.NoError(t, err)
+	require.Equal(t, 5, chaincodeStub.PutStateCallCount())
+
+	readingKey, err := shim.CreateCompositeKey("telemetry~asset~timestamp~device", []string{"asset1", "2024-12-13T08:00:00.000000000Z", "IOT-D001"})
+	require.NoError(t, err)
+	key, _ := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, readingKey, key)
+
+	batchKey, err := shim.CreateCompositeKey("batch", []string{"OIL-1234"})
+	require.NoError(t, err)
+	key, _ = chaincodeStub.PutStateArgsForCall(1)
+	require.Equal(t, batchKey, key)
+
+	oilBatchKey, err := shim.CreateCompositeKey("oilBatch~id", []string{"OIL-1234", "asset1"})
 	require.NoError(t, err)
+	key, _ = chaincodeStub.PutStateArgsForCall(3)
+	require.Equal(t, oilBatchKey, key)
 
+	carrierKey, err := shim.CreateCompositeKey("carrier~id", []string{"Fast Transport Co.", "asset1"})
+	require.NoError(t, err)
+	key, _ = chaincodeStub.PutStateArgsForCall(4)
+	require.Equal(t, carrierKey, key)
+
+	err = assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset2", OilID: "OIL-1234", Bill: chaincode.Bills{CarrierName: "Fast Transport Co."}, Receiver: "Org2MSP", SignerID: "party1", DigitalSignature: handover, IoTData: telemetry})
+	require.EqualError(t, err, "invalid signature from party1")
+
+	err = assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset1", OilID: "OIL-1234", Bill: chaincode.Bills{CarrierName: "Fast Transport Co."}, Receiver: "Org2MSP", SignerID: "party1", DigitalSignature: handover, IoTData: newTelemetry()})
+	require.EqualError(t, err, "invalid signature from IOT-D001")
+
+	err = assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset2", OilID: "OIL-1234", Bill: chaincode.Bills{CarrierName: "Fast Transport Co."}, Receiver: "Org2MSP", SignerID: "party1", DigitalSignature: handover, IoTData: chaincode.Telemetry{}})
+	require.EqualError(t, err, "telemetry device ID is required")
+
+	chaincodeStub.GetStateStub = nil
 	chaincodeStub.GetStateReturns([]byte{}, nil)
-	err = assetTransfer.CreateAsset(transactionContext, "asset1", "", 0, "", 0)
+	err = assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset1", OilID: "OIL-1234", Bill: chaincode.Bills{CarrierName: "Fast Transport Co."}, Receiver: "Org2MSP", SignerID: "party1", DigitalSignature: handover, IoTData: telemetry})
 	require.EqualError(t, err, "the asset asset1 already exists")
 
 	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	err = assetTransfer.CreateAsset(transactionContext, "asset1", "", 0, "", 0)
+	err = assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset1", OilID: "OIL-1234", Bill: chaincode.Bills{CarrierName: "Fast Transport Co."}, Receiver: "Org2MSP", SignerID: "party1", DigitalSignature: handover, IoTData: telemetry})
 	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
 }
 
-func TestReadAsset(t *testing.T) {
+func TestCreateAssetSchema(t *testing.T) {
+	assetChaincode, err := contractapi.NewChaincode(&chaincode.SmartContract{})
+	require.NoError(t, err)
+
 	chaincodeStub := &mocks.ChaincodeStub{}
+	chaincodeStub.GetFunctionAndParametersReturns("org.hyperledger.fabric:GetMetadata", nil)
+	response := assetChaincode.Invoke(chaincodeStub)
+	require.Equal(t, int32(shim.OK), response.Status)
+	var contractMetadata metadata.ContractChaincodeMetadata
+	err = json.Unmarshal(response.Payload, &contractMetadata)
+	require.NoError(t, err)
+	require.Contains(t, contractMetadata.Components.Schemas, "Asset")
+
+	chaincodeStub.GetFunctionAndParametersReturns("CreateAsset", []string{`{"ID":"asset1","Oil_Quantity_Typo":"12"}`})
+	response = assetChaincode.Invoke(chaincodeStub)
+	require.Equal(t, int32(shim.ERROR), response.Status)
+	require.Contains(t, response.Message, "Additional property Oil_Quantity_Typo is not allowed")
+	require.Contains(t, response.Message, "Oil_Batch_ID is required")
+}
+
+func TestCreateAssets(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newChaincodeStub()
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns("Org1MSP", nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+
+	drillBatch(t, state, "OIL-1")
+	drillBatch(t, state, "OIL-2")
+	deviceKey := registerSigningKey(t, state, "IOT-D001")
+	partyKey := registerSigningKey(t, state, "party1")
+	newAsset := func(id string, oilID string) chaincode.Asset {
+		asset := chaincode.Asset{ID: id, OilID: oilID, Receiver: "Org2MSP", SignerID: "party1"}
+		asset.DigitalSignature = sign(t, partyKey, asset)
+		asset.IoTData = newSignedTelemetry(t, deviceKey)
+		return asset
+	}
+	assets := []chaincode.Asset{newAsset("asset1", "OIL-1"), newAsset("asset2", "OIL-2")}
 
-	expectedAsset := &chaincode.Asset{ID: "asset1"}
-	bytes, err := json.Marshal(expectedAsset)
+	assetTransfer := chaincode.SmartContract{}
+	err := assetTransfer.CreateAssets(transactionContext, assets)
+	require.NoError(t, err)
+	require.Equal(t, 10, chaincodeStub.PutStateCallCount())
+	key, _ := chaincodeStub.PutStateArgsForCall(7)
+	require.Equal(t, "asset2", key)
+
+	err = assetTransfer.CreateAssets(transactionContext, []chaincode.Asset{assets[0], assets[0]})
+	require.EqualError(t, err, "asset 1: the asset asset1 appears more than once in the batch")
+
+	err = assetTransfer.CreateAssets(transactionContext, []chaincode.Asset{assets[0], newAsset("asset3", "OIL-1")})
+	require.EqualError(t, err, "asset 1: the oil batch OIL-1 appears more than once in the batch")
+
+	forged := newAsset("asset3", "OIL-3")
+	forged.SignerID = "IOT-D001"
+	err = assetTransfer.CreateAssets(transactionContext, []chaincode.Asset{assets[0], forged})
+	require.EqualError(t, err, "asset 1 (asset3): invalid signature from IOT-D001")
+
+	err = assetTransfer.CreateAssets(transactionContext, nil)
+	require.EqualError(t, err, "the batch is empty")
+
+	drillBatch(t, state, "OIL-4")
+	drillBatch(t, state, "OIL-5")
+	newOverheatedAsset := func(id string, oilID string) chaincode.Asset {
+		asset := newAsset(id, oilID)
+		asset.IoTData = newTelemetry()
+		asset.IoTData.Temperature = chaincode.Reading{Value: 500, Unit: "C"}
+		asset.IoTData.Signature = sign(t, deviceKey, asset.IoTData)
+		return asset
+	}
+	require.Equal(t, 0, chaincodeStub.SetEventCallCount())
+	err = assetTransfer.CreateAssets(transactionContext, []chaincode.Asset{newOverheatedAsset("asset4", "OIL-4"), newOverheatedAsset("asset5", "OIL-5")})
 	require.NoError(t, err)
+	require.Equal(t, 1, chaincodeStub.SetEventCallCount())
+
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "TelemetryOutOfRange", name)
+	var events []*chaincode.OutOfRangeEvent
+	require.NoError(t, json.Unmarshal(payload, &events))
+	require.Len(t, events, 2)
+	require.Equal(t, "asset4", events[0].AssetID)
+	require.Equal(t, "asset5", events[1].AssetID)
+}
 
-	chaincodeStub.GetStateReturns(bytes, nil)
+func TestChangeIotData(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newChaincodeStub()
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		state[key] = value
+		return nil
+	}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	deviceKey := registerSigningKey(t, state, "IOT-D001")
+	batchKey, err := shim.CreateCompositeKey("batch", []string{"OIL-1234"})
+	require.NoError(t, err)
+	batchJSON, err := json.Marshal(chaincode.BatchState{OilID: "OIL-1234", State: "InTransitToRefinery", Custodian: "Org1MSP"})
+	require.NoError(t, err)
+	state[batchKey] = batchJSON
+	asset := &chaincode.Asset{ID: "asset1", OilID: "OIL-1234", IoTData: newTelemetry()}
+	bytes, err := json.Marshal(asset)
+	require.NoError(t, err)
+	state["asset1"] = bytes
+
+	later := newTelemetry()
+	later.Timestamp = "2024-12-13T09:00:00Z"
+	later.Signature = sign(t, deviceKey, later)
 	assetTransfer := chaincode.SmartContract{}
-	asset, err := assetTransfer.ReadAsset(transactionContext, "")
+	_, err = assetTransfer.ChangeIotData(transactionContext, "asset1", later)
 	require.NoError(t, err)
-	require.Equal(t, expectedAsset, asset)
 
-	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	_, err = assetTransfer.ReadAsset(transactionContext, "")
-	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
+	updated, err := assetTransfer.ReadAsset(transactionContext, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, later, updated.IoTData)
 
-	chaincodeStub.GetStateReturns(nil, nil)
-	asset, err = assetTransfer.ReadAsset(transactionContext, "asset1")
-	require.EqualError(t, err, "the asset asset1 does not exist")
-	require.Nil(t, asset)
+	earlier := newTelemetry()
+	earlier.Timestamp = "2024-12-13T07:00:00Z"
+	earlier.Signature = sign(t, deviceKey, earlier)
+	_, err = assetTransfer.ChangeIotData(transactionContext, "asset1", earlier)
+	require.NoError(t, err)
+
+	updated, err = assetTransfer.ReadAsset(transactionContext, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, later, updated.IoTData)
+
+	for _, reading := range []chaincode.Telemetry{earlier, later} {
+		readingKey, err := shim.CreateCompositeKey("telemetry~asset~timestamp~device", []string{"asset1", reading.Timestamp[:19] + ".000000000Z", "IOT-D001"})
+		require.NoError(t, err)
+		require.Contains(t, state, readingKey)
+	}
+
+	_, err = assetTransfer.ChangeIotData(transactionContext, "asset1", later)
+	require.EqualError(t, err, "the reading of IOT-D001 at 2024-12-13T09:00:00Z is already recorded for asset1")
+
+	_, err = assetTransfer.ChangeIotData(transactionContext, "asset2", later)
+	require.EqualError(t, err, "the asset asset2 does not exist")
+
+	batchJSON, err = json.Marshal(chaincode.BatchState{OilID: "OIL-1234", State: "Refined", Custodian: "Org2MSP"})
+	require.NoError(t, err)
+	state[batchKey] = batchJSON
+	latest := newTelemetry()
+	latest.Timestamp = "2024-12-13T10:00:00Z"
+	latest.Signature = sign(t, deviceKey, latest)
+	_, err = assetTransfer.ChangeIotData(transactionContext, "asset1", latest)
+	require.EqualError(t, err, "the device IOT-D001 is owned by Org1MSP, not by the custodian Org2MSP")
 }
 
-func TestUpdateAsset(t *testing.T) {
-	chaincodeStub := &mocks.ChaincodeStub{}
+func TestReadAsset(t *testing.T) {
+	chaincodeStub := newChaincodeStub()
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
 
@@ -96,32 +337,39 @@ func TestUpdateAsset(t *testing.T) {
 
 	chaincodeStub.GetStateReturns(bytes, nil)
 	assetTransfer := chaincode.SmartContract{}
-	err = assetTransfer.UpdateAsset(transactionContext, "", "", 0, "", 0)
+	asset, err := assetTransfer.ReadAsset(transactionContext, "")
 	require.NoError(t, err)
-
-	chaincodeStub.GetStateReturns(nil, nil)
-	err = assetTransfer.UpdateAsset(transactionContext, "asset1", "", 0, "", 0)
-	require.EqualError(t, err, "the asset asset1 does not exist")
+	require.Equal(t, expectedAsset, asset)
 
 	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	err = assetTransfer.UpdateAsset(transactionContext, "asset1", "", 0, "", 0)
+	_, err = assetTransfer.ReadAsset(transactionContext, "")
 	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
+
+	chaincodeStub.GetStateReturns(nil, nil)
+	asset, err = assetTransfer.ReadAsset(transactionContext, "asset1")
+	require.EqualError(t, err, "the asset asset1 does not exist")
+	require.Nil(t, asset)
 }
 
 func TestDeleteAsset(t *testing.T) {
-	chaincodeStub := &mocks.ChaincodeStub{}
+	chaincodeStub := newChaincodeStub()
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
 
-	asset := &chaincode.Asset{ID: "asset1"}
+	asset := &chaincode.Asset{ID: "asset1", OilID: "OIL-1234"}
 	bytes, err := json.Marshal(asset)
 	require.NoError(t, err)
 
 	chaincodeStub.GetStateReturns(bytes, nil)
 	chaincodeStub.DelStateReturns(nil)
 	assetTransfer := chaincode.SmartContract{}
-	err = assetTransfer.DeleteAsset(transactionContext, "")
+	err = assetTransfer.DeleteAsset(transactionContext, "asset1")
 	require.NoError(t, err)
+	require.Equal(t, 3, chaincodeStub.DelStateCallCount())
+
+	oilBatchKey, err := shim.CreateCompositeKey("oilBatch~id", []string{"OIL-1234", "asset1"})
+	require.NoError(t, err)
+	require.Equal(t, oilBatchKey, chaincodeStub.DelStateArgsForCall(1))
 
 	chaincodeStub.GetStateReturns(nil, nil)
 	err = assetTransfer.DeleteAsset(transactionContext, "asset1")
@@ -132,25 +380,6 @@ func TestDeleteAsset(t *testing.T) {
 	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
 }
 
-func TestTransferAsset(t *testing.T) {
-	chaincodeStub := &mocks.ChaincodeStub{}
-	transactionContext := &mocks.TransactionContext{}
-	transactionContext.GetStubReturns(chaincodeStub)
-
-	asset := &chaincode.Asset{ID: "asset1"}
-	bytes, err := json.Marshal(asset)
-	require.NoError(t, err)
-
-	chaincodeStub.GetStateReturns(bytes, nil)
-	assetTransfer := chaincode.SmartContract{}
-	_, err = assetTransfer.TransferAsset(transactionContext, "", "")
-	require.NoError(t, err)
-
-	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	_, err = assetTransfer.TransferAsset(transactionContext, "", "")
-	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
-}
-
 func TestGetAllAssets(t *testing.T) {
 	asset := &chaincode.Asset{ID: "asset1"}
 	bytes, err := json.Marshal(asset)
@@ -161,7 +390,7 @@ func TestGetAllAssets(t *testing.T) {
 	iterator.HasNextReturnsOnCall(1, false)
 	iterator.NextReturns(&queryresult.KV{Value: bytes}, nil)
 
-	chaincodeStub := &mocks.ChaincodeStub{}
+	chaincodeStub := newChaincodeStub()
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
 
@@ -182,3 +411,67 @@ func TestGetAllAssets(t *testing.T) {
 	require.EqualError(t, err, "failed retrieving all assets")
 	require.Nil(t, assets)
 }
+
+func TestQueryAssetsByOilBatch(t *testing.T) {
+	asset := &chaincode.Asset{ID: "asset1", OilID: "OIL-1234"}
+	bytes, err := json.Marshal(asset)
+	require.NoError(t, err)
+
+	oilBatchKey, err := shim.CreateCompositeKey("oilBatch~id", []string{"OIL-1234", "asset1"})
+	require.NoError(t, err)
+
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.HasNextReturnsOnCall(1, false)
+	iterator.NextReturns(&queryresult.KV{Key: oilBatchKey, Value: []byte{0x00}}, nil)
+
+	chaincodeStub := newChaincodeStub()
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(iterator, nil)
+	chaincodeStub.GetStateReturns(bytes, nil)
+	assetTransfer := &chaincode.SmartContract{}
+	assets, err := assetTransfer.QueryAssetsByOilBatch(transactionContext, "OIL-1234")
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.Asset{asset}, assets)
+
+	index, attributes := chaincodeStub.GetStateByPartialCompositeKeyArgsForCall(0)
+	require.Equal(t, "oilBatch~id", index)
+	require.Equal(t, []string{"OIL-1234"}, attributes)
+	require.Equal(t, "asset1", chaincodeStub.GetStateArgsForCall(0))
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(nil, fmt.Errorf("failed retrieving index"))
+	assets, err = assetTransfer.QueryAssetsByOilBatch(transactionContext, "OIL-1234")
+	require.EqualError(t, err, "failed retrieving index")
+	require.Nil(t, assets)
+}
+
+func TestQueryAssetsByCarrier(t *testing.T) {
+	asset := &chaincode.Asset{ID: "asset1", Bill: chaincode.Bills{CarrierName: "Fast Transport Co."}}
+	bytes, err := json.Marshal(asset)
+	require.NoError(t, err)
+
+	carrierKey, err := shim.CreateCompositeKey("carrier~id", []string{"Fast Transport Co.", "asset1"})
+	require.NoError(t, err)
+
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.HasNextReturnsOnCall(1, false)
+	iterator.NextReturns(&queryresult.KV{Key: carrierKey, Value: []byte{0x00}}, nil)
+
+	chaincodeStub := newChaincodeStub()
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(iterator, nil)
+	chaincodeStub.GetStateReturns(bytes, nil)
+	assetTransfer := &chaincode.SmartContract{}
+	assets, err := assetTransfer.QueryAssetsByCarrier(transactionContext, "Fast Transport Co.")
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.Asset{asset}, assets)
+
+	index, attributes := chaincodeStub.GetStateByPartialCompositeKeyArgsForCall(0)
+	require.Equal(t, "carrier~id", index)
+	require.Equal(t, []string{"Fast Transport Co."}, attributes)
+}
@@ -0,0 +1,260 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+const (
+	outOfRangeIndex = "outOfRange~asset~timestamp~metric"
+	outOfRangeEvent = "TelemetryOutOfRange"
+	// timestampKeyLayout keeps timestamps in composite keys fixed width so they sort chronologically.
+	timestampKeyLayout = "2006-01-02T15:04:05.000000000Z"
+)
+
+// Reading is a numeric sensor value together with its unit.
+type Reading struct {
+	Value float64 `json:"Value"`
+	Unit  string  `json:"Unit"`
+}
+
+// Coordinates is the GPS position of the device when the reading was taken.
+type Coordinates struct {
+	Latitude  float64 `json:"Latitude"`
+	Longitude float64 `json:"Longitude"`
+}
+
+// Telemetry is a single IoT reading reported by a device attached to a shipment. The reading is signed
+// by the device, see telemetryPayload.
+type Telemetry struct {
+	DeviceID    string      `json:"Device_ID"`
+	Timestamp   string      `json:"Timestamp"`
+	Temperature Reading     `json:"Temperature"`
+	Pressure    Reading     `json:"Pressure"`
+	Quantity    Reading     `json:"Quantity"`
+	Quality     string      `json:"Quality"`
+	Location    string      `json:"Location" metadata:",optional"`
+	Coordinates Coordinates `json:"Coordinates"`
+	Signature   string      `json:"Signature,omitempty" metadata:",optional"`
+}
+
+// TelemetryLimits are the operating limits of a stage, in degrees Celsius and bar.
+type TelemetryLimits struct {
+	MinTemperature float64 `json:"Min_Temperature"`
+	MaxTemperature float64 `json:"Max_Temperature"`
+	MinPressure    float64 `json:"Min_Pressure"`
+	MaxPressure    float64 `json:"Max_Pressure"`
+}
+
+// OutOfRangeEvent records a reading that fell outside the stage limits.
+type OutOfRangeEvent struct {
+	AssetID   string  `json:"Asset_ID"`
+	DeviceID  string  `json:"Device_ID"`
+	Timestamp string  `json:"Timestamp"`
+	Metric    string  `json:"Metric"`
+	Value     float64 `json:"Value"`
+	Unit      string  `json:"Unit"`
+	Min       float64 `json:"Min"`
+	Max       float64 `json:"Max"`
+	TxID      string  `json:"Tx_ID"`
+}
+
+// stageLimits are the limits for crude carried by pipeline from the well to the refinery.
+var stageLimits = TelemetryLimits{
+	MinTemperature: -20,
+	MaxTemperature: 80,
+	MinPressure:    5,
+	MaxPressure:    100,
+}
+
+// temperatureUnits converts a temperature in the given unit to degrees Celsius.
+var temperatureUnits = map[string]func(float64) float64{
+	"C": func(v float64) float64 { return v },
+	"F": func(v float64) float64 { return (v - 32) * 5 / 9 },
+	"K": func(v float64) float64 { return v - 273.15 },
+}
+
+// pressureUnits holds the factor that converts a pressure in the given unit to bar.
+var pressureUnits = map[string]float64{
+	"bar": 1,
+	"kPa": 0.01,
+	"psi": 0.0689476,
+}
+
+// quantityUnits holds the factor that converts a quantity in the given unit to barrels.
+var quantityUnits = map[string]float64{
+	"bbl": 1,
+	"L":   1 / 158.987294928,
+	"m3":  6.289810770432105,
+}
+
+// GetStageLimits returns the telemetry limits enforced by this stage.
+func (s *SmartContract) GetStageLimits(ctx contractapi.TransactionContextInterface) (*TelemetryLimits, error) {
+	limits := stageLimits
+	return &limits, nil
+}
+
+// GetOutOfRangeEvents returns every out-of-range reading recorded for the asset.
+func (s *SmartContract) GetOutOfRangeEvents(ctx contractapi.TransactionContextInterface, assetID string) ([]*OutOfRangeEvent, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(outOfRangeIndex, []string{assetID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var events []*OutOfRangeEvent
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var event OutOfRangeEvent
+		err = json.Unmarshal(queryResponse.Value, &event)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+// validateTelemetry rejects readings that are incomplete or use an unknown unit.
+func validateTelemetry(telemetry *Telemetry) error {
+	if telemetry.DeviceID == "" {
+		return fmt.Errorf("telemetry device ID is required")
+	}
+	if _, err := time.Parse(time.RFC3339, telemetry.Timestamp); err != nil {
+		return fmt.Errorf("telemetry timestamp %q is not RFC 3339: %v", telemetry.Timestamp, err)
+	}
+	if _, ok := temperatureUnits[telemetry.Temperature.Unit]; !ok {
+		return fmt.Errorf("unknown temperature unit %q", telemetry.Temperature.Unit)
+	}
+	if _, ok := pressureUnits[telemetry.Pressure.Unit]; !ok {
+		return fmt.Errorf("unknown pressure unit %q", telemetry.Pressure.Unit)
+	}
+	if _, ok := quantityUnits[telemetry.Quantity.Unit]; !ok {
+		return fmt.Errorf("unknown quantity unit %q", telemetry.Quantity.Unit)
+	}
+	if telemetry.Quantity.Value < 0 {
+		return fmt.Errorf("telemetry quantity must not be negative")
+	}
+	if telemetry.Coordinates.Latitude < -90 || telemetry.Coordinates.Latitude > 90 {
+		return fmt.Errorf("latitude %v is out of range", telemetry.Coordinates.Latitude)
+	}
+	if telemetry.Coordinates.Longitude < -180 || telemetry.Coordinates.Longitude > 180 {
+		return fmt.Errorf("longitude %v is out of range", telemetry.Coordinates.Longitude)
+	}
+	return nil
+}
+
+// check returns an event for every reading that falls outside the limits.
+func (l TelemetryLimits) check(assetID string, telemetry *Telemetry) []*OutOfRangeEvent {
+	var events []*OutOfRangeEvent
+	newEvent := func(metric string, reading Reading, min float64, max float64) *OutOfRangeEvent {
+		return &OutOfRangeEvent{
+			AssetID:   assetID,
+			DeviceID:  telemetry.DeviceID,
+			Timestamp: telemetry.Timestamp,
+			Metric:    metric,
+			Value:     reading.Value,
+			Unit:      reading.Unit,
+			Min:       min,
+			Max:       max,
+		}
+	}
+
+	temperature := temperatureUnits[telemetry.Temperature.Unit](telemetry.Temperature.Value)
+	if temperature < l.MinTemperature || temperature > l.MaxTemperature {
+		events = append(events, newEvent("Temperature", telemetry.Temperature, l.MinTemperature, l.MaxTemperature))
+	}
+	pressure := telemetry.Pressure.Value * pressureUnits[telemetry.Pressure.Unit]
+	if pressure < l.MinPressure || pressure > l.MaxPressure {
+		events = append(events, newEvent("Pressure", telemetry.Pressure, l.MinPressure, l.MaxPressure))
+	}
+	return events
+}
+
+// telemetryPayload returns the bytes signed by the device: the JSON of the reading without its signature.
+func telemetryPayload(telemetry Telemetry) ([]byte, error) {
+	telemetry.Signature = ""
+	return json.Marshal(telemetry)
+}
+
+// recordTelemetry validates a reading for the asset, verifies it was signed by its registered device and that
+// the device is owned by custodian, the org holding the asset, appends it to the telemetry series of the
+// asset, and records an out-of-range event in world state when the reading falls outside the stage limits.
+// The out-of-range events are returned so that the transaction can emit them, together with those of its
+// other readings, with setOutOfRangeEvent.
+func recordTelemetry(ctx contractapi.TransactionContextInterface, assetID string, custodian string, telemetry *Telemetry) ([]*OutOfRangeEvent, error) {
+	err := validateTelemetry(telemetry)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := telemetryPayload(*telemetry)
+	if err != nil {
+		return nil, err
+	}
+	err = verifySignature(ctx, telemetry.DeviceID, payload, telemetry.Signature)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyDeviceOwner(ctx, telemetry.DeviceID, custodian)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := timestampKey(telemetry.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	err = appendTelemetry(ctx, assetID, timestamp, telemetry)
+	if err != nil {
+		return nil, err
+	}
+
+	events := stageLimits.check(assetID, telemetry)
+	for _, event := range events {
+		event.TxID = ctx.GetStub().GetTxID()
+		eventKey, err := ctx.GetStub().CreateCompositeKey(outOfRangeIndex, []string{assetID, timestamp, event.Metric})
+		if err != nil {
+			return nil, err
+		}
+		eventJSON, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		err = ctx.GetStub().PutState(eventKey, eventJSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to put to world state: %v", err)
+		}
+	}
+	return events, nil
+}
+
+// setOutOfRangeEvent emits the out-of-range events of every reading a transaction recorded. A transaction
+// carries a single chaincode event, so they are reported together, and no event is set when there are none.
+func setOutOfRangeEvent(ctx contractapi.TransactionContextInterface, events []*OutOfRangeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	eventsJSON, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(outOfRangeEvent, eventsJSON)
+}
+
+// timestampKey formats an RFC 3339 timestamp as a fixed width UTC string for use in composite keys.
+func timestampKey(timestamp string) (string, error) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return "", err
+	}
+	return t.UTC().Format(timestampKeyLayout), nil
+}
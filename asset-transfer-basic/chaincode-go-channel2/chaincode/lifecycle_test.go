@@ -0,0 +1,175 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/stretchr/testify/require"
+)
+
+// newBatchResponse returns the response of the previous stage contract to GetBatchState.
+func newBatchResponse(t *testing.T, state string, custodian string) *peer.Response {
+	payload, err := json.Marshal(BatchState{OilID: "OIL-1234", State: state, Custodian: custodian})
+	require.NoError(t, err)
+	return &peer.Response{Status: shim.OK, Payload: payload}
+}
+
+func TestHandOver(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	chaincodeStub.InvokeChaincodeReturns(newBatchResponse(t, stage.previousState, myOrg1Msp))
+	transactionContext := newTransactionContext(chaincodeStub, myOrg1Msp)
+
+	err := handOver(transactionContext, "OIL-1234", "asset1", "")
+	require.EqualError(t, err, "the receiver of oil batch OIL-1234 is not set")
+
+	err = handOver(transactionContext, "OIL-1234", "asset1", myOrg2Msp)
+	require.NoError(t, err)
+
+	chaincodeName, args, channel := chaincodeStub.InvokeChaincodeArgsForCall(0)
+	require.Equal(t, stage.previousChaincode, chaincodeName)
+	require.Equal(t, [][]byte{[]byte("GetBatchState"), []byte("OIL-1234")}, args)
+	require.Equal(t, stage.previousChannel, channel)
+
+	assetTransfer := SmartContract{}
+	batch, err := assetTransfer.GetBatchState(transactionContext, "OIL-1234")
+	require.NoError(t, err)
+	require.Equal(t, &BatchState{OilID: "OIL-1234", State: stage.inTransitState, Custodian: myOrg1Msp, AssetID: "asset1", Receiver: myOrg2Msp, HandedOverAt: txTimestamp}, batch)
+
+	err = handOver(transactionContext, "OIL-1234", "asset2", myOrg2Msp)
+	require.EqualError(t, err, "the oil batch OIL-1234 is already "+stage.inTransitState)
+
+	err = handOver(newTransactionContext(chaincodeStub, myOrg2Msp), "OIL-1234", "asset2", myOrg2Msp)
+	require.EqualError(t, err, "client from Org2Testmsp is not the custodian of oil batch OIL-1234, Org1Testmsp is")
+
+	chaincodeStub.InvokeChaincodeReturns(newBatchResponse(t, stateInTransitToRefinery, myOrg1Msp))
+	err = handOver(transactionContext, "OIL-1234", "asset2", myOrg2Msp)
+	require.EqualError(t, err, "the oil batch OIL-1234 is InTransitToRefinery, not "+stage.previousState)
+
+	chaincodeStub.InvokeChaincodeReturns(&peer.Response{Status: shim.ERROR, Message: "the oil batch OIL-5678 does not exist"})
+	err = handOver(transactionContext, "OIL-5678", "asset2", myOrg2Msp)
+	require.EqualError(t, err, "failed to query "+stage.previousChaincode+" on "+stage.previousChannel+": the oil batch OIL-5678 does not exist")
+}
+
+func TestAcceptHandover(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	chaincodeStub.InvokeChaincodeReturns(newBatchResponse(t, stage.previousState, myOrg1Msp))
+	transactionContext := newTransactionContext(chaincodeStub, myOrg1Msp)
+
+	assetTransfer := SmartContract{}
+	err := assetTransfer.AcceptHandover(transactionContext, "OIL-1234")
+	require.EqualError(t, err, "the oil batch OIL-1234 does not exist")
+
+	require.NoError(t, handOver(transactionContext, "OIL-1234", "asset1", myOrg2Msp))
+	err = assetTransfer.AcceptHandover(transactionContext, "OIL-1234")
+	require.EqualError(t, err, "client from Org1Testmsp is not the receiver of oil batch OIL-1234, Org2Testmsp is")
+
+	err = assetTransfer.AcceptHandover(newTransactionContext(chaincodeStub, myOrg2Msp), "OIL-1234")
+	require.NoError(t, err)
+
+	batch, err := assetTransfer.GetBatchState(transactionContext, "OIL-1234")
+	require.NoError(t, err)
+	require.Equal(t, &BatchState{OilID: "OIL-1234", State: stage.completeState, Custodian: myOrg2Msp, AssetID: "asset1", Receiver: myOrg2Msp, HandedOverAt: txTimestamp, AcceptedAt: txTimestamp}, batch)
+
+	eventName, eventPayload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "HandoverAccepted", eventName)
+	var accepted []*BatchState
+	require.NoError(t, json.Unmarshal(eventPayload, &accepted))
+	require.Equal(t, []*BatchState{batch}, accepted)
+
+	err = assetTransfer.AcceptHandover(transactionContext, "OIL-1234")
+	require.EqualError(t, err, "the oil batch OIL-1234 is "+stage.completeState+", not "+stage.inTransitState)
+}
+
+func TestAcceptHandovers(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	chaincodeStub.InvokeChaincodeReturns(newBatchResponse(t, stage.previousState, myOrg1Msp))
+	transactionContext := newTransactionContext(chaincodeStub, myOrg1Msp)
+
+	assetTransfer := SmartContract{}
+	err := assetTransfer.AcceptHandovers(transactionContext, []string{})
+	require.EqualError(t, err, "the batch is empty")
+
+	require.NoError(t, handOver(transactionContext, "OIL-1234", "asset1", myOrg2Msp))
+	require.NoError(t, handOver(transactionContext, "OIL-5678", "asset2", myOrg2Msp))
+	err = assetTransfer.AcceptHandovers(transactionContext, []string{"OIL-1234", "OIL-1234"})
+	require.EqualError(t, err, "oil batch 1: the oil batch OIL-1234 appears more than once in the batch")
+
+	err = assetTransfer.AcceptHandovers(transactionContext, []string{"OIL-9012", "OIL-1234"})
+	require.EqualError(t, err, "oil batch 0 (OIL-9012): the oil batch OIL-9012 does not exist")
+
+	err = assetTransfer.AcceptHandovers(transactionContext, []string{"OIL-1234", "OIL-5678"})
+	require.EqualError(t, err, "oil batch 0 (OIL-1234): client from Org1Testmsp is not the receiver of oil batch OIL-1234, Org2Testmsp is")
+
+	err = assetTransfer.AcceptHandovers(newTransactionContext(chaincodeStub, myOrg2Msp), []string{"OIL-1234", "OIL-5678"})
+	require.NoError(t, err)
+
+	eventName, eventPayload := chaincodeStub.SetEventArgsForCall(chaincodeStub.SetEventCallCount() - 1)
+	require.Equal(t, "HandoverAccepted", eventName)
+	var accepted []*BatchState
+	require.NoError(t, json.Unmarshal(eventPayload, &accepted))
+	require.Len(t, accepted, 2)
+	require.Equal(t, "OIL-5678", accepted[1].OilID)
+}
+
+func TestDispatchBatch(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	chaincodeStub.InvokeChaincodeReturns(newBatchResponse(t, stage.previousState, myOrg1Msp))
+	transactionContext := newTransactionContext(chaincodeStub, myOrg2Msp)
+
+	assetTransfer := SmartContract{}
+	err := assetTransfer.DispatchBatch(transactionContext, "OIL-1234", destinationFactory)
+	require.EqualError(t, err, "the oil batch OIL-1234 does not exist")
+
+	require.NoError(t, handOver(newTransactionContext(chaincodeStub, myOrg1Msp), "OIL-1234", "asset1", myOrg2Msp))
+	err = assetTransfer.DispatchBatch(transactionContext, "OIL-1234", destinationFactory)
+	require.EqualError(t, err, "the oil batch OIL-1234 is "+stage.inTransitState+", not "+stage.completeState)
+
+	require.NoError(t, assetTransfer.AcceptHandover(transactionContext, "OIL-1234"))
+	err = assetTransfer.DispatchBatch(transactionContext, "OIL-1234", "Refinery")
+	require.EqualError(t, err, "the destination Refinery is not Factory or OilPump")
+
+	err = assetTransfer.DispatchBatch(newTransactionContext(chaincodeStub, myOrg1Msp), "OIL-1234", destinationFactory)
+	require.EqualError(t, err, "client from Org1Testmsp is not the custodian of oil batch OIL-1234, Org2Testmsp is")
+
+	err = assetTransfer.DispatchBatch(transactionContext, "OIL-1234", destinationFactory)
+	require.NoError(t, err)
+
+	batch, err := assetTransfer.GetBatchState(transactionContext, "OIL-1234")
+	require.NoError(t, err)
+	require.Equal(t, destinationFactory, batch.DispatchedTo)
+	require.Equal(t, stage.completeState, batch.State)
+
+	err = assetTransfer.DispatchBatch(transactionContext, "OIL-1234", destinationPump)
+	require.EqualError(t, err, "the oil batch OIL-1234 is already dispatched to Factory")
+}
+
+func TestDispatchBatches(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	chaincodeStub.InvokeChaincodeReturns(newBatchResponse(t, stage.previousState, myOrg1Msp))
+	transactionContext := newTransactionContext(chaincodeStub, myOrg2Msp)
+
+	assetTransfer := SmartContract{}
+	err := assetTransfer.DispatchBatches(transactionContext, []Dispatch{})
+	require.EqualError(t, err, "the batch is empty")
+
+	for _, oilID := range []string{"OIL-1234", "OIL-5678"} {
+		require.NoError(t, handOver(newTransactionContext(chaincodeStub, myOrg1Msp), oilID, "asset-"+oilID, myOrg2Msp))
+		require.NoError(t, assetTransfer.AcceptHandover(transactionContext, oilID))
+	}
+	err = assetTransfer.DispatchBatches(transactionContext, []Dispatch{{OilID: "OIL-1234", Destination: destinationFactory}, {OilID: "OIL-1234", Destination: destinationPump}})
+	require.EqualError(t, err, "oil batch 1: the oil batch OIL-1234 appears more than once in the batch")
+
+	err = assetTransfer.DispatchBatches(transactionContext, []Dispatch{{OilID: "OIL-1234", Destination: destinationFactory}, {OilID: "OIL-5678", Destination: destinationPump}})
+	require.NoError(t, err)
+
+	batch, err := assetTransfer.GetBatchState(transactionContext, "OIL-5678")
+	require.NoError(t, err)
+	require.Equal(t, destinationPump, batch.DispatchedTo)
+}
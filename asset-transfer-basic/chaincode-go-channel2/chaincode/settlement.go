@@ -0,0 +1,176 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// The token-erc-20 contract bills are paid with. It is queried with a chaincode-to-chaincode call, so the
+// peers endorsing this contract must have joined its channel.
+const (
+	tokenChannel   = "channel6"
+	tokenChaincode = "token_erc20"
+)
+
+const settledPaymentIndex = "settlement~payment"
+
+// Payment states of a bill.
+const (
+	billUnpaid = "Unpaid"
+	billPaid   = "Paid"
+)
+
+// tokenPayment is the receipt the token contract keeps for a payment made with its Pay transaction.
+type tokenPayment struct {
+	TxID      string `json:"txID"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Value     int    `json:"value"`
+	Reference string `json:"reference"`
+}
+
+// BillSettlement is the payload of the BillSettled event.
+type BillSettlement struct {
+	OilID       string `json:"Oil_Batch_ID"`
+	AssetID     string `json:"Asset_ID"`
+	BillNumber  string `json:"Bill_Number"`
+	PaymentTxID string `json:"Payment_Tx_ID"`
+	Amount      int    `json:"Amount"`
+}
+
+// SettleBill marks the bill of the oil batch's handover on this stage as paid. The receiver, which became
+// the custodian of the batch when it accepted the handover, first pays the shipper the bill total with Pay
+// on the token contract, referencing the bill number, and then settles the bill with that transaction ID.
+func (s *SmartContract) SettleBill(ctx contractapi.TransactionContextInterface, oilID string, paymentTxID string) error {
+	batch, err := s.GetBatchState(ctx, oilID)
+	if err != nil {
+		return err
+	}
+	if batch.State != stage.completeState {
+		return fmt.Errorf("the oil batch %s is %s, not %s", oilID, batch.State, stage.completeState)
+	}
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if clientMSPID != batch.Custodian {
+		return fmt.Errorf("client from %s is not the custodian of oil batch %s, %s is", clientMSPID, oilID, batch.Custodian)
+	}
+
+	asset, err := s.ReadAsset(ctx, batch.AssetID)
+	if err != nil {
+		return err
+	}
+	if asset.Bill.PaymentStatus == billPaid {
+		return fmt.Errorf("the bill %s is already paid by %s", asset.Bill.BillNumber, asset.Bill.PaymentTxID)
+	}
+	amount, err := billAmount(asset.Bill.TotalPayment)
+	if err != nil {
+		return err
+	}
+
+	payment, err := readTokenPayment(ctx, paymentTxID)
+	if err != nil {
+		return err
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client ID: %v", err)
+	}
+	if payment.From != clientID {
+		return fmt.Errorf("the payment %s was not made by the client", paymentTxID)
+	}
+	if payment.To != asset.Bill.Payee {
+		return fmt.Errorf("the payment %s is not made to the shipper of oil batch %s", paymentTxID, oilID)
+	}
+	if payment.Reference != asset.Bill.BillNumber {
+		return fmt.Errorf("the payment %s references %q, not bill %s", paymentTxID, payment.Reference, asset.Bill.BillNumber)
+	}
+	if payment.Value < amount {
+		return fmt.Errorf("the payment %s of %d does not cover the bill total of %d", paymentTxID, payment.Value, amount)
+	}
+
+	// A payment settles a single bill, so it cannot be presented again on this stage.
+	settledKey, err := ctx.GetStub().CreateCompositeKey(settledPaymentIndex, []string{paymentTxID})
+	if err != nil {
+		return err
+	}
+	settledOilID, err := ctx.GetStub().GetState(settledKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if settledOilID != nil {
+		return fmt.Errorf("the payment %s already settles the bill of oil batch %s", paymentTxID, settledOilID)
+	}
+	err = ctx.GetStub().PutState(settledKey, []byte(oilID))
+	if err != nil {
+		return err
+	}
+
+	asset.Bill.PaymentStatus = billPaid
+	asset.Bill.PaymentTxID = paymentTxID
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(asset.ID, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	settlementJSON, err := json.Marshal(BillSettlement{
+		OilID:       oilID,
+		AssetID:     asset.ID,
+		BillNumber:  asset.Bill.BillNumber,
+		PaymentTxID: paymentTxID,
+		Amount:      payment.Value,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent("BillSettled", settlementJSON)
+}
+
+// openBill makes the client creating an asset the payee of its bill, which stays unpaid until SettleBill.
+// Payment fields sent by the client are ignored.
+func openBill(ctx contractapi.TransactionContextInterface, bill *Bills) error {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client ID: %v", err)
+	}
+	bill.Payee = clientID
+	bill.PaymentStatus = billUnpaid
+	bill.PaymentTxID = ""
+	return nil
+}
+
+// billAmount returns the bill total, written like "$ 110,000", as a whole number of tokens.
+func billAmount(totalPayment string) (int, error) {
+	amount, err := strconv.Atoi(strings.NewReplacer("$", "", ",", "", " ", "").Replace(totalPayment))
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("the bill total %q is not a whole number of tokens", totalPayment)
+	}
+	return amount, nil
+}
+
+// readTokenPayment queries the token contract for the payment made in the given transaction. The query is
+// read only: Fabric does not commit writes made through a chaincode on another channel.
+func readTokenPayment(ctx contractapi.TransactionContextInterface, paymentTxID string) (*tokenPayment, error) {
+	args := [][]byte{[]byte("GetPayment"), []byte(paymentTxID)}
+	response := ctx.GetStub().InvokeChaincode(tokenChaincode, args, tokenChannel)
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("failed to query %s on %s: %s", tokenChaincode, tokenChannel, response.Message)
+	}
+
+	var payment tokenPayment
+	err := json.Unmarshal(response.Payload, &payment)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
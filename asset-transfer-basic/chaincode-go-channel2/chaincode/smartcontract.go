@@ -4,9 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
 
+// Composite key indexes that allow assets to be looked up by oil batch or carrier on both LevelDB and CouchDB.
+const (
+	oilBatchIndex = "oilBatch~id"
+	carrierIndex  = "carrier~id"
+)
+
 // SmartContract provides functions for managing an Asset
 type SmartContract struct {
 	contractapi.Contract
@@ -21,98 +28,144 @@ type Bills struct {
 	CarrierName    string `json:"Carrier_Name"`
 	CarrierAddress string `json:"Carrier_Address"`
 	Date           string `json:"Date"`
-}
-type IotLogs struct {
-	Temperature string `json:"Temperature"`
-	Pressure    string `json:"Pressure"`
-	Location    string `json:"Location"`
-	Quantity    string `json:"Quantity"`
-	Quality     string `json:"Quality"`
+	// Set by the contract: the payee is the client that created the asset, and the bill is paid with SettleBill.
+	Payee         string `json:"Payee,omitempty" metadata:",optional"`
+	PaymentStatus string `json:"Payment_Status,omitempty" metadata:",optional"`
+	PaymentTxID   string `json:"Payment_Tx_ID,omitempty" metadata:",optional"`
 }
 type Asset struct {
-	ID               string  `json:"ID"`
-	Name             string  `json:"Name"`
-	FacilityID       string  `json:"Facility_ID"`
-	FacilityName     string  `json:"Facility_Name"`
-	OilID            string  `json:"Oil_Batch_ID"`
-	RefineryDetail   string  `json:"Refinery_Detail"`
-	OilQuantityCerti string  `json:"Oil_Quantity_Certificate"`
-	OilQualityCerti  string  `json:"Oil_Quality_Certificate"`
-	Bill             Bills   `json:"Bill"`
-	DigitalSignature string  `json:"Digital_Signature"`
-	IoTData          IotLogs `json:"Iot_Data"`
-}
-
-// CreateAsset issues a new asset to the world state with given details.
-func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, id string, name string, facilityID string, facilityName string, oilId string, refineryDetail string, quantiCerti string, ouanliCerti string, billNumber string, totalPay string, carrName string, carrAdd string, date string, digitalSign string, iotTemp string, iotPres string, iotLoc string, iotQuan string, iotQual string) error {
-	exists, err := s.AssetExists(ctx, id)
+	ID               string    `json:"ID"`
+	Name             string    `json:"Name"`
+	FacilityID       string    `json:"Facility_ID"`
+	FacilityName     string    `json:"Facility_Name"`
+	OilID            string    `json:"Oil_Batch_ID"`
+	RefineryDetail   string    `json:"Refinery_Detail"`
+	OilQuantityCerti string    `json:"Oil_Quantity_Certificate"`
+	OilQualityCerti  string    `json:"Oil_Quality_Certificate"`
+	Bill             Bills     `json:"Bill"`
+	Receiver         string    `json:"Receiver"`
+	SignerID         string    `json:"Signer_ID"`
+	DigitalSignature string    `json:"Digital_Signature"`
+	IoTData          Telemetry `json:"Iot_Data"`
+}
+
+// CreateAsset issues a new asset to the world state. The asset is passed as a single JSON document, which
+// is validated against the Asset schema published in the contract metadata before the contract is called.
+// Its Receiver is the MSP ID of the org the shipment is for, the only one that can accept the handover.
+func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, asset Asset) error {
+	events, err := s.createAsset(ctx, &asset)
 	if err != nil {
 		return err
 	}
+	return setOutOfRangeEvent(ctx, events)
+}
+
+// CreateAssets issues a batch of assets in a single transaction. Every asset is checked as in CreateAsset,
+// and the whole batch is rejected when any of them fails.
+func (s *SmartContract) CreateAssets(ctx contractapi.TransactionContextInterface, assets []Asset) error {
+	if len(assets) == 0 {
+		return fmt.Errorf("the batch is empty")
+	}
+
+	// Reads do not see the writes of the same transaction, so duplicates within the batch are caught here.
+	ids := make(map[string]bool)
+	oilIDs := make(map[string]bool)
+	var events []*OutOfRangeEvent
+	for i := range assets {
+		asset := &assets[i]
+		if ids[asset.ID] {
+			return fmt.Errorf("asset %d: the asset %s appears more than once in the batch", i, asset.ID)
+		}
+		ids[asset.ID] = true
+		if oilIDs[asset.OilID] {
+			return fmt.Errorf("asset %d: the oil batch %s appears more than once in the batch", i, asset.OilID)
+		}
+		oilIDs[asset.OilID] = true
+
+		assetEvents, err := s.createAsset(ctx, asset)
+		if err != nil {
+			return fmt.Errorf("asset %d (%s): %v", i, asset.ID, err)
+		}
+		events = append(events, assetEvents...)
+	}
+	return setOutOfRangeEvent(ctx, events)
+}
+
+// createAsset checks a new asset and writes it to the world state. It returns the out-of-range events of
+// its reading for the transaction to emit.
+func (s *SmartContract) createAsset(ctx contractapi.TransactionContextInterface, asset *Asset) ([]*OutOfRangeEvent, error) {
+	exists, err := s.AssetExists(ctx, asset.ID)
+	if err != nil {
+		return nil, err
+	}
 	if exists {
-		return fmt.Errorf("the asset %s already exists", id)
-	}
-	bilss := Bills{
-		BillNumber:     billNumber,
-		TotalPayment:   totalPay,
-		CarrierName:    carrName,
-		CarrierAddress: carrAdd,
-		Date:           date,
-	}
-	iotLog := IotLogs{
-		Temperature: iotTemp,
-		Pressure:    iotPres,
-		Location:    iotLoc,
-		Quantity:    iotQuan,
-		Quality:     iotQual,
-	}
-	asset := Asset{
-		ID:               id,
-		Name:             name,
-		FacilityID:       facilityID,
-		FacilityName:     facilityName,
-		OilID:            oilId,
-		RefineryDetail:   refineryDetail,
-		OilQuantityCerti: quantiCerti,
-		OilQualityCerti:  ouanliCerti,
-		Bill:             bilss,
-		DigitalSignature: digitalSign,
-		IoTData:          iotLog,
+		return nil, fmt.Errorf("the asset %s already exists", asset.ID)
+	}
+	// handOver lets only the custodian of the oil batch ship it, so the reading must come from a device of
+	// the caller's org.
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	events, err := recordTelemetry(ctx, asset.ID, clientMSPID, &asset.IoTData)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyHandover(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	err = openBill(ctx, &asset.Bill)
+	if err != nil {
+		return nil, err
+	}
+	err = handOver(ctx, asset.OilID, asset.ID, asset.Receiver)
+	if err != nil {
+		return nil, err
 	}
 	assetJSON, err := json.Marshal(asset)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return ctx.GetStub().PutState(id, assetJSON)
+	err = ctx.GetStub().PutState(asset.ID, assetJSON)
+	if err != nil {
+		return nil, err
+	}
+	err = putIndexKeys(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
 }
 
 // ReadAsset returns the asset stored in the world state with given id.
-func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, productID string) ([]*Asset, error) {
-	selector := `{"selector": {"ProductID": ` + productID + `}}`
-	resultsIterator, err := ctx.GetStub().GetQueryResult(selector)
+func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*Asset, error) {
+	assetJSON, err := ctx.GetStub().GetState(id)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
 	}
-	defer resultsIterator.Close()
-	var assets []*Asset
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return nil, err
-		}
-
-		var asset Asset
-		err = json.Unmarshal(queryResponse.Value, &asset)
-		if err != nil {
-			return nil, err
-		}
-		assets = append(assets, &asset)
+	if assetJSON == nil {
+		return nil, fmt.Errorf("the asset %s does not exist", id)
 	}
-	if len(assets) == 0 {
-		return nil, fmt.Errorf("no assets found for ProductID: %s", productID)
+
+	var asset Asset
+	err = json.Unmarshal(assetJSON, &asset)
+	if err != nil {
+		return nil, err
 	}
-	return assets, nil
+
+	return &asset, nil
+}
+
+// QueryAssetsByOilBatch returns every asset recorded for the given Oil_Batch_ID.
+func (s *SmartContract) QueryAssetsByOilBatch(ctx contractapi.TransactionContextInterface, oilId string) ([]*Asset, error) {
+	return queryAssetsByIndex(ctx, oilBatchIndex, oilId)
+}
+
+// QueryAssetsByCarrier returns every asset whose bill names the given carrier.
+func (s *SmartContract) QueryAssetsByCarrier(ctx contractapi.TransactionContextInterface, carrierName string) ([]*Asset, error) {
+	return queryAssetsByIndex(ctx, carrierIndex, carrierName)
 }
 
 // GetAllAssets returns all assets found in world state
@@ -145,15 +198,17 @@ func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface
 
 // DeleteAsset deletes an given asset from the world state.
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
-	exists, err := s.AssetExists(ctx, id)
+	asset, err := s.ReadAsset(ctx, id)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("the asset %s does not exist", id)
+
+	err = ctx.GetStub().DelState(id)
+	if err != nil {
+		return err
 	}
 
-	return ctx.GetStub().DelState(id)
+	return delIndexKeys(ctx, asset)
 }
 
 // AssetExists returns true when asset with given ID exists in world state
@@ -166,19 +221,37 @@ func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface,
 	return assetJSON != nil, nil
 }
 
-func (s *SmartContract) ChangeIotData(ctx contractapi.TransactionContextInterface, id string, iotTemp string, iotPres string, iotLocat string, iotQuanti string, iotQuali string) (string, error) {
+// ChangeIotData appends an IoT reading to the telemetry series of the asset, see GetTelemetry. The asset
+// itself keeps only the latest reading, so a late reading is recorded in the series without replacing it.
+// The reading must be signed by a device of the org that is the custodian of the oil batch on this stage.
+func (s *SmartContract) ChangeIotData(ctx contractapi.TransactionContextInterface, id string, iotData Telemetry) (string, error) {
 	asset, err := s.ReadAsset(ctx, id)
 	if err != nil {
 		return "", err
 	}
-	iotLog := IotLogs{
-		Temperature: iotTemp,
-		Pressure:    iotPres,
-		Location:    iotLocat,
-		Quantity:    iotQuanti,
-		Quality:     iotQuali,
+	batch, err := readBatchState(ctx, asset.OilID)
+	if err != nil {
+		return "", err
+	}
+	if batch == nil {
+		return "", fmt.Errorf("the oil batch %s does not exist", asset.OilID)
+	}
+	events, err := recordTelemetry(ctx, id, batch.Custodian, &iotData)
+	if err != nil {
+		return "", err
 	}
-	asset[0].IoTData = iotLog
+	err = setOutOfRangeEvent(ctx, events)
+	if err != nil {
+		return "", err
+	}
+	latest, err := isLaterReading(&iotData, &asset.IoTData)
+	if err != nil {
+		return "", err
+	}
+	if !latest {
+		return "It's all good", nil
+	}
+	asset.IoTData = iotData
 	assetJSON, err := json.Marshal(asset)
 	if err != nil {
 		return "", fmt.Errorf("Just giveup coding. err: %s", err)
@@ -190,3 +263,128 @@ func (s *SmartContract) ChangeIotData(ctx contractapi.TransactionContextInterfac
 	}
 	return "It's all good", nil
 }
+
+// isLaterReading reports whether the reading was taken no earlier than the current reading of an asset.
+func isLaterReading(reading *Telemetry, current *Telemetry) (bool, error) {
+	if current.Timestamp == "" {
+		return true, nil
+	}
+	readingTime, err := timestampKey(reading.Timestamp)
+	if err != nil {
+		return false, err
+	}
+	currentTime, err := timestampKey(current.Timestamp)
+	if err != nil {
+		return false, err
+	}
+	return readingTime >= currentTime, nil
+}
+
+// indexKeys returns the composite keys under which the asset is indexed by oil batch and by carrier.
+func indexKeys(ctx contractapi.TransactionContextInterface, asset *Asset) ([]string, error) {
+	oilBatchKey, err := ctx.GetStub().CreateCompositeKey(oilBatchIndex, []string{asset.OilID, asset.ID})
+	if err != nil {
+		return nil, err
+	}
+	carrierKey, err := ctx.GetStub().CreateCompositeKey(carrierIndex, []string{asset.Bill.CarrierName, asset.ID})
+	if err != nil {
+		return nil, err
+	}
+	return []string{oilBatchKey, carrierKey}, nil
+}
+
+func putIndexKeys(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	keys, err := indexKeys(ctx, asset)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		// Only the key is needed; a nil value would delete the key, so store the null character instead.
+		err = ctx.GetStub().PutState(key, []byte{0x00})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func delIndexKeys(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	keys, err := indexKeys(ctx, asset)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryAssetsByIndex reads the assets whose index entries match value through a partial composite key range query.
+func queryAssetsByIndex(ctx contractapi.TransactionContextInterface, index string, value string) ([]*Asset, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index, []string{value})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	return assetsFromIndexIterator(ctx, resultsIterator)
+}
+
+func assetsFromIndexIterator(ctx contractapi.TransactionContextInterface, resultsIterator shim.StateQueryIteratorInterface) ([]*Asset, error) {
+	var assets []*Asset
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		assetJSON, err := ctx.GetStub().GetState(compositeKeyParts[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from world state: %v", err)
+		}
+		if assetJSON == nil {
+			continue
+		}
+
+		var asset Asset
+		err = json.Unmarshal(assetJSON, &asset)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, &asset)
+	}
+
+	return assets, nil
+}
+
+// handoverPayload returns the bytes signed by the party handing over the shipment: the JSON of the asset
+// without its digital signature, without IoT readings, which are signed by the devices themselves, and
+// without the payment fields of the bill, which are set by the contract.
+func handoverPayload(asset Asset) ([]byte, error) {
+	asset.DigitalSignature = ""
+	asset.IoTData = Telemetry{}
+	asset.Bill.Payee = ""
+	asset.Bill.PaymentStatus = ""
+	asset.Bill.PaymentTxID = ""
+	return json.Marshal(asset)
+}
+
+// verifyHandover checks the digital signature of the asset against the key registered for its signer.
+func verifyHandover(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	payload, err := handoverPayload(*asset)
+	if err != nil {
+		return err
+	}
+	return verifySignature(ctx, asset.SignerID, payload, asset.DigitalSignature)
+}
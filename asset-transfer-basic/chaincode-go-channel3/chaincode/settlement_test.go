@@ -0,0 +1,115 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// newPaymentResponse returns the response of the token contract to GetPayment.
+func newPaymentResponse(t *testing.T, payment tokenPayment) *peer.Response {
+	payload, err := json.Marshal(payment)
+	require.NoError(t, err)
+	return &peer.Response{Status: shim.OK, Payload: payload}
+}
+
+// newShipment records an asset with an unpaid bill to shipper, whose handover the receiver has accepted.
+func newShipment(t *testing.T, transactionContext *mocks.TransactionContext, state map[string][]byte, oilID string, assetID string) {
+	asset := Asset{ID: assetID, Bill: Bills{BillNumber: "BILL-" + assetID, TotalPayment: "$ 110,000", Payee: "shipper", PaymentStatus: billUnpaid}}
+	assetJSON, err := json.Marshal(asset)
+	require.NoError(t, err)
+	state[assetID] = assetJSON
+	require.NoError(t, putBatchState(transactionContext, &BatchState{OilID: oilID, State: stage.completeState, Custodian: myOrg2Msp, AssetID: assetID}))
+}
+
+func TestSettleBill(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	transactionContext := newTransactionContext(chaincodeStub, myOrg2Msp)
+	transactionContext.GetClientIdentity().(*mocks.ClientIdentity).GetIDReturns("receiver", nil)
+	newShipment(t, transactionContext, state, "OIL-1234", "asset1")
+	newShipment(t, transactionContext, state, "OIL-5678", "asset2")
+	payment := tokenPayment{TxID: "tx1", From: "receiver", To: "shipper", Value: 110000, Reference: "BILL-asset1"}
+
+	assetTransfer := SmartContract{}
+	err := assetTransfer.SettleBill(newTransactionContext(chaincodeStub, myOrg1Msp), "OIL-1234", "tx1")
+	require.EqualError(t, err, "client from Org1Testmsp is not the custodian of oil batch OIL-1234, Org2Testmsp is")
+
+	chaincodeStub.InvokeChaincodeReturns(&peer.Response{Status: shim.ERROR, Message: "the payment tx1 does not exist"})
+	err = assetTransfer.SettleBill(transactionContext, "OIL-1234", "tx1")
+	require.EqualError(t, err, "failed to query token_erc20 on channel6: the payment tx1 does not exist")
+
+	forged := payment
+	forged.From = "someone"
+	chaincodeStub.InvokeChaincodeReturns(newPaymentResponse(t, forged))
+	err = assetTransfer.SettleBill(transactionContext, "OIL-1234", "tx1")
+	require.EqualError(t, err, "the payment tx1 was not made by the client")
+
+	forged = payment
+	forged.To = "receiver"
+	chaincodeStub.InvokeChaincodeReturns(newPaymentResponse(t, forged))
+	err = assetTransfer.SettleBill(transactionContext, "OIL-1234", "tx1")
+	require.EqualError(t, err, "the payment tx1 is not made to the shipper of oil batch OIL-1234")
+
+	forged = payment
+	forged.Reference = "BILL-asset2"
+	chaincodeStub.InvokeChaincodeReturns(newPaymentResponse(t, forged))
+	err = assetTransfer.SettleBill(transactionContext, "OIL-1234", "tx1")
+	require.EqualError(t, err, `the payment tx1 references "BILL-asset2", not bill BILL-asset1`)
+
+	forged = payment
+	forged.Value = 100000
+	chaincodeStub.InvokeChaincodeReturns(newPaymentResponse(t, forged))
+	err = assetTransfer.SettleBill(transactionContext, "OIL-1234", "tx1")
+	require.EqualError(t, err, "the payment tx1 of 100000 does not cover the bill total of 110000")
+
+	chaincodeStub.InvokeChaincodeReturns(newPaymentResponse(t, payment))
+	err = assetTransfer.SettleBill(transactionContext, "OIL-1234", "tx1")
+	require.NoError(t, err)
+
+	chaincodeName, args, channel := chaincodeStub.InvokeChaincodeArgsForCall(chaincodeStub.InvokeChaincodeCallCount() - 1)
+	require.Equal(t, "token_erc20", chaincodeName)
+	require.Equal(t, [][]byte{[]byte("GetPayment"), []byte("tx1")}, args)
+	require.Equal(t, "channel6", channel)
+
+	asset, err := assetTransfer.ReadAsset(transactionContext, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, billPaid, asset.Bill.PaymentStatus)
+	require.Equal(t, "tx1", asset.Bill.PaymentTxID)
+
+	eventName, eventPayload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "BillSettled", eventName)
+	require.JSONEq(t, `{"Oil_Batch_ID":"OIL-1234","Asset_ID":"asset1","Bill_Number":"BILL-asset1","Payment_Tx_ID":"tx1","Amount":110000}`, string(eventPayload))
+
+	err = assetTransfer.SettleBill(transactionContext, "OIL-1234", "tx1")
+	require.EqualError(t, err, "the bill BILL-asset1 is already paid by tx1")
+
+	payment.Reference = "BILL-asset2"
+	chaincodeStub.InvokeChaincodeReturns(newPaymentResponse(t, payment))
+	err = assetTransfer.SettleBill(transactionContext, "OIL-5678", "tx1")
+	require.EqualError(t, err, "the payment tx1 already settles the bill of oil batch OIL-1234")
+
+	require.NoError(t, putBatchState(transactionContext, &BatchState{OilID: "OIL-9012", State: stage.inTransitState, Custodian: myOrg2Msp}))
+	err = assetTransfer.SettleBill(transactionContext, "OIL-9012", "tx2")
+	require.EqualError(t, err, "the oil batch OIL-9012 is "+stage.inTransitState+", not "+stage.completeState)
+}
+
+func TestBillAmount(t *testing.T) {
+	amount, err := billAmount("$ 110,000")
+	require.NoError(t, err)
+	require.Equal(t, 110000, amount)
+
+	amount, err = billAmount("$37,500")
+	require.NoError(t, err)
+	require.Equal(t, 37500, amount)
+
+	_, err = billAmount("$37,500.50")
+	require.EqualError(t, err, `the bill total "$37,500.50" is not a whole number of tokens`)
+
+	_, err = billAmount("")
+	require.EqualError(t, err, `the bill total "" is not a whole number of tokens`)
+}
@@ -0,0 +1,295 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+const batchObjectType = "batch"
+
+// handoverAcceptedEvent is emitted with the states of the oil batches whose handover a transaction accepted.
+const handoverAcceptedEvent = "HandoverAccepted"
+
+// Lifecycle states of an oil batch. Every stage moves the batch from the state its previous stage completed
+// with, through an in-transit state while the shipment is on its way, to the state it completes with once
+// the receiving org accepts the handover.
+const (
+	stateDrilled             = "Drilled"
+	stateInTransitToRefinery = "InTransitToRefinery"
+	stateRefined             = "Refined"
+	stateInTransitToStorage  = "InTransitToStorage"
+	stateStored              = "Stored"
+	stateInTransitToConsumer = "InTransitToConsumer"
+	stateDispatched          = "Dispatched"
+	stateInTransitToCustomer = "InTransitToCustomer"
+	stateDelivered           = "Delivered"
+)
+
+// Destinations storage dispatches oil batches to. Factories on channel3 and oil pumps on channel4 are both
+// supplied from storage, and a batch leaves storage for only one of them.
+const (
+	destinationFactory = "Factory"
+	destinationPump    = "OilPump"
+)
+
+// BatchState is the lifecycle state of an oil batch on this stage and the org that holds the batch.
+type BatchState struct {
+	OilID     string `json:"Oil_Batch_ID"`
+	State     string `json:"State"`
+	Custodian string `json:"Custodian"`
+	AssetID   string `json:"Asset_ID,omitempty" metadata:",optional"`
+	// Receiver is the org the batch is handed over to on this stage, the only one that can accept it.
+	Receiver string `json:"Receiver,omitempty" metadata:",optional"`
+	// DispatchedTo is the destination storage dispatched the batch to. It is only set on storage.
+	DispatchedTo string `json:"Dispatched_To,omitempty" metadata:",optional"`
+	// Transaction timestamps, in RFC 3339, of the handover on this stage and of its acceptance.
+	HandedOverAt string `json:"Handed_Over_At,omitempty" metadata:",optional"`
+	AcceptedAt   string `json:"Accepted_At,omitempty" metadata:",optional"`
+}
+
+// lifecycleStage is the step of the batch lifecycle recorded by this contract.
+type lifecycleStage struct {
+	// previousChannel and previousChaincode locate the contract of the previous stage, which is queried
+	// with a chaincode-to-chaincode call. The peers endorsing this contract must have joined that channel.
+	// They are empty on the first stage.
+	previousChannel   string
+	previousChaincode string
+	previousState     string
+	inTransitState    string
+	completeState     string
+	// dispatchedTo is the destination the previous stage must have dispatched the batch to, on stages that
+	// share their previous stage with another one. It is empty on the others.
+	dispatchedTo string
+}
+
+// stage dispatches stored oil to an oil pump.
+var stage = lifecycleStage{
+	previousChannel:   "channel2",
+	previousChaincode: "basic_channel2",
+	previousState:     stateStored,
+	inTransitState:    stateInTransitToConsumer,
+	completeState:     stateDispatched,
+	dispatchedTo:      destinationPump,
+}
+
+// GetBatchState returns the lifecycle state of the oil batch on this stage. Later stages query it to check
+// that this stage is complete before they accept a handover.
+func (s *SmartContract) GetBatchState(ctx contractapi.TransactionContextInterface, oilID string) (*BatchState, error) {
+	batch, err := readBatchState(ctx, oilID)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, fmt.Errorf("the oil batch %s does not exist", oilID)
+	}
+	return batch, nil
+}
+
+// AcceptHandover completes this stage for the oil batch. Only the receiver org named in the handover can
+// accept the shipment. It becomes the custodian, and only the custodian can hand the batch over on the
+// next stage.
+func (s *SmartContract) AcceptHandover(ctx contractapi.TransactionContextInterface, oilID string) error {
+	batch, err := acceptHandover(ctx, oilID)
+	if err != nil {
+		return err
+	}
+	return setHandoverAcceptedEvent(ctx, []*BatchState{batch})
+}
+
+// AcceptHandovers accepts the handover of every oil batch in a single transaction, and is rejected as a
+// whole when any of them cannot be accepted.
+func (s *SmartContract) AcceptHandovers(ctx contractapi.TransactionContextInterface, oilIDs []string) error {
+	var batches []*BatchState
+	err := forEachOilBatch(oilIDs, func(oilID string) error {
+		batch, err := acceptHandover(ctx, oilID)
+		if err != nil {
+			return err
+		}
+		batches = append(batches, batch)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// A transaction carries a single chaincode event, so the batches are reported together.
+	return setHandoverAcceptedEvent(ctx, batches)
+}
+
+func acceptHandover(ctx contractapi.TransactionContextInterface, oilID string) (*BatchState, error) {
+	batch, err := readBatchState(ctx, oilID)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, fmt.Errorf("the oil batch %s does not exist", oilID)
+	}
+	if batch.State != stage.inTransitState {
+		return nil, fmt.Errorf("the oil batch %s is %s, not %s", oilID, batch.State, stage.inTransitState)
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if clientMSPID != batch.Receiver {
+		return nil, fmt.Errorf("client from %s is not the receiver of oil batch %s, %s is", clientMSPID, oilID, batch.Receiver)
+	}
+	acceptedAt, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch.State = stage.completeState
+	batch.Custodian = clientMSPID
+	batch.AcceptedAt = acceptedAt
+	err = putBatchState(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// handOver moves the oil batch into this stage, on its way to the receiver org. The previous stage must be
+// complete and the caller's org must be the custodian the previous stage handed the batch to.
+func handOver(ctx contractapi.TransactionContextInterface, oilID string, assetID string, receiver string) error {
+	if receiver == "" {
+		return fmt.Errorf("the receiver of oil batch %s is not set", oilID)
+	}
+
+	previous, err := previousBatchState(ctx, oilID)
+	if err != nil {
+		return err
+	}
+	if previous.State != stage.previousState {
+		return fmt.Errorf("the oil batch %s is %s, not %s", oilID, previous.State, stage.previousState)
+	}
+	if stage.dispatchedTo != "" && previous.DispatchedTo != stage.dispatchedTo {
+		return fmt.Errorf("the oil batch %s is not dispatched to %s", oilID, stage.dispatchedTo)
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if clientMSPID != previous.Custodian {
+		return fmt.Errorf("client from %s is not the custodian of oil batch %s, %s is", clientMSPID, oilID, previous.Custodian)
+	}
+
+	existing, err := readBatchState(ctx, oilID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("the oil batch %s is already %s", oilID, existing.State)
+	}
+
+	handedOverAt, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	return putBatchState(ctx, &BatchState{
+		OilID:        oilID,
+		State:        stage.inTransitState,
+		Custodian:    clientMSPID,
+		AssetID:      assetID,
+		Receiver:     receiver,
+		HandedOverAt: handedOverAt,
+	})
+}
+
+// previousBatchState queries the contract of the previous stage for the state of the oil batch. The query
+// is read only: Fabric does not commit writes made through a chaincode on another channel.
+func previousBatchState(ctx contractapi.TransactionContextInterface, oilID string) (*BatchState, error) {
+	args := [][]byte{[]byte("GetBatchState"), []byte(oilID)}
+	response := ctx.GetStub().InvokeChaincode(stage.previousChaincode, args, stage.previousChannel)
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("failed to query %s on %s: %s", stage.previousChaincode, stage.previousChannel, response.Message)
+	}
+
+	var batch BatchState
+	err := json.Unmarshal(response.Payload, &batch)
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func readBatchState(ctx contractapi.TransactionContextInterface, oilID string) (*BatchState, error) {
+	batchKey, err := ctx.GetStub().CreateCompositeKey(batchObjectType, []string{oilID})
+	if err != nil {
+		return nil, err
+	}
+	batchJSON, err := ctx.GetStub().GetState(batchKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if batchJSON == nil {
+		return nil, nil
+	}
+
+	var batch BatchState
+	err = json.Unmarshal(batchJSON, &batch)
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func putBatchState(ctx contractapi.TransactionContextInterface, batch *BatchState) error {
+	batchKey, err := ctx.GetStub().CreateCompositeKey(batchObjectType, []string{batch.OilID})
+	if err != nil {
+		return err
+	}
+	batchJSON, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(batchKey, batchJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
+
+func setHandoverAcceptedEvent(ctx contractapi.TransactionContextInterface, batches []*BatchState) error {
+	batchesJSON, err := json.Marshal(batches)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(handoverAcceptedEvent, batchesJSON)
+}
+
+// txTime returns the timestamp of the transaction, which is the same on every endorsing peer, in RFC 3339.
+func txTime(ctx contractapi.TransactionContextInterface) (string, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	return timestamp.AsTime().UTC().Format(time.RFC3339), nil
+}
+
+// forEachOilBatch applies a lifecycle transition to every oil batch of a batch transaction. Reads do not see
+// the writes of the same transaction, so an oil batch may appear only once.
+func forEachOilBatch(oilIDs []string, transition func(oilID string) error) error {
+	if len(oilIDs) == 0 {
+		return fmt.Errorf("the batch is empty")
+	}
+
+	seen := make(map[string]bool)
+	for i, oilID := range oilIDs {
+		if seen[oilID] {
+			return fmt.Errorf("oil batch %d: the oil batch %s appears more than once in the batch", i, oilID)
+		}
+		seen[oilID] = true
+	}
+	for i, oilID := range oilIDs {
+		err := transition(oilID)
+		if err != nil {
+			return fmt.Errorf("oil batch %d (%s): %v", i, oilID, err)
+		}
+	}
+	return nil
+}
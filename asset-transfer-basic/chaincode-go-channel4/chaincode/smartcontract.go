@@ -15,81 +15,115 @@ type SmartContract struct {
 // Asset describes basic details of what makes up a simple asset
 // Insert struct field in alphabetic order => to achieve determinism across languages
 // golang keeps the order when marshal to json but doesn't order automatically
-type IotLogs struct {
-	Temperature string `json:"Temperature"`
-	Pressure    string `json:"Pressure"`
-	Location    string `json:"Location"`
-	Quantity    string `json:"Quantity"`
-	Quality     string `json:"Quality"`
-}
 type Bills struct {
 	BillNumber     string `json:"Bill_Number"`
 	TotalPayment   string `json:"Total_Payment"`
 	CarrierName    string `json:"Carrier_Name"`
 	CarrierAddress string `json:"Carrier_Address"`
 	Date           string `json:"Date"`
+	// Set by the contract: the payee is the client that created the asset, and the bill is paid with SettleBill.
+	Payee         string `json:"Payee,omitempty" metadata:",optional"`
+	PaymentStatus string `json:"Payment_Status,omitempty" metadata:",optional"`
+	PaymentTxID   string `json:"Payment_Tx_ID,omitempty" metadata:",optional"`
 }
 type Env struct {
 	Temperature string `json:"Temperature"`
 	Pressure    string `json:"Pressure"`
 }
 type Asset struct {
-	ID              string  `json:"ID"`
-	Name            string  `json:"Name"`
-	FacilityID      string  `json:"Facility_ID"`
-	FacilityName    string  `json:"Facility_Name"`
-	OilId           string  `json:"Oil_Batch_ID"`
-	OilQualityCerti string  `json:"Oil_Quality_Certificate"`
-	OilQuantity     string  `json:"Oil_Quantity"`
-	Bill            Bills   `json:"Bill"`
-	Compliance      Env     `json:"Compliance"`
-	IotData         IotLogs `json:"Iot_Data"`
+	ID              string    `json:"ID"`
+	Name            string    `json:"Name"`
+	FacilityID      string    `json:"Facility_ID"`
+	FacilityName    string    `json:"Facility_Name"`
+	OilId           string    `json:"Oil_Batch_ID"`
+	OilQualityCerti string    `json:"Oil_Quality_Certificate"`
+	OilQuantity     string    `json:"Oil_Quantity"`
+	Bill            Bills     `json:"Bill"`
+	Receiver        string    `json:"Receiver"`
+	Compliance      Env       `json:"Compliance"`
+	IotData         Telemetry `json:"Iot_Data"`
 }
 
-func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, id string, name string, facilID string, facilName string, oilId string, oilQuanti string, oilQuali string, bilNumber string, totalPay string, carrName string, carrAdd string, date string, temp string, press string, iotTemp string, iotPres string, iotLoc string, iotQuan string, iotQual string) error {
-	exists, err := s.AssetExists(ctx, id)
+// CreateAsset issues a new asset to the world state. The asset is passed as a single JSON document, which
+// is validated against the Asset schema published in the contract metadata before the contract is called.
+// Its Receiver is the MSP ID of the org the shipment is for, the only one that can accept the handover.
+func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, asset Asset) error {
+	events, err := s.createAsset(ctx, &asset)
 	if err != nil {
 		return err
 	}
+	return setOutOfRangeEvent(ctx, events)
+}
+
+// CreateAssets issues a batch of assets in a single transaction. Every asset is checked as in CreateAsset,
+// and the whole batch is rejected when any of them fails.
+func (s *SmartContract) CreateAssets(ctx contractapi.TransactionContextInterface, assets []Asset) error {
+	if len(assets) == 0 {
+		return fmt.Errorf("the batch is empty")
+	}
+
+	// Reads do not see the writes of the same transaction, so duplicates within the batch are caught here.
+	ids := make(map[string]bool)
+	oilIDs := make(map[string]bool)
+	var events []*OutOfRangeEvent
+	for i := range assets {
+		asset := &assets[i]
+		if ids[asset.ID] {
+			return fmt.Errorf("asset %d: the asset %s appears more than once in the batch", i, asset.ID)
+		}
+		ids[asset.ID] = true
+		if oilIDs[asset.OilId] {
+			return fmt.Errorf("asset %d: the oil batch %s appears more than once in the batch", i, asset.OilId)
+		}
+		oilIDs[asset.OilId] = true
+
+		assetEvents, err := s.createAsset(ctx, asset)
+		if err != nil {
+			return fmt.Errorf("asset %d (%s): %v", i, asset.ID, err)
+		}
+		events = append(events, assetEvents...)
+	}
+	return setOutOfRangeEvent(ctx, events)
+}
+
+// createAsset checks a new asset and writes it to the world state. It returns the out-of-range events of
+// its reading for the transaction to emit.
+func (s *SmartContract) createAsset(ctx contractapi.TransactionContextInterface, asset *Asset) ([]*OutOfRangeEvent, error) {
+	exists, err := s.AssetExists(ctx, asset.ID)
+	if err != nil {
+		return nil, err
+	}
 	if exists {
-		return fmt.Errorf("the asset %s already exists", id)
-	}
-	bilss := Bills{
-		BillNumber:     bilNumber,
-		TotalPayment:   totalPay,
-		CarrierName:    carrName,
-		CarrierAddress: carrAdd,
-		Date:           date,
-	}
-	iotLog := IotLogs{
-		Temperature: iotTemp,
-		Pressure:    iotPres,
-		Location:    iotLoc,
-		Quantity:    iotQuan,
-		Quality:     iotQual,
-	}
-	compliance := Env{
-		Temperature: temp,
-		Pressure:    press,
-	}
-	asset := Asset{
-		ID:              id,
-		Name:            name,
-		FacilityID:      facilID,
-		FacilityName:    facilName,
-		OilId:           oilId,
-		OilQualityCerti: oilQuali,
-		OilQuantity:     oilQuanti,
-		Bill:            bilss,
-		Compliance:      compliance,
-		IotData:         iotLog,
+		return nil, fmt.Errorf("the asset %s already exists", asset.ID)
+	}
+	// handOver lets only the custodian of the oil batch ship it, so the reading must come from a device of
+	// the caller's org.
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	events, err := recordTelemetry(ctx, asset.ID, clientMSPID, &asset.IotData)
+	if err != nil {
+		return nil, err
+	}
+	err = openBill(ctx, &asset.Bill)
+	if err != nil {
+		return nil, err
+	}
+	err = handOver(ctx, asset.OilId, asset.ID, asset.Receiver)
+	if err != nil {
+		return nil, err
 	}
 	assetJSON, err := json.Marshal(asset)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return ctx.GetStub().PutState(id, assetJSON)
+	err = ctx.GetStub().PutState(asset.ID, assetJSON)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
 }
 
 // ReadAsset returns the asset stored in the world state with given id.
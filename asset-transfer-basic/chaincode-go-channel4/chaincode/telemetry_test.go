@@ -0,0 +1,194 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func newTelemetry() Telemetry {
+	return Telemetry{
+		DeviceID:    "IOT-D001",
+		Timestamp:   "2024-12-13T08:00:00Z",
+		Temperature: Reading{Value: 25, Unit: "C"},
+		Pressure:    Reading{Value: 10, Unit: "bar"},
+		Quantity:    Reading{Value: 10000, Unit: "bbl"},
+		Quality:     "Grade A",
+		Location:    "Houston, TX",
+		Coordinates: Coordinates{Latitude: 29.7604, Longitude: -95.3698},
+	}
+}
+
+func TestValidateTelemetry(t *testing.T) {
+	telemetry := newTelemetry()
+	require.NoError(t, validateTelemetry(&telemetry))
+
+	telemetry = newTelemetry()
+	telemetry.DeviceID = ""
+	require.EqualError(t, validateTelemetry(&telemetry), "telemetry device ID is required")
+
+	telemetry = newTelemetry()
+	telemetry.Timestamp = "2024-12-13"
+	require.ErrorContains(t, validateTelemetry(&telemetry), `telemetry timestamp "2024-12-13" is not RFC 3339`)
+
+	telemetry = newTelemetry()
+	telemetry.Temperature.Unit = "°C"
+	require.EqualError(t, validateTelemetry(&telemetry), `unknown temperature unit "°C"`)
+
+	telemetry = newTelemetry()
+	telemetry.Pressure.Unit = ""
+	require.EqualError(t, validateTelemetry(&telemetry), `unknown pressure unit ""`)
+
+	telemetry = newTelemetry()
+	telemetry.Quantity = Reading{Value: -1, Unit: "bbl"}
+	require.EqualError(t, validateTelemetry(&telemetry), "telemetry quantity must not be negative")
+
+	telemetry = newTelemetry()
+	telemetry.Coordinates.Latitude = 91
+	require.EqualError(t, validateTelemetry(&telemetry), "latitude 91 is out of range")
+}
+
+func signTelemetry(t *testing.T, privateKey *ecdsa.PrivateKey, telemetry Telemetry) Telemetry {
+	payload, err := telemetryPayload(telemetry)
+	require.NoError(t, err)
+	telemetry.Signature = signPayload(t, privateKey, payload)
+	return telemetry
+}
+
+func TestRecordTelemetry(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	chaincodeStub.GetTxIDReturns("tx1")
+	transactionContext := newAdminTransactionContext(chaincodeStub, myOrg1Msp)
+
+	privateKey, publicKeyPEM := newSigningKey(t)
+	assetTransfer := SmartContract{}
+	require.NoError(t, assetTransfer.RegisterDevice(transactionContext, "IOT-D001", publicKeyPEM))
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+
+	telemetry := newTelemetry()
+	telemetry.Timestamp = "2024-12-13T07:00:00Z"
+	telemetry.Temperature = Reading{Value: 77, Unit: "F"}
+	telemetry = signTelemetry(t, privateKey, telemetry)
+	events, err := recordTelemetry(transactionContext, "asset1", myOrg1Msp, &telemetry)
+	require.NoError(t, err)
+	require.Empty(t, events)
+	require.Equal(t, 2, chaincodeStub.PutStateCallCount())
+
+	readingKey, err := shim.CreateCompositeKey("telemetry~asset~timestamp~device", []string{"asset1", "2024-12-13T07:00:00.000000000Z", "IOT-D001"})
+	require.NoError(t, err)
+	key, value := chaincodeStub.PutStateArgsForCall(1)
+	require.Equal(t, readingKey, key)
+	var recorded Telemetry
+	require.NoError(t, json.Unmarshal(value, &recorded))
+	require.Equal(t, telemetry, recorded)
+
+	_, err = recordTelemetry(transactionContext, "asset1", myOrg1Msp, &telemetry)
+	require.EqualError(t, err, "the reading of IOT-D001 at 2024-12-13T07:00:00Z is already recorded for asset1")
+
+	telemetry.Temperature = Reading{Value: 25, Unit: "C"}
+	_, err = recordTelemetry(transactionContext, "asset1", myOrg1Msp, &telemetry)
+	require.EqualError(t, err, "invalid signature from IOT-D001")
+
+	telemetry = newTelemetry()
+	telemetry.Temperature = Reading{Value: 500, Unit: "C"}
+	telemetry = signTelemetry(t, privateKey, telemetry)
+	events, err = recordTelemetry(transactionContext, "asset1", myOrg1Msp, &telemetry)
+	require.NoError(t, err)
+	require.Equal(t, 4, chaincodeStub.PutStateCallCount())
+	require.Equal(t, 0, chaincodeStub.SetEventCallCount())
+
+	eventKey, err := shim.CreateCompositeKey("outOfRange~asset~timestamp~metric", []string{"asset1", "2024-12-13T08:00:00.000000000Z", "Temperature"})
+	require.NoError(t, err)
+	key, value = chaincodeStub.PutStateArgsForCall(3)
+	require.Equal(t, eventKey, key)
+
+	var event OutOfRangeEvent
+	require.NoError(t, json.Unmarshal(value, &event))
+	require.Equal(t, OutOfRangeEvent{
+		AssetID:   "asset1",
+		DeviceID:  "IOT-D001",
+		Timestamp: "2024-12-13T08:00:00Z",
+		Metric:    "Temperature",
+		Value:     500,
+		Unit:      "C",
+		Min:       stageLimits.MinTemperature,
+		Max:       stageLimits.MaxTemperature,
+		TxID:      "tx1",
+	}, event)
+
+	require.Equal(t, []*OutOfRangeEvent{&event}, events)
+
+	telemetry = newTelemetry()
+	telemetry.Timestamp = "2024-12-13T09:00:00Z"
+	telemetry.Pressure = Reading{Value: 100000, Unit: "psi"}
+	telemetry = signTelemetry(t, privateKey, telemetry)
+	chaincodeStub.PutStateStub = nil
+	chaincodeStub.PutStateReturns(fmt.Errorf("failed inserting key"))
+	_, err = recordTelemetry(transactionContext, "asset1", myOrg1Msp, &telemetry)
+	require.EqualError(t, err, "failed to put to world state: failed inserting key")
+
+	telemetry.DeviceID = ""
+	_, err = recordTelemetry(transactionContext, "asset1", myOrg1Msp, &telemetry)
+	require.EqualError(t, err, "telemetry device ID is required")
+}
+
+func TestGetOutOfRangeEvents(t *testing.T) {
+	event := &OutOfRangeEvent{AssetID: "asset1", Metric: "Pressure"}
+	bytes, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextReturnsOnCall(0, true)
+	iterator.HasNextReturnsOnCall(1, false)
+	iterator.NextReturns(&queryresult.KV{Value: bytes}, nil)
+
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(iterator, nil)
+	assetTransfer := &SmartContract{}
+	events, err := assetTransfer.GetOutOfRangeEvents(transactionContext, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, []*OutOfRangeEvent{event}, events)
+
+	index, attributes := chaincodeStub.GetStateByPartialCompositeKeyArgsForCall(0)
+	require.Equal(t, "outOfRange~asset~timestamp~metric", index)
+	require.Equal(t, []string{"asset1"}, attributes)
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(nil, fmt.Errorf("failed retrieving events"))
+	events, err = assetTransfer.GetOutOfRangeEvents(transactionContext, "asset1")
+	require.EqualError(t, err, "failed retrieving events")
+	require.Nil(t, events)
+}
+
+func TestSetOutOfRangeEvent(t *testing.T) {
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	err := setOutOfRangeEvent(transactionContext, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, chaincodeStub.SetEventCallCount())
+
+	events := []*OutOfRangeEvent{
+		{AssetID: "asset1", Metric: "Temperature"},
+		{AssetID: "asset2", Metric: "Pressure"},
+	}
+	err = setOutOfRangeEvent(transactionContext, events)
+	require.NoError(t, err)
+	require.Equal(t, 1, chaincodeStub.SetEventCallCount())
+
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "TelemetryOutOfRange", name)
+	var emitted []*OutOfRangeEvent
+	require.NoError(t, json.Unmarshal(payload, &emitted))
+	require.Equal(t, events, emitted)
+}
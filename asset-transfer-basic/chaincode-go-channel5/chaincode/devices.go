@@ -0,0 +1,258 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"slices"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+const deviceObjectType = "device"
+
+// adminOU is the organizational unit that Fabric node OUs put in the certificates of org admins.
+const adminOU = "admin"
+
+// Device is a device or party key registered to sign IoT readings and stage handover documents.
+type Device struct {
+	ID         string `json:"ID"`
+	PublicKey  string `json:"Public_Key"`
+	Owner      string `json:"Owner"`
+	KeyVersion int    `json:"Key_Version"`
+	Revoked    bool   `json:"Revoked"`
+}
+
+// RegisterDevice registers the ECDSA public key of a device or party. The public key is given as a PEM
+// encoded public key or certificate. Only an org admin can register a device, and the org of the admin
+// becomes the owner of the device: its readings are accepted only for assets that org is the custodian of.
+func (s *SmartContract) RegisterDevice(ctx contractapi.TransactionContextInterface, deviceID string, publicKeyPEM string) error {
+	err := assertOrgAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	if deviceID == "" {
+		return fmt.Errorf("device ID is required")
+	}
+	existing, err := readDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("the device %s already exists", deviceID)
+	}
+	if _, err := parsePublicKey(publicKeyPEM); err != nil {
+		return err
+	}
+
+	owner, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+
+	device := Device{
+		ID:         deviceID,
+		PublicKey:  publicKeyPEM,
+		Owner:      owner,
+		KeyVersion: 1,
+	}
+	return putDevice(ctx, &device)
+}
+
+// RotateDeviceKey replaces the public key of a device. Only an admin of the owner of the device can rotate its key.
+func (s *SmartContract) RotateDeviceKey(ctx contractapi.TransactionContextInterface, deviceID string, publicKeyPEM string) error {
+	device, err := ownedDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if device.Revoked {
+		return fmt.Errorf("the device %s is revoked", deviceID)
+	}
+	if _, err := parsePublicKey(publicKeyPEM); err != nil {
+		return err
+	}
+
+	device.PublicKey = publicKeyPEM
+	device.KeyVersion++
+	return putDevice(ctx, device)
+}
+
+// RevokeDevice revokes a device so that its signatures are no longer accepted. Only an admin of the owner
+// of the device can revoke it.
+func (s *SmartContract) RevokeDevice(ctx contractapi.TransactionContextInterface, deviceID string) error {
+	device, err := ownedDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+
+	device.Revoked = true
+	return putDevice(ctx, device)
+}
+
+// ReadDevice returns the device registered with the given ID.
+func (s *SmartContract) ReadDevice(ctx contractapi.TransactionContextInterface, deviceID string) (*Device, error) {
+	device, err := readDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, fmt.Errorf("the device %s does not exist", deviceID)
+	}
+	return device, nil
+}
+
+// readDevice returns the device with the given ID, or nil when no such device is registered.
+func readDevice(ctx contractapi.TransactionContextInterface, deviceID string) (*Device, error) {
+	deviceKey, err := ctx.GetStub().CreateCompositeKey(deviceObjectType, []string{deviceID})
+	if err != nil {
+		return nil, err
+	}
+	deviceJSON, err := ctx.GetStub().GetState(deviceKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if deviceJSON == nil {
+		return nil, nil
+	}
+
+	var device Device
+	err = json.Unmarshal(deviceJSON, &device)
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// ownedDevice returns the device for a change by an admin of the org that owns it.
+func ownedDevice(ctx contractapi.TransactionContextInterface, deviceID string) (*Device, error) {
+	err := assertOrgAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	device, err := readDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, fmt.Errorf("the device %s does not exist", deviceID)
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if clientMSPID != device.Owner {
+		return nil, fmt.Errorf("client from %s is not authorized to change device %s owned by %s", clientMSPID, deviceID, device.Owner)
+	}
+	return device, nil
+}
+
+// assertOrgAdmin rejects callers whose certificate does not carry the admin organizational unit.
+func assertOrgAdmin(ctx contractapi.TransactionContextInterface) error {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	certificate, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return fmt.Errorf("failed to get client certificate: %v", err)
+	}
+	if certificate != nil && slices.Contains(certificate.Subject.OrganizationalUnit, adminOU) {
+		return nil
+	}
+	return fmt.Errorf("client is not an admin of %s", clientMSPID)
+}
+
+// verifyDeviceOwner checks that the device is owned by the custodian of the asset it reports on, so an org
+// cannot sign readings for a shipment it does not hold.
+func verifyDeviceOwner(ctx contractapi.TransactionContextInterface, deviceID string, custodian string) error {
+	device, err := readDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if device == nil {
+		return fmt.Errorf("the device %s is not registered", deviceID)
+	}
+	if device.Owner != custodian {
+		return fmt.Errorf("the device %s is owned by %s, not by the custodian %s", deviceID, device.Owner, custodian)
+	}
+	return nil
+}
+
+func putDevice(ctx contractapi.TransactionContextInterface, device *Device) error {
+	deviceKey, err := ctx.GetStub().CreateCompositeKey(deviceObjectType, []string{device.ID})
+	if err != nil {
+		return err
+	}
+	deviceJSON, err := json.Marshal(device)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(deviceKey, deviceJSON)
+}
+
+// parsePublicKey reads an ECDSA public key from a PEM encoded public key or certificate.
+func parsePublicKey(publicKeyPEM string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("public key is not PEM encoded")
+	}
+
+	var publicKey any
+	switch block.Type {
+	case "CERTIFICATE":
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %v", err)
+		}
+		publicKey = certificate.PublicKey
+	case "PUBLIC KEY":
+		var err error
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %s", block.Type)
+	}
+
+	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not an ECDSA key")
+	}
+	return ecdsaPublicKey, nil
+}
+
+// verifySignature checks a base64 encoded ASN.1 ECDSA signature over the SHA-256 digest of the payload
+// against the key registered for the signer.
+func verifySignature(ctx contractapi.TransactionContextInterface, signerID string, payload []byte, signature string) error {
+	device, err := readDevice(ctx, signerID)
+	if err != nil {
+		return err
+	}
+	if device == nil {
+		return fmt.Errorf("the device %s is not registered", signerID)
+	}
+	if device.Revoked {
+		return fmt.Errorf("the device %s is revoked", signerID)
+	}
+
+	publicKey, err := parsePublicKey(device.PublicKey)
+	if err != nil {
+		return err
+	}
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("signature of %s is not base64 encoded: %v", signerID, err)
+	}
+
+	digest := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(publicKey, digest[:], signatureBytes) {
+		return fmt.Errorf("invalid signature from %s", signerID)
+	}
+	return nil
+}
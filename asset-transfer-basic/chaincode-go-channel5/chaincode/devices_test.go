@@ -0,0 +1,209 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	myOrg1Msp = "Org1Testmsp"
+	myOrg2Msp = "Org2Testmsp"
+	// txTimestamp is the timestamp of every transaction run with newWorldStateStub.
+	txTimestamp = "2024-12-13T09:00:00Z"
+)
+
+// newWorldStateStub returns a stub whose GetState and PutState read and write the given map.
+func newWorldStateStub(state map[string][]byte) *mocks.ChaincodeStub {
+	chaincodeStub := &mocks.ChaincodeStub{}
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Date(2024, 12, 13, 9, 0, 0, 0, time.UTC)), nil)
+	chaincodeStub.CreateCompositeKeyStub = shim.CreateCompositeKey
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		state[key] = value
+		return nil
+	}
+	return chaincodeStub
+}
+
+func newTransactionContext(chaincodeStub *mocks.ChaincodeStub, mspID string) *mocks.TransactionContext {
+	return newTransactionContextWithOU(chaincodeStub, mspID, "client")
+}
+
+// newAdminTransactionContext returns a transaction context whose client is an admin of the org.
+func newAdminTransactionContext(chaincodeStub *mocks.ChaincodeStub, mspID string) *mocks.TransactionContext {
+	return newTransactionContextWithOU(chaincodeStub, mspID, adminOU)
+}
+
+func newTransactionContextWithOU(chaincodeStub *mocks.ChaincodeStub, mspID string, ou string) *mocks.TransactionContext {
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns(mspID, nil)
+	clientIdentity.GetX509CertificateReturns(&x509.Certificate{Subject: pkix.Name{OrganizationalUnit: []string{ou}}}, nil)
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+	return transactionContext
+}
+
+func newSigningKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	return privateKey, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}))
+}
+
+func signPayload(t *testing.T, privateKey *ecdsa.PrivateKey, payload []byte) string {
+	digest := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestRegisterDevice(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	transactionContext := newAdminTransactionContext(chaincodeStub, myOrg1Msp)
+	_, publicKeyPEM := newSigningKey(t)
+
+	assetTransfer := SmartContract{}
+	err := assetTransfer.RegisterDevice(newTransactionContext(chaincodeStub, myOrg1Msp), "IOT-D001", publicKeyPEM)
+	require.EqualError(t, err, "client is not an admin of Org1Testmsp")
+
+	err = assetTransfer.RegisterDevice(transactionContext, "IOT-D001", publicKeyPEM)
+	require.NoError(t, err)
+
+	device, err := assetTransfer.ReadDevice(transactionContext, "IOT-D001")
+	require.NoError(t, err)
+	require.Equal(t, &Device{ID: "IOT-D001", PublicKey: publicKeyPEM, Owner: myOrg1Msp, KeyVersion: 1}, device)
+
+	err = assetTransfer.RegisterDevice(transactionContext, "IOT-D001", publicKeyPEM)
+	require.EqualError(t, err, "the device IOT-D001 already exists")
+
+	err = assetTransfer.RegisterDevice(transactionContext, "IOT-D002", "not a key")
+	require.EqualError(t, err, "public key is not PEM encoded")
+
+	_, err = assetTransfer.ReadDevice(transactionContext, "IOT-D002")
+	require.EqualError(t, err, "the device IOT-D002 does not exist")
+}
+
+func TestRotateDeviceKey(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	transactionContext := newAdminTransactionContext(chaincodeStub, myOrg1Msp)
+	oldKey, oldPublicKeyPEM := newSigningKey(t)
+	newKey, newPublicKeyPEM := newSigningKey(t)
+
+	assetTransfer := SmartContract{}
+	require.NoError(t, assetTransfer.RegisterDevice(transactionContext, "IOT-D001", oldPublicKeyPEM))
+
+	otherOrgContext := newAdminTransactionContext(chaincodeStub, myOrg2Msp)
+	err := assetTransfer.RotateDeviceKey(otherOrgContext, "IOT-D001", newPublicKeyPEM)
+	require.EqualError(t, err, "client from Org2Testmsp is not authorized to change device IOT-D001 owned by Org1Testmsp")
+
+	err = assetTransfer.RotateDeviceKey(newTransactionContext(chaincodeStub, myOrg1Msp), "IOT-D001", newPublicKeyPEM)
+	require.EqualError(t, err, "client is not an admin of Org1Testmsp")
+
+	err = assetTransfer.RotateDeviceKey(transactionContext, "IOT-D001", newPublicKeyPEM)
+	require.NoError(t, err)
+
+	device, err := assetTransfer.ReadDevice(transactionContext, "IOT-D001")
+	require.NoError(t, err)
+	require.Equal(t, newPublicKeyPEM, device.PublicKey)
+	require.Equal(t, 2, device.KeyVersion)
+
+	payload := []byte("reading")
+	err = verifySignature(transactionContext, "IOT-D001", payload, signPayload(t, oldKey, payload))
+	require.EqualError(t, err, "invalid signature from IOT-D001")
+	err = verifySignature(transactionContext, "IOT-D001", payload, signPayload(t, newKey, payload))
+	require.NoError(t, err)
+
+	err = assetTransfer.RotateDeviceKey(transactionContext, "IOT-D002", newPublicKeyPEM)
+	require.EqualError(t, err, "the device IOT-D002 does not exist")
+}
+
+func TestRevokeDevice(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	transactionContext := newAdminTransactionContext(chaincodeStub, myOrg1Msp)
+	privateKey, publicKeyPEM := newSigningKey(t)
+
+	assetTransfer := SmartContract{}
+	require.NoError(t, assetTransfer.RegisterDevice(transactionContext, "IOT-D001", publicKeyPEM))
+
+	err := assetTransfer.RevokeDevice(newAdminTransactionContext(chaincodeStub, myOrg2Msp), "IOT-D001")
+	require.EqualError(t, err, "client from Org2Testmsp is not authorized to change device IOT-D001 owned by Org1Testmsp")
+
+	err = assetTransfer.RevokeDevice(transactionContext, "IOT-D001")
+	require.NoError(t, err)
+
+	payload := []byte("reading")
+	err = verifySignature(transactionContext, "IOT-D001", payload, signPayload(t, privateKey, payload))
+	require.EqualError(t, err, "the device IOT-D001 is revoked")
+
+	err = assetTransfer.RotateDeviceKey(transactionContext, "IOT-D001", publicKeyPEM)
+	require.EqualError(t, err, "the device IOT-D001 is revoked")
+}
+
+func TestVerifySignature(t *testing.T) {
+	state := map[string][]byte{}
+	transactionContext := newTransactionContext(newWorldStateStub(state), myOrg1Msp)
+	privateKey, publicKeyPEM := newSigningKey(t)
+	otherKey, _ := newSigningKey(t)
+
+	deviceKey, err := shim.CreateCompositeKey("device", []string{"IOT-D001"})
+	require.NoError(t, err)
+	deviceJSON, err := json.Marshal(Device{ID: "IOT-D001", PublicKey: publicKeyPEM, Owner: myOrg1Msp, KeyVersion: 1})
+	require.NoError(t, err)
+	state[deviceKey] = deviceJSON
+
+	payload := []byte("reading")
+	err = verifySignature(transactionContext, "IOT-D001", payload, signPayload(t, privateKey, payload))
+	require.NoError(t, err)
+
+	err = verifySignature(transactionContext, "IOT-D001", []byte("tampered"), signPayload(t, privateKey, payload))
+	require.EqualError(t, err, "invalid signature from IOT-D001")
+
+	err = verifySignature(transactionContext, "IOT-D001", payload, signPayload(t, otherKey, payload))
+	require.EqualError(t, err, "invalid signature from IOT-D001")
+
+	err = verifySignature(transactionContext, "IOT-D001", payload, "sig-abcdef123456")
+	require.ErrorContains(t, err, "signature of IOT-D001 is not base64 encoded")
+
+	err = verifySignature(transactionContext, "IOT-D002", payload, signPayload(t, privateKey, payload))
+	require.EqualError(t, err, "the device IOT-D002 is not registered")
+}
+
+func TestVerifyDeviceOwner(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newWorldStateStub(state)
+	transactionContext := newAdminTransactionContext(chaincodeStub, myOrg1Msp)
+	_, publicKeyPEM := newSigningKey(t)
+
+	assetTransfer := SmartContract{}
+	require.NoError(t, assetTransfer.RegisterDevice(transactionContext, "IOT-D001", publicKeyPEM))
+
+	err := verifyDeviceOwner(transactionContext, "IOT-D001", myOrg1Msp)
+	require.NoError(t, err)
+
+	err = verifyDeviceOwner(transactionContext, "IOT-D001", myOrg2Msp)
+	require.EqualError(t, err, "the device IOT-D001 is owned by Org1Testmsp, not by the custodian Org2Testmsp")
+
+	err = verifyDeviceOwner(transactionContext, "IOT-D002", myOrg1Msp)
+	require.EqualError(t, err, "the device IOT-D002 is not registered")
+}
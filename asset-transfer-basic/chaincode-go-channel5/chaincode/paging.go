@@ -0,0 +1,135 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// billDateLayout is the layout of the bill dates the filter of GetAssetsPage compares.
+const billDateLayout = "2006-01-02"
+
+// AssetPage is one page of the assets in world state. Pass the bookmark to GetAssetsPage to read the next
+// page; an empty bookmark means every asset has been read.
+type AssetPage struct {
+	Records             []*Asset `json:"Records"`
+	FetchedRecordsCount int32    `json:"Fetched_Records_Count"`
+	Bookmark            string   `json:"Bookmark"`
+}
+
+// assetFilter selects the assets of a page, see GetAssetsPage.
+type assetFilter struct {
+	from     time.Time
+	to       time.Time
+	carrier  string
+	consumer string
+}
+
+// GetAssetsPage returns a page of at most pageSize assets, in key order, starting at the bookmark of the
+// previous page. Unlike GetAllAssets, the response stays within the gRPC message size limit however many
+// assets there are. The page holds only the assets whose bill is dated between from and to, both inclusive
+// and written as 2006-01-02, whose bill names carrier, and that are handed over to consumer; leave any of
+// them empty to not filter on it. Paginated queries are only valid for read only transactions.
+func (s *SmartContract) GetAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string, from string, to string, carrier string, consumer string) (*AssetPage, error) {
+	if pageSize < 1 {
+		return nil, fmt.Errorf("the page size must be at least 1")
+	}
+	filter := assetFilter{carrier: carrier, consumer: consumer}
+	var err error
+	filter.from, filter.to, err = billDateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assets the filter skips still count against the page size of a range query, so read on until the
+	// page is full or the range is exhausted.
+	page := &AssetPage{Records: []*Asset{}, Bookmark: bookmark}
+	for {
+		records, next, err := matchingAssets(ctx, &filter, int32(pageSize-len(page.Records)), page.Bookmark)
+		if err != nil {
+			return nil, err
+		}
+		page.Records = append(page.Records, records...)
+		page.Bookmark = next
+		if len(page.Records) == pageSize || next == "" {
+			break
+		}
+	}
+	page.FetchedRecordsCount = int32(len(page.Records))
+
+	return page, nil
+}
+
+// matchingAssets reads one range query page of assets and returns those that match the filter, together
+// with the bookmark of the next page.
+func matchingAssets(ctx contractapi.TransactionContextInterface, filter *assetFilter, pageSize int32, bookmark string) ([]*Asset, string, error) {
+	// range query with empty string for startKey and endKey does an
+	// open-ended query of all assets in the chaincode namespace.
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resultsIterator.Close()
+
+	var assets []*Asset
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, "", err
+		}
+
+		var asset Asset
+		err = json.Unmarshal(queryResponse.Value, &asset)
+		if err != nil {
+			return nil, "", err
+		}
+		if filter.matches(&asset) {
+			assets = append(assets, &asset)
+		}
+	}
+
+	return assets, responseMetadata.Bookmark, nil
+}
+
+// billDateRange parses the bill date bounds of a filter. A bound that is left empty is the zero time.
+func billDateRange(from string, to string) (time.Time, time.Time, error) {
+	var fromDate, toDate time.Time
+	var err error
+	if from != "" {
+		fromDate, err = time.Parse(billDateLayout, from)
+		if err != nil {
+			return fromDate, toDate, fmt.Errorf("from %q is not a date like %s", from, billDateLayout)
+		}
+	}
+	if to != "" {
+		toDate, err = time.Parse(billDateLayout, to)
+		if err != nil {
+			return fromDate, toDate, fmt.Errorf("to %q is not a date like %s", to, billDateLayout)
+		}
+	}
+	if !fromDate.IsZero() && !toDate.IsZero() && fromDate.After(toDate) {
+		return fromDate, toDate, fmt.Errorf("from %s is after to %s", from, to)
+	}
+	return fromDate, toDate, nil
+}
+
+// matches reports whether the asset passes the filter. An asset whose bill date does not parse never
+// matches a date range.
+func (f *assetFilter) matches(asset *Asset) bool {
+	if f.carrier != "" && asset.Bill.CarrierName != f.carrier {
+		return false
+	}
+	if f.consumer != "" && asset.ConsumerName != f.consumer {
+		return false
+	}
+	if f.from.IsZero() && f.to.IsZero() {
+		return true
+	}
+	date, err := time.Parse(billDateLayout, asset.Bill.Date)
+	if err != nil {
+		return false
+	}
+	return !date.Before(f.from) && (f.to.IsZero() || !date.After(f.to))
+}
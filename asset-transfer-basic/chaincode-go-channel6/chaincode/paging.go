@@ -0,0 +1,135 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// drillDateLayout is the layout of the drilling dates the filter of GetAssetsPage compares.
+const drillDateLayout = "2006-01-02"
+
+// AssetPage is one page of the main chain summaries in world state. Pass the bookmark to GetAssetsPage to read the next
+// page; an empty bookmark means every asset has been read.
+type AssetPage struct {
+	Records             []*Asset `json:"Records"`
+	FetchedRecordsCount int32    `json:"Fetched_Records_Count"`
+	Bookmark            string   `json:"Bookmark"`
+}
+
+// assetFilter selects the assets of a page, see GetAssetsPage.
+type assetFilter struct {
+	from     time.Time
+	to       time.Time
+	consumer string
+}
+
+// GetAssetsPage returns a page of at most pageSize main chain summaries, in key order, starting at the
+// bookmark of the previous page. Unlike GetAllAssets, the response stays within the gRPC message size limit
+// however many summaries there are. The page holds only the summaries of oil batches drilled between from
+// and to, both inclusive and written as 2006-01-02, and delivered to consumer; leave any of them empty to
+// not filter on it. Summaries do not record carriers, so carrier must be empty. It is accepted so every
+// stage is paged with the same arguments. Paginated queries are only valid for read only transactions.
+func (s *SmartContract) GetAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string, from string, to string, carrier string, consumer string) (*AssetPage, error) {
+	if pageSize < 1 {
+		return nil, fmt.Errorf("the page size must be at least 1")
+	}
+	if carrier != "" {
+		return nil, fmt.Errorf("the main chain summaries do not record carriers")
+	}
+	filter := assetFilter{consumer: consumer}
+	var err error
+	filter.from, filter.to, err = dateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assets the filter skips still count against the page size of a range query, so read on until the
+	// page is full or the range is exhausted.
+	page := &AssetPage{Records: []*Asset{}, Bookmark: bookmark}
+	for {
+		records, next, err := matchingAssets(ctx, &filter, int32(pageSize-len(page.Records)), page.Bookmark)
+		if err != nil {
+			return nil, err
+		}
+		page.Records = append(page.Records, records...)
+		page.Bookmark = next
+		if len(page.Records) == pageSize || next == "" {
+			break
+		}
+	}
+	page.FetchedRecordsCount = int32(len(page.Records))
+
+	return page, nil
+}
+
+// matchingAssets reads one range query page of assets and returns those that match the filter, together
+// with the bookmark of the next page.
+func matchingAssets(ctx contractapi.TransactionContextInterface, filter *assetFilter, pageSize int32, bookmark string) ([]*Asset, string, error) {
+	// range query with empty string for startKey and endKey does an
+	// open-ended query of all assets in the chaincode namespace.
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resultsIterator.Close()
+
+	var assets []*Asset
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, "", err
+		}
+
+		var asset Asset
+		err = json.Unmarshal(queryResponse.Value, &asset)
+		if err != nil {
+			return nil, "", err
+		}
+		if filter.matches(&asset) {
+			assets = append(assets, &asset)
+		}
+	}
+
+	return assets, responseMetadata.Bookmark, nil
+}
+
+// dateRange parses the date bounds of a filter. A bound that is left empty is the zero time.
+func dateRange(from string, to string) (time.Time, time.Time, error) {
+	var fromDate, toDate time.Time
+	var err error
+	if from != "" {
+		fromDate, err = time.Parse(drillDateLayout, from)
+		if err != nil {
+			return fromDate, toDate, fmt.Errorf("from %q is not a date like %s", from, drillDateLayout)
+		}
+	}
+	if to != "" {
+		toDate, err = time.Parse(drillDateLayout, to)
+		if err != nil {
+			return fromDate, toDate, fmt.Errorf("to %q is not a date like %s", to, drillDateLayout)
+		}
+	}
+	if !fromDate.IsZero() && !toDate.IsZero() && fromDate.After(toDate) {
+		return fromDate, toDate, fmt.Errorf("from %s is after to %s", from, to)
+	}
+	return fromDate, toDate, nil
+}
+
+// matches reports whether the summary passes the filter. A summary whose drilling date does not parse
+// never matches a date range.
+func (f *assetFilter) matches(asset *Asset) bool {
+	if f.consumer != "" && asset.Consumer.Name != f.consumer {
+		return false
+	}
+	if f.from.IsZero() && f.to.IsZero() {
+		return true
+	}
+	date, err := time.Parse(drillDateLayout, asset.Driller.Date)
+	if err != nil {
+		return false
+	}
+	return !date.Before(f.from) && (f.to.IsZero() || !date.After(f.to))
+}
@@ -0,0 +1,107 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// newAssetIterator returns an iterator over the given assets, keyed by their IDs as in world state.
+func newAssetIterator(t *testing.T, assets ...*chaincode.Asset) *mocks.StateQueryIterator {
+	iterator := &mocks.StateQueryIterator{}
+	for i, asset := range assets {
+		assetJSON, err := json.Marshal(asset)
+		require.NoError(t, err)
+		iterator.HasNextReturnsOnCall(i, true)
+		iterator.NextReturnsOnCall(i, &queryresult.KV{Key: asset.ID, Value: assetJSON}, nil)
+	}
+	iterator.HasNextReturnsOnCall(len(assets), false)
+	return iterator
+}
+
+func newSummary(id string, date string, consumer string) *chaincode.Asset {
+	return &chaincode.Asset{
+		ID:       id,
+		Driller:  chaincode.Drilling{Name: "Driller A", Date: date},
+		Consumer: chaincode.Consumers{Name: consumer},
+	}
+}
+
+func TestGetAssetsPage(t *testing.T) {
+	first := newSummary("MAIN-OIL-001", "2024-12-01", "Refinery A")
+	second := newSummary("MAIN-OIL-002", "2024-12-02", "Refinery B")
+
+	chaincodeStub := newChaincodeStub()
+	chaincodeStub.GetStateByRangeWithPaginationReturns(newAssetIterator(t, first, second), &peer.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "MAIN-OIL-003"}, nil)
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	assetTransfer := chaincode.SmartContract{}
+	page, err := assetTransfer.GetAssetsPage(transactionContext, 2, "", "", "", "", "")
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.AssetPage{Records: []*chaincode.Asset{first, second}, FetchedRecordsCount: 2, Bookmark: "MAIN-OIL-003"}, page)
+
+	startKey, endKey, pageSize, bookmark := chaincodeStub.GetStateByRangeWithPaginationArgsForCall(0)
+	require.Equal(t, "", startKey)
+	require.Equal(t, "", endKey)
+	require.Equal(t, int32(2), pageSize)
+	require.Equal(t, "", bookmark)
+}
+
+func TestGetAssetsPageFilter(t *testing.T) {
+	first := newSummary("MAIN-OIL-001", "2024-12-01", "Refinery A")
+	second := newSummary("MAIN-OIL-002", "2024-12-02", "Refinery B")
+	third := newSummary("MAIN-OIL-003", "2024-12-03", "Refinery A")
+	fourth := newSummary("MAIN-OIL-004", "2024-12-09", "Refinery A")
+
+	chaincodeStub := newChaincodeStub()
+	chaincodeStub.GetStateByRangeWithPaginationReturnsOnCall(0, newAssetIterator(t, first, second), &peer.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "MAIN-OIL-003"}, nil)
+	chaincodeStub.GetStateByRangeWithPaginationReturnsOnCall(1, newAssetIterator(t, third), &peer.QueryResponseMetadata{FetchedRecordsCount: 1, Bookmark: "MAIN-OIL-004"}, nil)
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	// The consumer filter skips the second asset, so the rest of the page is read from where the first range query ended.
+	assetTransfer := chaincode.SmartContract{}
+	page, err := assetTransfer.GetAssetsPage(transactionContext, 2, "", "2024-12-01", "2024-12-05", "", "Refinery A")
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.AssetPage{Records: []*chaincode.Asset{first, third}, FetchedRecordsCount: 2, Bookmark: "MAIN-OIL-004"}, page)
+
+	_, _, pageSize, bookmark := chaincodeStub.GetStateByRangeWithPaginationArgsForCall(1)
+	require.Equal(t, int32(1), pageSize)
+	require.Equal(t, "MAIN-OIL-003", bookmark)
+
+	// Assets outside the date range are skipped until the range query is exhausted.
+	chaincodeStub.GetStateByRangeWithPaginationReturnsOnCall(2, newAssetIterator(t, fourth), &peer.QueryResponseMetadata{FetchedRecordsCount: 1}, nil)
+	page, err = assetTransfer.GetAssetsPage(transactionContext, 2, "MAIN-OIL-004", "2024-12-01", "2024-12-05", "", "Refinery A")
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.AssetPage{Records: []*chaincode.Asset{}}, page)
+}
+
+func TestGetAssetsPageErrors(t *testing.T) {
+	chaincodeStub := newChaincodeStub()
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	assetTransfer := chaincode.SmartContract{}
+
+	_, err := assetTransfer.GetAssetsPage(transactionContext, 0, "", "", "", "", "")
+	require.EqualError(t, err, "the page size must be at least 1")
+
+	_, err = assetTransfer.GetAssetsPage(transactionContext, 10, "", "12/01/2024", "", "", "")
+	require.EqualError(t, err, `from "12/01/2024" is not a date like 2006-01-02`)
+
+	_, err = assetTransfer.GetAssetsPage(transactionContext, 10, "", "2024-12-05", "2024-12-01", "", "")
+	require.EqualError(t, err, "from 2024-12-05 is after to 2024-12-01")
+
+	_, err = assetTransfer.GetAssetsPage(transactionContext, 10, "", "", "", "PipeCo", "")
+	require.EqualError(t, err, "the main chain summaries do not record carriers")
+
+	chaincodeStub.GetStateByRangeWithPaginationReturns(nil, nil, fmt.Errorf("failed retrieving assets"))
+	_, err = assetTransfer.GetAssetsPage(transactionContext, 10, "", "", "", "", "")
+	require.EqualError(t, err, "failed retrieving assets")
+}
@@ -0,0 +1,201 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+const (
+	// telemetryIndex keys every reading of an asset by its timestamp, so the series of an asset can be read
+	// in chronological order without reading the asset itself.
+	telemetryIndex = "telemetry~asset~timestamp~device"
+	// summaryPageSize is the number of readings read per page while aggregating a series.
+	summaryPageSize = 100
+)
+
+// TelemetryPage is one page of the telemetry series of an asset. Pass the bookmark to GetTelemetry to read
+// the next page; an empty bookmark means the series has been read to its end.
+type TelemetryPage struct {
+	Records             []*Telemetry `json:"Records"`
+	FetchedRecordsCount int32        `json:"Fetched_Records_Count"`
+	Bookmark            string       `json:"Bookmark"`
+}
+
+// MetricSummary aggregates one metric of a telemetry series, in degrees Celsius, bar or barrels.
+type MetricSummary struct {
+	Metric  string  `json:"Metric"`
+	Unit    string  `json:"Unit"`
+	Min     float64 `json:"Min"`
+	Max     float64 `json:"Max"`
+	Average float64 `json:"Average"`
+}
+
+// TelemetrySummary aggregates the readings of an asset taken between From and To.
+type TelemetrySummary struct {
+	AssetID string           `json:"Asset_ID"`
+	From    string           `json:"From"`
+	To      string           `json:"To"`
+	Count   int              `json:"Count"`
+	Metrics []*MetricSummary `json:"Metrics"`
+}
+
+// GetTelemetry returns a page of the readings of an asset taken between from and to, both inclusive and
+// in RFC 3339, oldest first. Leave from or to empty to leave the range open on that side.
+// Paginated queries are only valid for read only transactions.
+func (s *SmartContract) GetTelemetry(ctx contractapi.TransactionContextInterface, assetID string, from string, to string, pageSize int, bookmark string) (*TelemetryPage, error) {
+	fromKey, toKey, err := telemetryRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return telemetryPage(ctx, assetID, fromKey, toKey, int32(pageSize), bookmark)
+}
+
+// GetTelemetrySummary returns the minimum, maximum and average of every metric over the readings of an
+// asset taken between from and to, with the same range semantics as GetTelemetry.
+func (s *SmartContract) GetTelemetrySummary(ctx contractapi.TransactionContextInterface, assetID string, from string, to string) (*TelemetrySummary, error) {
+	fromKey, toKey, err := telemetryRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := []*MetricSummary{
+		{Metric: "Temperature", Unit: "C", Min: math.Inf(1), Max: math.Inf(-1)},
+		{Metric: "Pressure", Unit: "bar", Min: math.Inf(1), Max: math.Inf(-1)},
+		{Metric: "Quantity", Unit: "bbl", Min: math.Inf(1), Max: math.Inf(-1)},
+	}
+	summary := &TelemetrySummary{AssetID: assetID, From: from, To: to, Metrics: []*MetricSummary{}}
+
+	bookmark := ""
+	for {
+		page, err := telemetryPage(ctx, assetID, fromKey, toKey, summaryPageSize, bookmark)
+		if err != nil {
+			return nil, err
+		}
+		for _, telemetry := range page.Records {
+			values := []float64{
+				temperatureUnits[telemetry.Temperature.Unit](telemetry.Temperature.Value),
+				telemetry.Pressure.Value * pressureUnits[telemetry.Pressure.Unit],
+				telemetry.Quantity.Value * quantityUnits[telemetry.Quantity.Unit],
+			}
+			for i, value := range values {
+				metrics[i].Min = math.Min(metrics[i].Min, value)
+				metrics[i].Max = math.Max(metrics[i].Max, value)
+				metrics[i].Average += value
+			}
+			summary.Count++
+		}
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+
+	if summary.Count == 0 {
+		return summary, nil
+	}
+	for _, metric := range metrics {
+		metric.Average /= float64(summary.Count)
+	}
+	summary.Metrics = metrics
+	return summary, nil
+}
+
+// appendTelemetry adds a reading to the series of the asset. Readings are never overwritten, so a device
+// can report only one reading per asset and timestamp.
+func appendTelemetry(ctx contractapi.TransactionContextInterface, assetID string, timestamp string, telemetry *Telemetry) error {
+	readingKey, err := ctx.GetStub().CreateCompositeKey(telemetryIndex, []string{assetID, timestamp, telemetry.DeviceID})
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(readingKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("the reading of %s at %s is already recorded for %s", telemetry.DeviceID, telemetry.Timestamp, assetID)
+	}
+
+	telemetryJSON, err := json.Marshal(telemetry)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(readingKey, telemetryJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
+
+// telemetryRange converts an RFC 3339 range to the timestamps used in the series keys.
+func telemetryRange(from string, to string) (string, string, error) {
+	var fromKey, toKey string
+	var err error
+	if from != "" {
+		fromKey, err = timestampKey(from)
+		if err != nil {
+			return "", "", fmt.Errorf("from %q is not RFC 3339: %v", from, err)
+		}
+	}
+	if to != "" {
+		toKey, err = timestampKey(to)
+		if err != nil {
+			return "", "", fmt.Errorf("to %q is not RFC 3339: %v", to, err)
+		}
+	}
+	if fromKey != "" && toKey != "" && fromKey > toKey {
+		return "", "", fmt.Errorf("from %s is after to %s", from, to)
+	}
+	return fromKey, toKey, nil
+}
+
+// telemetryPage reads a page of the series of an asset. The bookmark of a range query is the key the next
+// page starts at, so the first page of a range starts at the key of its from timestamp. The page ends early,
+// with an empty bookmark, at the first reading after the to timestamp.
+func telemetryPage(ctx contractapi.TransactionContextInterface, assetID string, fromKey string, toKey string, pageSize int32, bookmark string) (*TelemetryPage, error) {
+	if bookmark == "" && fromKey != "" {
+		var err error
+		bookmark, err = ctx.GetStub().CreateCompositeKey(telemetryIndex, []string{assetID, fromKey})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(telemetryIndex, []string{assetID}, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	page := &TelemetryPage{Records: []*Telemetry{}, Bookmark: responseMetadata.Bookmark}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(attributes) != 3 {
+			return nil, fmt.Errorf("invalid telemetry key %q", queryResponse.Key)
+		}
+		if toKey != "" && attributes[1] > toKey {
+			page.Bookmark = ""
+			break
+		}
+
+		var telemetry Telemetry
+		err = json.Unmarshal(queryResponse.Value, &telemetry)
+		if err != nil {
+			return nil, err
+		}
+		page.Records = append(page.Records, &telemetry)
+	}
+	page.FetchedRecordsCount = int32(len(page.Records))
+
+	return page, nil
+}
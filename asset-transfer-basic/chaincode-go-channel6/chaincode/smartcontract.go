@@ -38,13 +38,6 @@ type Consumers struct {
 	Date        string `json:"Date"`
 	RealTimeSum string `json:"Reail_Time_Summary"`
 }
-type IotLogs struct {
-	Temperature string `json:"Temperature"`
-	Pressure    string `json:"Pressure"`
-	Location    string `json:"Location"`
-	Quantity    string `json:"Quantity"`
-	Quality     string `json:"Quality"`
-}
 type Asset struct {
 	ID               string     `json:"ID"`
 	Driller          Drilling   `json:"Driller"`
@@ -57,72 +50,133 @@ type Asset struct {
 	OilQualityCerti  string     `json:"Oil_Quality_Certificate"`
 	OilQuantity      string     `json:"Oil_Quantity"`
 	Time             string     `json:"Time_To_Complete"`
+	SignerID         string     `json:"Signer_ID"`
 	DigitalSignature string     `json:"Digital_Signature"`
-	IotData          []IotLogs  `json:"IotData"`
+	// Set by the contract: the org that created the summary. Only readings of its devices are recorded.
+	Custodian string      `json:"Custodian,omitempty" metadata:",optional"`
+	IotData   []Telemetry `json:"IotData"`
 }
 
-// CreateAsset issues a new asset to the world state with given details.
-func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, id string, drillName string, drillPay string, drillDate string, refName string, refPay string, refDate string, refReal string, stName string, stPay string, stDate string, stReal string, conName string, conPay string, conDate string, conReal string, complia string, payment string, oilID string, oilQuali string, oilQuanti string, time string, digSign string, iotTemp string, iotPres string, iotLoc string, iotquanti string, iotquali string) error {
-	exists, err := s.AssetExists(ctx, id)
+// CreateAsset issues a new asset to the world state. The asset is passed as a single JSON document, which
+// is validated against the Asset schema published in the contract metadata before the contract is called.
+func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, asset Asset) error {
+	events, err := s.createAsset(ctx, &asset)
 	if err != nil {
 		return err
 	}
+	return setOutOfRangeEvent(ctx, events)
+}
+
+// CreateAssets issues a batch of assets in a single transaction. Every asset is checked as in CreateAsset,
+// and the whole batch is rejected when any of them fails.
+func (s *SmartContract) CreateAssets(ctx contractapi.TransactionContextInterface, assets []Asset) error {
+	if len(assets) == 0 {
+		return fmt.Errorf("the batch is empty")
+	}
+
+	// Reads do not see the writes of the same transaction, so duplicates within the batch are caught here.
+	ids := make(map[string]bool)
+	var events []*OutOfRangeEvent
+	for i := range assets {
+		asset := &assets[i]
+		if ids[asset.ID] {
+			return fmt.Errorf("asset %d: the asset %s appears more than once in the batch", i, asset.ID)
+		}
+		ids[asset.ID] = true
+
+		assetEvents, err := s.createAsset(ctx, asset)
+		if err != nil {
+			return fmt.Errorf("asset %d (%s): %v", i, asset.ID, err)
+		}
+		events = append(events, assetEvents...)
+	}
+	return setOutOfRangeEvent(ctx, events)
+}
+
+// createAsset checks a new asset and writes it to the world state. It returns the out-of-range events of
+// its readings for the transaction to emit.
+func (s *SmartContract) createAsset(ctx contractapi.TransactionContextInterface, asset *Asset) ([]*OutOfRangeEvent, error) {
+	exists, err := s.AssetExists(ctx, asset.ID)
+	if err != nil {
+		return nil, err
+	}
 	if exists {
-		return fmt.Errorf("the asset %s already exists", id)
-	}
-	drill := Drilling{
-		Name:    drillName,
-		Payment: drillPay,
-		Date:    drillDate,
-	}
-	refin := Refineries{
-		Name:        refName,
-		Payment:     refPay,
-		Date:        refDate,
-		RealTimeSum: refReal,
-	}
-	stor := Storages{
-		Name:        stName,
-		Payment:     stPay,
-		Date:        stDate,
-		RealTimeSum: stReal,
-	}
-	consu := Consumers{
-		Name:        conName,
-		Payment:     conPay,
-		Date:        conDate,
-		RealTimeSum: conReal,
-	}
-	iot := []IotLogs{
-		{
-			Temperature: iotTemp,
-			Pressure:    iotPres,
-			Location:    iotLoc,
-			Quantity:    iotquanti,
-			Quality:     iotquali,
-		},
-	}
-	asset := Asset{
-		ID:               id,
-		Driller:          drill,
-		Refinery:         refin,
-		Storage:          stor,
-		Consumer:         consu,
-		ComplianceReport: complia,
-		Payment:          payment,
-		OilId:            oilID,
-		OilQualityCerti:  oilQuali,
-		OilQuantity:      oilQuanti,
-		Time:             time,
-		DigitalSignature: digSign,
-		IotData:          iot,
+		return nil, fmt.Errorf("the asset %s already exists", asset.ID)
+	}
+	asset.Custodian, err = ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	var events []*OutOfRangeEvent
+	for i := range asset.IotData {
+		readingEvents, err := recordTelemetry(ctx, asset.ID, asset.Custodian, &asset.IotData[i])
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, readingEvents...)
+	}
+	err = verifyHandover(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.GetStub().PutState(asset.ID, assetJSON)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// UpdateSummary issues the main chain summary of an oil batch, or replaces it when it exists. The
+// reconciler submits it each time a stage of the batch completes. Its readings are copied from the stage
+// contracts, which verified them against the devices registered on their own channels, so they are kept
+// as they are: they are not checked against the devices of this channel nor added to its telemetry series.
+// An existing summary can only be replaced by its custodian, the org that created it, and must keep the
+// signer it was created with.
+func (s *SmartContract) UpdateSummary(ctx contractapi.TransactionContextInterface, asset Asset) error {
+	existingJSON, err := ctx.GetStub().GetState(asset.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if existingJSON != nil {
+		var existing Asset
+		err = json.Unmarshal(existingJSON, &existing)
+		if err != nil {
+			return err
+		}
+		if clientMSPID != existing.Custodian {
+			return fmt.Errorf("client from %s is not the custodian of summary %s, %s is", clientMSPID, asset.ID, existing.Custodian)
+		}
+		if asset.SignerID != existing.SignerID {
+			return fmt.Errorf("the summary %s is signed by %s, not %s", asset.ID, existing.SignerID, asset.SignerID)
+		}
+	}
+	asset.Custodian = clientMSPID
+
+	for i := range asset.IotData {
+		err = validateTelemetry(&asset.IotData[i])
+		if err != nil {
+			return err
+		}
+	}
+	err = verifyHandover(ctx, &asset)
+	if err != nil {
+		return err
 	}
 	assetJSON, err := json.Marshal(asset)
 	if err != nil {
 		return err
 	}
 
-	return ctx.GetStub().PutState(id, assetJSON)
+	return ctx.GetStub().PutState(asset.ID, assetJSON)
 }
 
 // ReadAsset returns the asset stored in the world state with given id.
@@ -195,28 +249,40 @@ func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface
 	return assets, nil
 }
 
-// updatre
-func (s *SmartContract) UpdateIoTLogs(ctx contractapi.TransactionContextInterface, id string, temp string, press string, loca string, quanti string, quali string) (string, error) {
+// UpdateIoTLogs appends an IoT reading to the telemetry series of the main chain record, see GetTelemetry.
+// The record keeps the readings it was created with; later readings live in the series only. The reading
+// must be signed by a device of the custodian of the record.
+func (s *SmartContract) UpdateIoTLogs(ctx contractapi.TransactionContextInterface, id string, iotData Telemetry) (string, error) {
 	asset, err := s.ReadAsset(ctx, id)
 	if err != nil {
 		return "", err
 	}
-	iotLogs := IotLogs{
-		Temperature: temp,
-		Pressure:    press,
-		Location:    loca,
-		Quantity:    quanti,
-		Quality:     quali,
+	events, err := recordTelemetry(ctx, id, asset.Custodian, &iotData)
+	if err != nil {
+		return "", err
 	}
-	asset.IotData = append(asset.IotData, iotLogs)
-	assetJSON, err := json.Marshal(asset)
+	err = setOutOfRangeEvent(ctx, events)
 	if err != nil {
-		return "", fmt.Errorf("Just giveup coding. err: %s", err)
+		return "", err
 	}
+	return "It's all good", nil
+}
 
-	err = ctx.GetStub().PutState(id, assetJSON)
+// handoverPayload returns the bytes signed by the party handing over the shipment: the JSON of the asset
+// without its digital signature, without IoT readings, which are signed by the devices themselves, and
+// without the custodian, which is set by the contract.
+func handoverPayload(asset Asset) ([]byte, error) {
+	asset.DigitalSignature = ""
+	asset.Custodian = ""
+	asset.IotData = nil
+	return json.Marshal(asset)
+}
+
+// verifyHandover checks the digital signature of the asset against the key registered for its signer.
+func verifyHandover(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	payload, err := handoverPayload(*asset)
 	if err != nil {
-		return "", fmt.Errorf("You're terrible at this. err: %s", err)
+		return err
 	}
-	return "It's all good", nil
+	return verifySignature(ctx, asset.SignerID, payload, asset.DigitalSignature)
 }
@@ -1,12 +1,21 @@
 package chaincode_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
 	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-contract-api-go/v2/metadata"
 	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
 	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
 	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
@@ -28,64 +37,282 @@ type stateQueryIterator interface {
 	shim.StateQueryIteratorInterface
 }
 
-func TestInitLedger(t *testing.T) {
+//go:generate counterfeiter -o mocks/clientIdentity.go -fake-name ClientIdentity . clientIdentity
+type clientIdentity interface {
+	cid.ClientIdentity
+}
+
+func newChaincodeStub() *mocks.ChaincodeStub {
 	chaincodeStub := &mocks.ChaincodeStub{}
-	transactionContext := &mocks.TransactionContext{}
-	transactionContext.GetStubReturns(chaincodeStub)
+	chaincodeStub.CreateCompositeKeyStub = shim.CreateCompositeKey
+	chaincodeStub.SplitCompositeKeyStub = func(key string) (string, []string, error) {
+		return (&shim.ChaincodeStub{}).SplitCompositeKey(key)
+	}
+	return chaincodeStub
+}
 
-	assetTransfer := chaincode.SmartContract{}
-	err := assetTransfer.InitLedger(transactionContext)
+func newTelemetry() chaincode.Telemetry {
+	return chaincode.Telemetry{
+		DeviceID:    "IOT-D001",
+		Timestamp:   "2024-12-13T08:00:00Z",
+		Temperature: chaincode.Reading{Value: 25, Unit: "C"},
+		Pressure:    chaincode.Reading{Value: 10, Unit: "bar"},
+		Quantity:    chaincode.Reading{Value: 10000, Unit: "bbl"},
+		Quality:     "Grade A",
+		Coordinates: chaincode.Coordinates{Latitude: 29.7604, Longitude: -95.3698},
+	}
+}
+
+// registerSigningKey stores a freshly generated key for the device in the world state and returns its private key.
+func registerSigningKey(t *testing.T, state map[string][]byte, deviceID string) *ecdsa.PrivateKey {
+	return registerOwnedSigningKey(t, state, deviceID, "Org1MSP")
+}
+
+// registerOwnedSigningKey is registerSigningKey for a device owned by owner.
+func registerOwnedSigningKey(t *testing.T, state map[string][]byte, deviceID string, owner string) *ecdsa.PrivateKey {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
 	require.NoError(t, err)
 
-	chaincodeStub.PutStateReturns(fmt.Errorf("failed inserting key"))
-	err = assetTransfer.InitLedger(transactionContext)
-	require.EqualError(t, err, "failed to put to world state. failed inserting key")
+	device := chaincode.Device{
+		ID:         deviceID,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})),
+		Owner:      owner,
+		KeyVersion: 1,
+	}
+	deviceJSON, err := json.Marshal(device)
+	require.NoError(t, err)
+	deviceKey, err := shim.CreateCompositeKey("device", []string{deviceID})
+	require.NoError(t, err)
+	state[deviceKey] = deviceJSON
+	return privateKey
+}
+
+// sign returns the base64 encoded signature over the JSON of the payload.
+func sign(t *testing.T, privateKey *ecdsa.PrivateKey, payload any) string {
+	payloadJSON, err := json.Marshal(payload)
+	require.NoError(t, err)
+	digest := sha256.Sum256(payloadJSON)
+	signature, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func newSignedTelemetry(t *testing.T, privateKey *ecdsa.PrivateKey) chaincode.Telemetry {
+	telemetry := newTelemetry()
+	telemetry.Signature = sign(t, privateKey, telemetry)
+	return telemetry
 }
 
 func TestCreateAsset(t *testing.T) {
-	chaincodeStub := &mocks.ChaincodeStub{}
+	state := map[string][]byte{}
+	chaincodeStub := newChaincodeStub()
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns("Org1MSP", nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+
+	deviceKey := registerSigningKey(t, state, "IOT-D001")
+	partyKey := registerSigningKey(t, state, "party1")
+	telemetry := newSignedTelemetry(t, deviceKey)
+	handover := sign(t, partyKey, chaincode.Asset{ID: "asset1", SignerID: "party1"})
 
 	assetTransfer := chaincode.SmartContract{}
-	err := assetTransfer.CreateAsset(transactionContext, "", "", 0, "", 0)
+	err := assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset1", SignerID: "party1", DigitalSignature: handover, IotData: []chaincode.Telemetry{telemetry}})
+	require.NoError(t, err)
+	require.Equal(t, 2, chaincodeStub.PutStateCallCount())
+
+	readingKey, err := shim.CreateCompositeKey("telemetry~asset~timestamp~device", []string{"asset1", "2024-12-13T08:00:00.000000000Z", "IOT-D001"})
 	require.NoError(t, err)
+	key, _ := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, readingKey, key)
+
+	err = assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset2", SignerID: "party1", DigitalSignature: handover, IotData: []chaincode.Telemetry{telemetry}})
+	require.EqualError(t, err, "invalid signature from party1")
+
+	err = assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset1", SignerID: "party1", DigitalSignature: handover, IotData: []chaincode.Telemetry{newTelemetry()}})
+	require.EqualError(t, err, "invalid signature from IOT-D001")
 
+	err = assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset2", SignerID: "party1", DigitalSignature: handover, IotData: []chaincode.Telemetry{chaincode.Telemetry{}}})
+	require.EqualError(t, err, "telemetry device ID is required")
+
+	chaincodeStub.GetStateStub = nil
 	chaincodeStub.GetStateReturns([]byte{}, nil)
-	err = assetTransfer.CreateAsset(transactionContext, "asset1", "", 0, "", 0)
+	err = assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset1", SignerID: "party1", DigitalSignature: handover, IotData: []chaincode.Telemetry{telemetry}})
 	require.EqualError(t, err, "the asset asset1 already exists")
 
 	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	err = assetTransfer.CreateAsset(transactionContext, "asset1", "", 0, "", 0)
+	err = assetTransfer.CreateAsset(transactionContext, chaincode.Asset{ID: "asset1", SignerID: "party1", DigitalSignature: handover, IotData: []chaincode.Telemetry{telemetry}})
 	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
 }
 
-func TestReadAsset(t *testing.T) {
+func TestCreateAssetSchema(t *testing.T) {
+	assetChaincode, err := contractapi.NewChaincode(&chaincode.SmartContract{})
+	require.NoError(t, err)
+
 	chaincodeStub := &mocks.ChaincodeStub{}
+	chaincodeStub.GetFunctionAndParametersReturns("org.hyperledger.fabric:GetMetadata", nil)
+	response := assetChaincode.Invoke(chaincodeStub)
+	require.Equal(t, int32(shim.OK), response.Status)
+	var contractMetadata metadata.ContractChaincodeMetadata
+	err = json.Unmarshal(response.Payload, &contractMetadata)
+	require.NoError(t, err)
+	require.Contains(t, contractMetadata.Components.Schemas, "Asset")
+
+	chaincodeStub.GetFunctionAndParametersReturns("CreateAsset", []string{`{"ID":"asset1","Oil_Quantity_Typo":"12"}`})
+	response = assetChaincode.Invoke(chaincodeStub)
+	require.Equal(t, int32(shim.ERROR), response.Status)
+	require.Contains(t, response.Message, "Additional property Oil_Quantity_Typo is not allowed")
+	require.Contains(t, response.Message, "Oil_Batch_ID is required")
+}
+
+func TestCreateAssets(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newChaincodeStub()
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns("Org1MSP", nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+
+	deviceKey := registerSigningKey(t, state, "IOT-D001")
+	partyKey := registerSigningKey(t, state, "party1")
+	newAsset := func(id string) chaincode.Asset {
+		asset := chaincode.Asset{ID: id, SignerID: "party1"}
+		asset.DigitalSignature = sign(t, partyKey, asset)
+		asset.IotData = []chaincode.Telemetry{newSignedTelemetry(t, deviceKey)}
+		return asset
+	}
+	assets := []chaincode.Asset{newAsset("asset1"), newAsset("asset2")}
 
-	expectedAsset := &chaincode.Asset{ID: "asset1"}
-	bytes, err := json.Marshal(expectedAsset)
+	assetTransfer := chaincode.SmartContract{}
+	err := assetTransfer.CreateAssets(transactionContext, assets)
+	require.NoError(t, err)
+	require.Equal(t, 4, chaincodeStub.PutStateCallCount())
+	key, _ := chaincodeStub.PutStateArgsForCall(3)
+	require.Equal(t, "asset2", key)
+
+	err = assetTransfer.CreateAssets(transactionContext, []chaincode.Asset{assets[0], assets[0]})
+	require.EqualError(t, err, "asset 1: the asset asset1 appears more than once in the batch")
+
+	forged := newAsset("asset3")
+	forged.SignerID = "IOT-D001"
+	err = assetTransfer.CreateAssets(transactionContext, []chaincode.Asset{assets[0], forged})
+	require.EqualError(t, err, "asset 1 (asset3): invalid signature from IOT-D001")
+
+	err = assetTransfer.CreateAssets(transactionContext, nil)
+	require.EqualError(t, err, "the batch is empty")
+
+	overheated := newAsset("asset4")
+	overheated.IotData = nil
+	for _, timestamp := range []string{"2024-12-13T08:00:00Z", "2024-12-14T08:00:00Z"} {
+		reading := newTelemetry()
+		reading.Timestamp = timestamp
+		reading.Temperature = chaincode.Reading{Value: 500, Unit: "C"}
+		reading.Signature = sign(t, deviceKey, reading)
+		overheated.IotData = append(overheated.IotData, reading)
+	}
+	require.Equal(t, 0, chaincodeStub.SetEventCallCount())
+	err = assetTransfer.CreateAsset(transactionContext, overheated)
 	require.NoError(t, err)
+	require.Equal(t, 1, chaincodeStub.SetEventCallCount())
+
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "TelemetryOutOfRange", name)
+	var events []*chaincode.OutOfRangeEvent
+	require.NoError(t, json.Unmarshal(payload, &events))
+	require.Len(t, events, 2)
+	require.Equal(t, "2024-12-13T08:00:00Z", events[0].Timestamp)
+	require.Equal(t, "2024-12-14T08:00:00Z", events[1].Timestamp)
+}
+
+func TestUpdateSummary(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newChaincodeStub()
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		state[key] = value
+		return nil
+	}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns("Org1MSP", nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+
+	partyKey := registerSigningKey(t, state, "party1")
+	newSummary := func(payment string, readings ...chaincode.Telemetry) chaincode.Asset {
+		summary := chaincode.Asset{ID: "MAIN-OIL-1234", OilId: "OIL-1234", Payment: payment, SignerID: "party1"}
+		summary.DigitalSignature = sign(t, partyKey, summary)
+		summary.IotData = readings
+		return summary
+	}
+
+	// The stage readings are signed by devices of the driller and refinery, registered on their own channels.
+	drillerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	drilled := newSignedTelemetry(t, drillerKey)
+	refinery := registerOwnedSigningKey(t, state, "IOT-R001", "Org2MSP")
+	refined := newTelemetry()
+	refined.DeviceID = "IOT-R001"
+	refined.Timestamp = "2024-12-14T08:00:00Z"
+	refined.Temperature = chaincode.Reading{Value: 500, Unit: "C"}
+	refined.Signature = sign(t, refinery, refined)
 
-	chaincodeStub.GetStateReturns(bytes, nil)
 	assetTransfer := chaincode.SmartContract{}
-	asset, err := assetTransfer.ReadAsset(transactionContext, "")
+	err = assetTransfer.UpdateSummary(transactionContext, newSummary("$ 37,500", drilled))
 	require.NoError(t, err)
-	require.Equal(t, expectedAsset, asset)
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
 
-	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	_, err = assetTransfer.ReadAsset(transactionContext, "")
-	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
+	err = assetTransfer.UpdateSummary(transactionContext, newSummary("$ 75,000", drilled, refined))
+	require.NoError(t, err)
+	require.Equal(t, 2, chaincodeStub.PutStateCallCount())
+	require.Equal(t, 0, chaincodeStub.SetEventCallCount())
 
-	chaincodeStub.GetStateReturns(nil, nil)
-	asset, err = assetTransfer.ReadAsset(transactionContext, "asset1")
-	require.EqualError(t, err, "the asset asset1 does not exist")
-	require.Nil(t, asset)
+	summary, err := assetTransfer.ReadAsset(transactionContext, "MAIN-OIL-1234")
+	require.NoError(t, err)
+	require.Equal(t, "$ 75,000", summary.Payment)
+	require.Equal(t, []chaincode.Telemetry{drilled, refined}, summary.IotData)
+	require.Equal(t, "Org1MSP", summary.Custodian)
+
+	forged := newSummary("$ 75,000", drilled, refined)
+	forged.Payment = "$ 1"
+	err = assetTransfer.UpdateSummary(transactionContext, forged)
+	require.EqualError(t, err, "invalid signature from party1")
+
+	incomplete := newTelemetry()
+	incomplete.Quantity.Unit = "gal"
+	err = assetTransfer.UpdateSummary(transactionContext, newSummary("$ 75,000", drilled, incomplete))
+	require.EqualError(t, err, "unknown quantity unit \"gal\"")
+
+	otherKey := registerSigningKey(t, state, "party2")
+	resigned := newSummary("$ 1", drilled, refined)
+	resigned.SignerID = "party2"
+	resigned.DigitalSignature = ""
+	resigned.IotData = nil
+	resigned.DigitalSignature = sign(t, otherKey, resigned)
+	resigned.IotData = []chaincode.Telemetry{drilled, refined}
+	err = assetTransfer.UpdateSummary(transactionContext, resigned)
+	require.EqualError(t, err, "the summary MAIN-OIL-1234 is signed by party1, not party2")
+
+	otherOrg := &mocks.ClientIdentity{}
+	otherOrg.GetMSPIDReturns("Org2MSP", nil)
+	transactionContext.GetClientIdentityReturns(otherOrg)
+	err = assetTransfer.UpdateSummary(transactionContext, newSummary("$ 1", drilled, refined))
+	require.EqualError(t, err, "client from Org2MSP is not the custodian of summary MAIN-OIL-1234, Org1MSP is")
 }
 
-func TestUpdateAsset(t *testing.T) {
+func TestReadAsset(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
@@ -96,16 +323,18 @@ func TestUpdateAsset(t *testing.T) {
 
 	chaincodeStub.GetStateReturns(bytes, nil)
 	assetTransfer := chaincode.SmartContract{}
-	err = assetTransfer.UpdateAsset(transactionContext, "", "", 0, "", 0)
+	asset, err := assetTransfer.ReadAsset(transactionContext, "")
 	require.NoError(t, err)
-
-	chaincodeStub.GetStateReturns(nil, nil)
-	err = assetTransfer.UpdateAsset(transactionContext, "asset1", "", 0, "", 0)
-	require.EqualError(t, err, "the asset asset1 does not exist")
+	require.Equal(t, expectedAsset, asset)
 
 	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	err = assetTransfer.UpdateAsset(transactionContext, "asset1", "", 0, "", 0)
+	_, err = assetTransfer.ReadAsset(transactionContext, "")
 	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
+
+	chaincodeStub.GetStateReturns(nil, nil)
+	asset, err = assetTransfer.ReadAsset(transactionContext, "asset1")
+	require.EqualError(t, err, "the asset asset1 does not exist")
+	require.Nil(t, asset)
 }
 
 func TestDeleteAsset(t *testing.T) {
@@ -132,25 +361,6 @@ func TestDeleteAsset(t *testing.T) {
 	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
 }
 
-func TestTransferAsset(t *testing.T) {
-	chaincodeStub := &mocks.ChaincodeStub{}
-	transactionContext := &mocks.TransactionContext{}
-	transactionContext.GetStubReturns(chaincodeStub)
-
-	asset := &chaincode.Asset{ID: "asset1"}
-	bytes, err := json.Marshal(asset)
-	require.NoError(t, err)
-
-	chaincodeStub.GetStateReturns(bytes, nil)
-	assetTransfer := chaincode.SmartContract{}
-	_, err = assetTransfer.TransferAsset(transactionContext, "", "")
-	require.NoError(t, err)
-
-	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	_, err = assetTransfer.TransferAsset(transactionContext, "", "")
-	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
-}
-
 func TestGetAllAssets(t *testing.T) {
 	asset := &chaincode.Asset{ID: "asset1"}
 	bytes, err := json.Marshal(asset)
@@ -182,3 +392,46 @@ func TestGetAllAssets(t *testing.T) {
 	require.EqualError(t, err, "failed retrieving all assets")
 	require.Nil(t, assets)
 }
+
+func TestUpdateIoTLogs(t *testing.T) {
+	state := map[string][]byte{}
+	chaincodeStub := newChaincodeStub()
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	deviceKey := registerSigningKey(t, state, "IOT-D001")
+	asset := &chaincode.Asset{ID: "asset1", Custodian: "Org1MSP", IotData: []chaincode.Telemetry{newSignedTelemetry(t, deviceKey)}}
+	bytes, err := json.Marshal(asset)
+	require.NoError(t, err)
+	state["asset1"] = bytes
+
+	assetTransfer := chaincode.SmartContract{}
+	_, err = assetTransfer.UpdateIoTLogs(transactionContext, "asset1", newSignedTelemetry(t, deviceKey))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+	readingKey, err := shim.CreateCompositeKey("telemetry~asset~timestamp~device", []string{"asset1", "2024-12-13T08:00:00.000000000Z", "IOT-D001"})
+	require.NoError(t, err)
+	key, _ := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, readingKey, key)
+
+	_, err = assetTransfer.UpdateIoTLogs(transactionContext, "asset1", newTelemetry())
+	require.EqualError(t, err, "invalid signature from IOT-D001")
+
+	_, err = assetTransfer.UpdateIoTLogs(transactionContext, "asset1", chaincode.Telemetry{})
+	require.EqualError(t, err, "telemetry device ID is required")
+
+	asset.Custodian = "Org2MSP"
+	bytes, err = json.Marshal(asset)
+	require.NoError(t, err)
+	state["asset1"] = bytes
+	_, err = assetTransfer.UpdateIoTLogs(transactionContext, "asset1", newSignedTelemetry(t, deviceKey))
+	require.EqualError(t, err, "the device IOT-D001 is owned by Org1MSP, not by the custodian Org2MSP")
+
+	delete(state, "asset1")
+	_, err = assetTransfer.UpdateIoTLogs(transactionContext, "asset1", newSignedTelemetry(t, deviceKey))
+	require.EqualError(t, err, "the asset asset1 does not exist")
+}
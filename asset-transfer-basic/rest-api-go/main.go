@@ -1,26 +1,45 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"rest-api-go/web"
+	"time"
 )
 
 func main() {
-	//Initialize setup for Org1
-	cryptoPath := "../../test-network/organizations/peerOrganizations/org1.example.com"
-	orgConfig := web.OrgSetup{
-		OrgName:      "Org1",
-		MSPID:        "Org1MSP",
-		CertPath:     cryptoPath + "/users/User1@org1.example.com/msp/signcerts/cert.pem",
-		KeyPath:      cryptoPath + "/users/User1@org1.example.com/msp/keystore/",
-		TLSCertPath:  cryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt",
-		PeerEndpoint: "dns:///localhost:7051",
-		GatewayPeer:  "peer0.org1.example.com",
+	configPath := flag.String("config", "config.json", "organizations and users the server submits as")
+	issueToken := flag.Bool("issue-token", false, "print a bearer token signed with the configured JWT secret and exit, standing in for the identity provider")
+	subject := flag.String("subject", "", "caller named by the issued token")
+	role := flag.String("role", "", "role of the issued token")
+	ttl := flag.Duration("ttl", time.Hour, "lifetime of the issued token")
+	flag.Parse()
+
+	config, err := web.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Println("Error loading config: ", err)
+		os.Exit(1)
+	}
+
+	if *issueToken {
+		token, err := web.IssueToken(config, *subject, *role, *ttl)
+		if err != nil {
+			fmt.Println("Error issuing token: ", err)
+			os.Exit(1)
+		}
+		fmt.Println(token)
+		return
 	}
 
-	orgSetup, err := web.Initialize(orgConfig)
+	server, err := web.NewServer(config)
 	if err != nil {
-		fmt.Println("Error initializing setup for Org1: ", err)
+		fmt.Println("Error initializing setup: ", err)
+		os.Exit(1)
+	}
+	defer server.Close()
+
+	if err := server.Serve(); err != nil {
+		fmt.Println(err)
 	}
-	web.Serve(web.OrgSetup(*orgSetup))
 }
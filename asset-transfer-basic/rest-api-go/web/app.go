@@ -2,14 +2,20 @@ package web
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"google.golang.org/grpc"
 )
 
+// IdentityHeader selects the identity a request outside /orgs is signed with, as "<org>/<user>".
+const IdentityHeader = "X-Fabric-Identity"
+
 // OrgSetup contains organization's config to interact with the network.
 type OrgSetup struct {
 	OrgName      string
+	UserName     string
 	MSPID        string
 	CryptoPath   string
 	CertPath     string
@@ -20,12 +26,154 @@ type OrgSetup struct {
 	Gateway      client.Gateway
 }
 
-// Serve starts http web server.
-func Serve(setups OrgSetup) {
-	http.HandleFunc("/query", setups.Query)
-	http.HandleFunc("/invoke", setups.Invoke)
-	fmt.Println("Listening (http://localhost:3000/)...")
-	if err := http.ListenAndServe(":3000", nil); err != nil {
-		fmt.Println(err)
+// identityName names the identity of the setup the way requests select it.
+func (setup *OrgSetup) identityName() string {
+	return identityName(setup.OrgName, setup.UserName)
+}
+
+// Server serves chaincode requests for every configured identity.
+type Server struct {
+	listenAddress   string
+	defaultIdentity string
+	setups          map[string]*OrgSetup
+	connections     []*grpc.ClientConn
+	transactions    *transactionTracker
+	authenticator   *authenticator
+	audit           *auditLog
+}
+
+// NewServer connects a gateway for every user in the config. Users whose organizations share a gateway
+// peer share one gRPC connection to it.
+func NewServer(config *Config) (*Server, error) {
+	server := &Server{
+		listenAddress:   config.ListenAddress,
+		defaultIdentity: config.DefaultIdentity,
+		setups:          make(map[string]*OrgSetup),
+		transactions:    newTransactionTracker(config.CallbackHosts),
+	}
+	auditLogPath := ""
+	if config.Auth != nil {
+		policy, err := LoadPolicy(config.Auth.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		server.authenticator, err = newAuthenticator(config.Auth, policy)
+		if err != nil {
+			return nil, err
+		}
+		auditLogPath = config.Auth.AuditLog
+	} else {
+		log.Println("Authentication is disabled, every client may call any function")
+	}
+	audit, err := openAuditLog(auditLogPath)
+	if err != nil {
+		return nil, err
+	}
+	server.audit = audit
+
+	connections := make(map[connectionKey]*grpc.ClientConn)
+	for _, setup := range config.setups() {
+		key := connectionKey{setup.PeerEndpoint, setup.GatewayPeer, setup.TLSCertPath}
+		connection, ok := connections[key]
+		if !ok {
+			var err error
+			connection, err = setup.newGrpcConnection()
+			if err != nil {
+				server.Close()
+				return nil, err
+			}
+			connections[key] = connection
+			server.connections = append(server.connections, connection)
+		}
+
+		orgSetup, err := Initialize(setup, connection)
+		if err != nil {
+			server.Close()
+			return nil, fmt.Errorf("failed to initialize %s: %w", identityName(setup.OrgName, setup.UserName), err)
+		}
+		server.setups[identityName(setup.OrgName, setup.UserName)] = orgSetup
+	}
+	return server, nil
+}
+
+// connectionKey identifies a gateway peer, so the users connecting to it can share a connection.
+type connectionKey struct {
+	peerEndpoint string
+	gatewayPeer  string
+	tlsCertPath  string
+}
+
+// Close closes the gateways and the gRPC connections of the server.
+func (server *Server) Close() {
+	for _, setup := range server.setups {
+		setup.Gateway.Close()
+	}
+	for _, connection := range server.connections {
+		connection.Close()
+	}
+	if server.audit != nil {
+		server.audit.Close()
+	}
+}
+
+// Serve starts http web server. The identity of a request is selected by the path, as in
+// /orgs/Org1/users/User1/query, or by the X-Fabric-Identity header on the paths without /orgs. The typed
+// supply chain resources of swagger.yaml are served next to the generic chaincode requests.
+func (server *Server) Serve() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/query", server.withIdentity(headerIdentity, (*OrgSetup).Query))
+	mux.HandleFunc("/invoke", server.withIdentity(headerIdentity, (*OrgSetup).Invoke))
+	mux.HandleFunc("/orgs/{org}/users/{user}/query", server.withIdentity(pathIdentity, (*OrgSetup).Query))
+	mux.HandleFunc("/orgs/{org}/users/{user}/invoke", server.withIdentity(pathIdentity, (*OrgSetup).Invoke))
+	mux.HandleFunc("GET /events/chaincode", server.withIdentity(headerIdentity, (*OrgSetup).ChaincodeEvents))
+	mux.HandleFunc("GET /events/blocks", server.withIdentity(headerIdentity, (*OrgSetup).BlockEvents))
+	mux.HandleFunc("GET /orgs/{org}/users/{user}/events/chaincode", server.withIdentity(pathIdentity, (*OrgSetup).ChaincodeEvents))
+	mux.HandleFunc("GET /orgs/{org}/users/{user}/events/blocks", server.withIdentity(pathIdentity, (*OrgSetup).BlockEvents))
+	mux.HandleFunc("POST /transactions", server.withIdentity(headerIdentity, server.SubmitTransaction))
+	mux.HandleFunc("GET /transactions/{id}", server.withIdentity(headerIdentity, server.GetTransaction))
+	mux.HandleFunc("POST /orgs/{org}/users/{user}/transactions", server.withIdentity(pathIdentity, server.SubmitTransaction))
+	mux.HandleFunc("GET /orgs/{org}/users/{user}/transactions/{id}", server.withIdentity(pathIdentity, server.GetTransaction))
+	server.handleSupplyChain(mux)
+	fmt.Printf("Listening (http://localhost%s/)...\n", server.listenAddress)
+	return http.ListenAndServe(server.listenAddress, server.withAuth(mux))
+}
+
+func headerIdentity(r *http.Request) string {
+	return r.Header.Get(IdentityHeader)
+}
+
+func pathIdentity(r *http.Request) string {
+	return identityName(r.PathValue("org"), r.PathValue("user"))
+}
+
+// withIdentity passes the request to the handler of the identity it selects, or of the default identity
+// when it selects none.
+func (server *Server) withIdentity(selectIdentity func(*http.Request) string, handler func(*OrgSetup, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setup, err := server.selectSetup(r, selectIdentity(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		handler(setup, w, r)
+	}
+}
+
+// selectSetup returns the setup of the named identity, or of the default identity when name is empty.
+func (server *Server) selectSetup(r *http.Request, name string) (*OrgSetup, error) {
+	if name == "" {
+		name = server.defaultIdentity
+	}
+	if name == "" {
+		return nil, newError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("no identity selected, set the %s header", IdentityHeader))
+	}
+	// Callers learn nothing about the identities their role may not use.
+	if err := authorizeIdentity(r.Context(), name); err != nil {
+		return nil, err
+	}
+	setup, ok := server.setups[name]
+	if !ok {
+		return nil, newError(http.StatusNotFound, CodeUnknownIdentity, fmt.Sprintf("unknown identity %s", name))
 	}
+	return setup, nil
 }
@@ -0,0 +1,152 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditEntry records a call to the server: who made it, what it called and how it ended.
+type AuditEntry struct {
+	Time          time.Time `json:"time"`
+	Caller        string    `json:"caller,omitempty"`
+	Role          string    `json:"role,omitempty"`
+	RemoteAddr    string    `json:"remoteAddr"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	Identity      string    `json:"identity,omitempty"`
+	ChannelID     string    `json:"channelId,omitempty"`
+	ChaincodeName string    `json:"chaincodeId,omitempty"`
+	Function      string    `json:"function,omitempty"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	Status        int       `json:"status"`
+	ErrorCode     string    `json:"errorCode,omitempty"`
+	Duration      string    `json:"duration"`
+}
+
+func (entry *AuditEntry) transaction(channelID string, chaincodeName string, function string) {
+	entry.ChannelID = channelID
+	entry.ChaincodeName = chaincodeName
+	entry.Function = function
+}
+
+type auditKey struct{}
+
+// auditFrom returns the audit entry of the request. Requests outside the audit middleware get a scratch
+// entry, so handlers can always record to it.
+func auditFrom(ctx context.Context) *AuditEntry {
+	if entry, ok := ctx.Value(auditKey{}).(*AuditEntry); ok {
+		return entry
+	}
+	return &AuditEntry{}
+}
+
+// auditLog appends an entry for each call as a JSON line.
+type auditLog struct {
+	mu     sync.Mutex
+	writer io.Writer
+	file   *os.File
+}
+
+func openAuditLog(filename string) (*auditLog, error) {
+	if filename == "" {
+		return &auditLog{writer: os.Stdout}, nil
+	}
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &auditLog{writer: file, file: file}, nil
+}
+
+func (audit *auditLog) write(entry *AuditEntry) {
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Failed to marshal audit entry: %s\n", err)
+		return
+	}
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	if _, err := audit.writer.Write(append(entryJSON, '\n')); err != nil {
+		log.Printf("Failed to write audit entry: %s\n", err)
+	}
+}
+
+func (audit *auditLog) Close() error {
+	if audit.file == nil {
+		return nil
+	}
+	return audit.file.Close()
+}
+
+// auditResponseWriter records the status, transaction ID and error code of the response in the audit entry.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	entry *AuditEntry
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	w.entry.Status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if w.entry.Status == 0 {
+		w.entry.Status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush lets event streams flush through the audit middleware.
+func (w *auditResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// recordResponse records the envelope of a response in the audit entry of its writer.
+func recordResponse(w http.ResponseWriter, response Response) {
+	auditWriter, ok := w.(*auditResponseWriter)
+	if !ok {
+		return
+	}
+	if response.TransactionID != "" {
+		auditWriter.entry.TransactionID = response.TransactionID
+	}
+	if response.Error != nil {
+		auditWriter.entry.ErrorCode = response.Error.Code
+	}
+}
+
+// withAuth authenticates each request, when authentication is enabled, and writes it to the audit log once
+// it has been served. Event streams are written when they end.
+func (server *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		entry := &AuditEntry{Time: start.UTC(), RemoteAddr: r.RemoteAddr, Method: r.Method, Path: r.URL.Path}
+		auditWriter := &auditResponseWriter{ResponseWriter: w, entry: entry}
+		defer func() {
+			entry.Duration = time.Since(start).String()
+			server.audit.write(entry)
+		}()
+
+		ctx := context.WithValue(r.Context(), auditKey{}, entry)
+		if server.authenticator != nil {
+			caller, err := server.authenticator.authenticate(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(auditWriter, newError(http.StatusUnauthorized, CodeUnauthenticated, err.Error()))
+				return
+			}
+			entry.Caller, entry.Role = caller.Name, caller.Role
+			ctx = context.WithValue(ctx, callerKey{}, caller)
+		}
+		next.ServeHTTP(auditWriter, r.WithContext(ctx))
+	})
+}
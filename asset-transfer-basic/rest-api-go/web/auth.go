@@ -0,0 +1,255 @@
+package web
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// APIKeyHeader carries the API key of a caller that does not authenticate with a bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// AuthConfig configures who may call the server and what they may call.
+type AuthConfig struct {
+	APIKeys []APIKeyConfig `json:"apiKeys"`
+	JWT     *JWTConfig     `json:"jwt,omitempty"`
+	// PolicyFile allowlists the channels, chaincodes and functions of each role, see Policy.
+	PolicyFile string `json:"policyFile"`
+	// AuditLog is the file each call is appended to as a JSON line. Empty means standard output.
+	AuditLog string `json:"auditLog"`
+}
+
+// APIKeyConfig names the caller and role of an API key. Only the hex encoded SHA-256 hash of the key is
+// configured, so the config file does not hold the key.
+type APIKeyConfig struct {
+	Caller  string `json:"caller"`
+	Role    string `json:"role"`
+	KeyHash string `json:"keySHA256"`
+}
+
+// JWTConfig configures bearer tokens signed with HMAC SHA-256 by the identity provider. The token subject
+// is the caller and its role claim the role.
+type JWTConfig struct {
+	Issuer   string `json:"issuer"`
+	Audience string `json:"audience"`
+	// SecretEnv names the environment variable that holds the shared secret.
+	SecretEnv string `json:"secretEnv"`
+}
+
+// Caller is an authenticated client of the server.
+type Caller struct {
+	Name   string
+	Role   string
+	policy *Policy
+}
+
+type callerKey struct{}
+
+// callerFrom returns the authenticated caller of the request, or nil when authentication is disabled.
+func callerFrom(ctx context.Context) *Caller {
+	caller, _ := ctx.Value(callerKey{}).(*Caller)
+	return caller
+}
+
+// callerName returns the name of the authenticated caller of the request, or "" when authentication is disabled.
+func callerName(r *http.Request) string {
+	if caller := callerFrom(r.Context()); caller != nil {
+		return caller.Name
+	}
+	return ""
+}
+
+// authenticator checks the API key or bearer token of each request.
+type authenticator struct {
+	policy   *Policy
+	apiKeys  map[string]APIKeyConfig
+	issuer   string
+	audience string
+	secret   []byte
+}
+
+func newAuthenticator(config *AuthConfig, policy *Policy) (*authenticator, error) {
+	auth := &authenticator{policy: policy, apiKeys: make(map[string]APIKeyConfig)}
+	for _, apiKey := range config.APIKeys {
+		if apiKey.Caller == "" || apiKey.Role == "" || len(apiKey.KeyHash) != sha256.Size*2 {
+			return nil, fmt.Errorf("API key of caller %q needs a caller, role and keySHA256", apiKey.Caller)
+		}
+		auth.apiKeys[strings.ToLower(apiKey.KeyHash)] = apiKey
+	}
+	if config.JWT != nil {
+		secret := os.Getenv(config.JWT.SecretEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("the JWT secret environment variable %q is not set", config.JWT.SecretEnv)
+		}
+		auth.issuer = config.JWT.Issuer
+		auth.audience = config.JWT.Audience
+		auth.secret = []byte(secret)
+	}
+	return auth, nil
+}
+
+// authenticate returns the caller of the request, authenticated by its bearer token or API key.
+func (auth *authenticator) authenticate(r *http.Request) (*Caller, error) {
+	caller, err := auth.credentials(r)
+	if err != nil {
+		return nil, err
+	}
+	caller.policy = auth.policy
+	return caller, nil
+}
+
+func (auth *authenticator) credentials(r *http.Request) (*Caller, error) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		if auth.secret == nil {
+			return nil, fmt.Errorf("bearer tokens are not accepted")
+		}
+		return auth.verifyToken(token, time.Now())
+	}
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		digest := sha256.Sum256([]byte(key))
+		hash := hex.EncodeToString(digest[:])
+		for keyHash, apiKey := range auth.apiKeys {
+			if subtle.ConstantTimeCompare([]byte(keyHash), []byte(hash)) == 1 {
+				return &Caller{Name: apiKey.Caller, Role: apiKey.Role}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown API key")
+	}
+	return nil, fmt.Errorf("no credentials, send a bearer token or the %s header", APIKeyHeader)
+}
+
+// tokenClaims are the claims of a bearer token the server checks.
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Role      string   `json:"role"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+// audience is the aud claim, which is either a single audience or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func (auth *authenticator) verifyToken(token string, now time.Time) (*Caller, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed bearer token")
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("malformed bearer token header")
+	}
+	var header struct {
+		Algorithm string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Algorithm != "HS256" {
+		return nil, fmt.Errorf("bearer tokens must be signed with HS256")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, auth.sign(parts[0]+"."+parts[1])) {
+		return nil, fmt.Errorf("invalid bearer token signature")
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed bearer token claims")
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, fmt.Errorf("malformed bearer token claims: %w", err)
+	}
+	switch {
+	case claims.Subject == "" || claims.Role == "":
+		return nil, fmt.Errorf("the bearer token has no subject or role")
+	case auth.issuer != "" && claims.Issuer != auth.issuer:
+		return nil, fmt.Errorf("the bearer token is not issued by %s", auth.issuer)
+	case auth.audience != "" && !claims.Audience.contains(auth.audience):
+		return nil, fmt.Errorf("the bearer token is not meant for %s", auth.audience)
+	case claims.ExpiresAt == 0 || now.Unix() >= claims.ExpiresAt:
+		return nil, fmt.Errorf("the bearer token has expired")
+	case claims.NotBefore != 0 && now.Unix() < claims.NotBefore:
+		return nil, fmt.Errorf("the bearer token is not valid yet")
+	}
+	return &Caller{Name: claims.Subject, Role: claims.Role}, nil
+}
+
+func (a audience) contains(name string) bool {
+	for _, entry := range a {
+		if entry == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (auth *authenticator) sign(signingInput string) []byte {
+	mac := hmac.New(sha256.New, auth.secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+// IssueToken signs a bearer token for the caller with the configured JWT secret. It stands in for the
+// identity provider when testing the server locally.
+func IssueToken(config *Config, subject string, role string, ttl time.Duration) (string, error) {
+	if config.Auth == nil || config.Auth.JWT == nil {
+		return "", fmt.Errorf("bearer tokens are not configured")
+	}
+	if subject == "" || role == "" {
+		return "", fmt.Errorf("a token needs a subject and role")
+	}
+	auth, err := newAuthenticator(config.Auth, nil)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := tokenClaims{
+		Subject:   subject,
+		Role:      role,
+		Issuer:    auth.issuer,
+		ExpiresAt: now.Add(ttl).Unix(),
+		IssuedAt:  now.Unix(),
+	}
+	if auth.audience != "" {
+		claims.Audience = audience{auth.audience}
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(auth.sign(signingInput)), nil
+}
@@ -0,0 +1,270 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSecretEnv = "OILCHAIN_TEST_JWT_SECRET"
+	testSecret    = "test-secret"
+	testIssuer    = "https://idp.example.com"
+	testAudience  = "oilchain-api"
+	testAPIKey    = "dashboard-dev-key"
+)
+
+var testNow = time.Date(2024, 12, 13, 9, 0, 0, 0, time.UTC)
+
+func newTestAuthenticator(t *testing.T) *authenticator {
+	t.Helper()
+	t.Setenv(testSecretEnv, testSecret)
+	keyHash := sha256.Sum256([]byte(testAPIKey))
+	config := &AuthConfig{
+		APIKeys: []APIKeyConfig{{Caller: "dashboard", Role: "viewer", KeyHash: hex.EncodeToString(keyHash[:])}},
+		JWT:     &JWTConfig{Issuer: testIssuer, Audience: testAudience, SecretEnv: testSecretEnv},
+	}
+	auth, err := newAuthenticator(config, &Policy{})
+	if err != nil {
+		t.Fatalf("newAuthenticator: %v", err)
+	}
+	return auth
+}
+
+// signToken returns a token with the given header and claims, signed with HMAC SHA-256 under secret.
+func signToken(t *testing.T, header string, claims map[string]any, secret string) string {
+	t.Helper()
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("failed to marshal claims: %v", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// validClaims returns the claims of a token that is valid at testNow, with the overrides applied. A nil
+// override removes the claim.
+func validClaims(overrides map[string]any) map[string]any {
+	claims := map[string]any{
+		"sub":  "alice",
+		"role": "driller",
+		"iss":  testIssuer,
+		"aud":  testAudience,
+		"exp":  testNow.Add(time.Hour).Unix(),
+		"iat":  testNow.Add(-time.Minute).Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+	return claims
+}
+
+func TestVerifyToken(t *testing.T) {
+	const hs256 = `{"alg":"HS256","typ":"JWT"}`
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+		err   string
+	}{
+		{
+			name:  "valid",
+			token: func(t *testing.T) string { return signToken(t, hs256, validClaims(nil), testSecret) },
+		},
+		{
+			name: "audience list",
+			token: func(t *testing.T) string {
+				return signToken(t, hs256, validClaims(map[string]any{"aud": []string{"other-api", testAudience}}), testSecret)
+			},
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				return signToken(t, hs256, validClaims(map[string]any{"exp": testNow.Add(-time.Second).Unix()}), testSecret)
+			},
+			err: "the bearer token has expired",
+		},
+		{
+			name: "expires now",
+			token: func(t *testing.T) string {
+				return signToken(t, hs256, validClaims(map[string]any{"exp": testNow.Unix()}), testSecret)
+			},
+			err: "the bearer token has expired",
+		},
+		{
+			name: "no expiry",
+			token: func(t *testing.T) string {
+				return signToken(t, hs256, validClaims(map[string]any{"exp": nil}), testSecret)
+			},
+			err: "the bearer token has expired",
+		},
+		{
+			name: "not yet valid",
+			token: func(t *testing.T) string {
+				return signToken(t, hs256, validClaims(map[string]any{"nbf": testNow.Add(time.Minute).Unix()}), testSecret)
+			},
+			err: "the bearer token is not valid yet",
+		},
+		{
+			name: "alg none",
+			token: func(t *testing.T) string {
+				token := signToken(t, `{"alg":"none","typ":"JWT"}`, validClaims(nil), testSecret)
+				return withSignature(token, "")
+			},
+			err: "bearer tokens must be signed with HS256",
+		},
+		{
+			name: "alg RS256",
+			token: func(t *testing.T) string {
+				return signToken(t, `{"alg":"RS256","typ":"JWT"}`, validClaims(nil), testSecret)
+			},
+			err: "bearer tokens must be signed with HS256",
+		},
+		{
+			name: "signed with another secret",
+			token: func(t *testing.T) string {
+				return signToken(t, hs256, validClaims(nil), "another-secret")
+			},
+			err: "invalid bearer token signature",
+		},
+		{
+			name: "claims changed after signing",
+			token: func(t *testing.T) string {
+				signed := signToken(t, hs256, validClaims(nil), testSecret)
+				forged := signToken(t, hs256, validClaims(map[string]any{"role": "admin"}), testSecret)
+				return withSignature(forged, signed[strings.LastIndex(signed, ".")+1:])
+			},
+			err: "invalid bearer token signature",
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T) string {
+				return signToken(t, hs256, validClaims(map[string]any{"iss": "https://evil.example.com"}), testSecret)
+			},
+			err: "the bearer token is not issued by " + testIssuer,
+		},
+		{
+			name: "wrong audience",
+			token: func(t *testing.T) string {
+				return signToken(t, hs256, validClaims(map[string]any{"aud": "other-api"}), testSecret)
+			},
+			err: "the bearer token is not meant for " + testAudience,
+		},
+		{
+			name: "no role",
+			token: func(t *testing.T) string {
+				return signToken(t, hs256, validClaims(map[string]any{"role": nil}), testSecret)
+			},
+			err: "the bearer token has no subject or role",
+		},
+		{
+			name:  "malformed",
+			token: func(t *testing.T) string { return "not-a-token" },
+			err:   "malformed bearer token",
+		},
+	}
+
+	auth := newTestAuthenticator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caller, err := auth.verifyToken(tt.token(t), testNow)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("verifyToken() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyToken() error = %v", err)
+			}
+			if caller.Name != "alice" || caller.Role != "driller" {
+				t.Errorf("verifyToken() = %+v, want caller alice with role driller", caller)
+			}
+		})
+	}
+}
+
+// withSignature replaces the signature part of a token.
+func withSignature(token string, signature string) string {
+	return token[:strings.LastIndex(token, ".")+1] + signature
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		caller  string
+		err     string
+	}{
+		{name: "API key", headers: map[string]string{APIKeyHeader: testAPIKey}, caller: "dashboard"},
+		{name: "unknown API key", headers: map[string]string{APIKeyHeader: "guessed-key"}, err: "unknown API key"},
+		{name: "no credentials", err: "no credentials, send a bearer token or the X-API-Key header"},
+		{name: "empty bearer token", headers: map[string]string{"Authorization": "Bearer "}, err: "malformed bearer token"},
+	}
+
+	auth := newTestAuthenticator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/batches/OIL-1234", nil)
+			for name, value := range tt.headers {
+				request.Header.Set(name, value)
+			}
+			caller, err := auth.authenticate(request)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("authenticate() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authenticate() error = %v", err)
+			}
+			if caller.Name != tt.caller || caller.policy != auth.policy {
+				t.Errorf("authenticate() = %+v, want caller %s with the server policy", caller, tt.caller)
+			}
+		})
+	}
+}
+
+func TestAuthenticateWithoutJWT(t *testing.T) {
+	auth, err := newAuthenticator(&AuthConfig{}, &Policy{})
+	if err != nil {
+		t.Fatalf("newAuthenticator: %v", err)
+	}
+	request := httptest.NewRequest("GET", "/batches/OIL-1234", nil)
+	request.Header.Set("Authorization", "Bearer "+signToken(t, `{"alg":"HS256","typ":"JWT"}`, validClaims(nil), testSecret))
+	if _, err := auth.authenticate(request); err == nil || err.Error() != "bearer tokens are not accepted" {
+		t.Fatalf("authenticate() error = %v, want %q", err, "bearer tokens are not accepted")
+	}
+}
+
+func TestIssueToken(t *testing.T) {
+	auth := newTestAuthenticator(t)
+	config := &Config{Auth: &AuthConfig{JWT: &JWTConfig{Issuer: testIssuer, Audience: testAudience, SecretEnv: testSecretEnv}}}
+
+	token, err := IssueToken(config, "alice", "driller", time.Hour)
+	if err != nil {
+		t.Fatalf("IssueToken() error = %v", err)
+	}
+	caller, err := auth.verifyToken(token, time.Now())
+	if err != nil {
+		t.Fatalf("verifyToken() error = %v", err)
+	}
+	if caller.Name != "alice" || caller.Role != "driller" {
+		t.Errorf("verifyToken() = %+v, want caller alice with role driller", caller)
+	}
+	if _, err := auth.verifyToken(token, time.Now().Add(time.Hour)); err == nil {
+		t.Errorf("verifyToken() accepted the token after its time to live")
+	}
+}
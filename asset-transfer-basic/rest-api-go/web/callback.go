@@ -0,0 +1,108 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// callbackGuard keeps callbacks from reaching the server's own network. Hosts in the allowlist may be called
+// wherever they resolve. Without an allowlist, any host may be called that resolves only to public addresses.
+type callbackGuard struct {
+	hosts  map[string]bool
+	lookup func(ctx context.Context, host string) ([]net.IPAddr, error)
+	dialer *net.Dialer
+}
+
+func newCallbackGuard(hosts []string) *callbackGuard {
+	guard := &callbackGuard{
+		hosts:  make(map[string]bool),
+		lookup: net.DefaultResolver.LookupIPAddr,
+		dialer: &net.Dialer{Timeout: callbackTimeout},
+	}
+	for _, host := range hosts {
+		guard.hosts[strings.ToLower(host)] = true
+	}
+	return guard
+}
+
+// checkURL checks a callback URL when the transaction is submitted, so the client learns of a URL the server
+// will not call.
+func (guard *callbackGuard) checkURL(ctx context.Context, rawURL string) *Error {
+	callbackURL, err := url.Parse(rawURL)
+	if err != nil || (callbackURL.Scheme != "http" && callbackURL.Scheme != "https") || callbackURL.Hostname() == "" {
+		return newError(http.StatusBadRequest, CodeBadRequest, "callbackUrl must be an absolute http or https URL")
+	}
+	if _, err := guard.resolve(ctx, callbackURL.Hostname()); err != nil {
+		return newError(http.StatusBadRequest, CodeBadRequest, "callbackUrl "+err.Error())
+	}
+	return nil
+}
+
+// resolve returns the addresses the host may be called at, or none for an allowlisted host, which is dialed
+// by name.
+func (guard *callbackGuard) resolve(ctx context.Context, host string) ([]net.IPAddr, error) {
+	host = strings.ToLower(host)
+	if len(guard.hosts) > 0 {
+		if !guard.hosts[host] {
+			return nil, fmt.Errorf("host %s is not allowed", host)
+		}
+		return nil, nil
+	}
+	addresses, err := guard.lookup(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("host %s cannot be resolved", host)
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("host %s has no address", host)
+	}
+	for _, address := range addresses {
+		if !isPublicAddress(address.IP) {
+			return nil, fmt.Errorf("host %s resolves to the non-public address %s", host, address.IP)
+		}
+	}
+	return addresses, nil
+}
+
+// dialContext connects to the addresses the host was checked against, so a host cannot pass the check and
+// then resolve to another address, and redirects are checked like the callback URL.
+func (guard *callbackGuard) dialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := guard.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if addresses == nil {
+		return guard.dialer.DialContext(ctx, network, address)
+	}
+	var conn net.Conn
+	for _, ipAddress := range addresses {
+		conn, err = guard.dialer.DialContext(ctx, network, net.JoinHostPort(ipAddress.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// newClient returns the HTTP client callbacks are posted with. It does not use a proxy, which would dial the
+// callback host in the guard's place.
+func (guard *callbackGuard) newClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = guard.dialContext
+	return &http.Client{Timeout: callbackTimeout, Transport: transport}
+}
+
+// isPublicAddress reports whether the address is outside the loopback, private, link-local, multicast and
+// unspecified ranges.
+func isPublicAddress(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
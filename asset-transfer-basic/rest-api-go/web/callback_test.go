@@ -0,0 +1,105 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newTestCallbackGuard returns a guard that resolves the names in addresses instead of asking DNS.
+func newTestCallbackGuard(hosts []string, addresses map[string]string) *callbackGuard {
+	guard := newCallbackGuard(hosts)
+	guard.lookup = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IPAddr{{IP: ip}}, nil
+		}
+		address, ok := addresses[host]
+		if !ok {
+			return nil, fmt.Errorf("no such host %s", host)
+		}
+		return []net.IPAddr{{IP: net.ParseIP(address)}}, nil
+	}
+	return guard
+}
+
+func TestCheckCallbackURL(t *testing.T) {
+	addresses := map[string]string{
+		"hooks.example.com":  "93.184.216.34",
+		"internal.example":   "10.1.2.3",
+		"localhost":          "127.0.0.1",
+		"metadata.example":   "169.254.169.254",
+		"receiver.corp.test": "192.168.10.20",
+	}
+
+	tests := []struct {
+		name    string
+		hosts   []string
+		url     string
+		message string
+	}{
+		{name: "public host", url: "https://hooks.example.com/fabric"},
+		{name: "public address", url: "http://93.184.216.34:8080/fabric"},
+		{name: "not http", url: "ftp://hooks.example.com/fabric", message: "callbackUrl must be an absolute http or https URL"},
+		{name: "relative", url: "/fabric", message: "callbackUrl must be an absolute http or https URL"},
+		{name: "loopback host", url: "http://localhost:3000/invoke", message: "callbackUrl host localhost resolves to the non-public address 127.0.0.1"},
+		{name: "loopback address", url: "http://127.0.0.1/", message: "callbackUrl host 127.0.0.1 resolves to the non-public address 127.0.0.1"},
+		{name: "IPv6 loopback", url: "http://[::1]:3000/", message: "callbackUrl host ::1 resolves to the non-public address ::1"},
+		{name: "private host", url: "https://internal.example/", message: "callbackUrl host internal.example resolves to the non-public address 10.1.2.3"},
+		{name: "link-local metadata", url: "http://metadata.example/latest", message: "callbackUrl host metadata.example resolves to the non-public address 169.254.169.254"},
+		{name: "unspecified address", url: "http://0.0.0.0/", message: "callbackUrl host 0.0.0.0 resolves to the non-public address 0.0.0.0"},
+		{name: "unresolved host", url: "https://unknown.example/", message: "callbackUrl host unknown.example cannot be resolved"},
+		{name: "allowlisted private host", hosts: []string{"Receiver.corp.test"}, url: "https://receiver.corp.test/fabric"},
+		{name: "host outside allowlist", hosts: []string{"receiver.corp.test"}, url: "https://hooks.example.com/fabric", message: "callbackUrl host hooks.example.com is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := newTestCallbackGuard(tt.hosts, addresses)
+			err := guard.checkURL(context.Background(), tt.url)
+			if tt.message == "" {
+				if err != nil {
+					t.Fatalf("checkURL(%q) = %v, want nil", tt.url, err)
+				}
+				return
+			}
+			if err == nil || err.Message != tt.message || err.Status != http.StatusBadRequest {
+				t.Fatalf("checkURL(%q) = %v, want %d %q", tt.url, err, http.StatusBadRequest, tt.message)
+			}
+		})
+	}
+}
+
+func TestCallbackClient(t *testing.T) {
+	received := make(chan struct{}, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer receiver.Close()
+	receiverURL, err := url.Parse(receiver.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The receiver listens on a loopback address, which only an allowlist lets callbacks reach.
+	tracker := newTransactionTracker(nil)
+	if err := tracker.post(receiver.URL, []byte("{}")); err == nil {
+		t.Fatalf("posted a callback to the loopback receiver %s", receiver.URL)
+	}
+
+	tracker = newTransactionTracker([]string{receiverURL.Hostname()})
+	if err := tracker.post(receiver.URL, []byte("{}")); err != nil {
+		t.Fatalf("post to allowlisted receiver: %v", err)
+	}
+	<-received
+
+	// A redirect to a host outside the allowlist is not followed.
+	redirector := httptest.NewServer(http.RedirectHandler("http://localhost:"+receiverURL.Port(), http.StatusTemporaryRedirect))
+	defer redirector.Close()
+	if err := tracker.post(redirector.URL, []byte("{}")); err == nil {
+		t.Fatalf("followed a redirect to a host outside the allowlist")
+	}
+}
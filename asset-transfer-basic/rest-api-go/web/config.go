@@ -0,0 +1,136 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config lists the organizations and users the server submits as.
+type Config struct {
+	ListenAddress string `json:"listenAddress"`
+	// DefaultIdentity is used by requests that do not select one, as "<org>/<user>". Empty means they are rejected.
+	DefaultIdentity string      `json:"defaultIdentity"`
+	Organizations   []OrgConfig `json:"organizations"`
+	// Auth enables authentication and authorization. Without it every client may call anything.
+	Auth *AuthConfig `json:"auth,omitempty"`
+	// CallbackHosts are the only hosts the callback URLs of submitted transactions may name, wherever they
+	// resolve. Empty means any host that resolves only to public addresses.
+	CallbackHosts []string `json:"callbackHosts,omitempty"`
+}
+
+// OrgConfig describes an organization and the gateway peer its users connect to. Relative paths are
+// resolved against CryptoPath.
+type OrgConfig struct {
+	Name         string       `json:"name"`
+	MSPID        string       `json:"mspID"`
+	CryptoPath   string       `json:"cryptoPath"`
+	TLSCertPath  string       `json:"tlsCertPath"`
+	PeerEndpoint string       `json:"peerEndpoint"`
+	GatewayPeer  string       `json:"gatewayPeer"`
+	Users        []UserConfig `json:"users"`
+}
+
+// UserConfig locates the certificate and private key directory of a user.
+type UserConfig struct {
+	Name     string `json:"name"`
+	CertPath string `json:"certPath"`
+	KeyPath  string `json:"keyPath"`
+}
+
+// LoadConfig reads the server configuration from a JSON file. Relative crypto paths in the file are
+// resolved against the directory of the file.
+func LoadConfig(filename string) (*Config, error) {
+	configJSON, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
+	}
+	if config.ListenAddress == "" {
+		config.ListenAddress = ":3000"
+	}
+
+	dir := filepath.Dir(filename)
+	for i := range config.Organizations {
+		org := &config.Organizations[i]
+		if !filepath.IsAbs(org.CryptoPath) {
+			org.CryptoPath = filepath.Join(dir, org.CryptoPath)
+		}
+	}
+	if config.Auth != nil {
+		config.Auth.PolicyFile = resolvePath(dir, config.Auth.PolicyFile)
+		if config.Auth.AuditLog != "" {
+			config.Auth.AuditLog = resolvePath(dir, config.Auth.AuditLog)
+		}
+	}
+	return &config, config.validate()
+}
+
+func (config *Config) validate() error {
+	if len(config.Organizations) == 0 {
+		return fmt.Errorf("no organizations are configured")
+	}
+	identities := make(map[string]bool)
+	for _, org := range config.Organizations {
+		if org.Name == "" || org.MSPID == "" || org.PeerEndpoint == "" || org.GatewayPeer == "" {
+			return fmt.Errorf("organization %q needs a name, mspID, peerEndpoint and gatewayPeer", org.Name)
+		}
+		if len(org.Users) == 0 {
+			return fmt.Errorf("organization %s has no users", org.Name)
+		}
+		for _, user := range org.Users {
+			if user.Name == "" || user.CertPath == "" || user.KeyPath == "" {
+				return fmt.Errorf("user %q of organization %s needs a name, certPath and keyPath", user.Name, org.Name)
+			}
+			name := identityName(org.Name, user.Name)
+			if identities[name] {
+				return fmt.Errorf("the identity %s is configured more than once", name)
+			}
+			identities[name] = true
+		}
+	}
+	if config.DefaultIdentity != "" && !identities[config.DefaultIdentity] {
+		return fmt.Errorf("the default identity %s is not configured", config.DefaultIdentity)
+	}
+	if config.Auth != nil && len(config.Auth.APIKeys) == 0 && config.Auth.JWT == nil {
+		return fmt.Errorf("auth needs apiKeys or jwt")
+	}
+	return nil
+}
+
+// setups returns the setup of every configured user.
+func (config *Config) setups() []OrgSetup {
+	var setups []OrgSetup
+	for _, org := range config.Organizations {
+		for _, user := range org.Users {
+			setups = append(setups, OrgSetup{
+				OrgName:      org.Name,
+				UserName:     user.Name,
+				MSPID:        org.MSPID,
+				CryptoPath:   org.CryptoPath,
+				CertPath:     resolvePath(org.CryptoPath, user.CertPath),
+				KeyPath:      resolvePath(org.CryptoPath, user.KeyPath),
+				TLSCertPath:  resolvePath(org.CryptoPath, org.TLSCertPath),
+				PeerEndpoint: org.PeerEndpoint,
+				GatewayPeer:  org.GatewayPeer,
+			})
+		}
+	}
+	return setups
+}
+
+func resolvePath(base string, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(base, name)
+}
+
+// identityName names a user of an organization the way requests select it: "<org>/<user>".
+func identityName(orgName string, userName string) string {
+	return orgName + "/" + userName
+}
@@ -0,0 +1,509 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"rest-api-go/routes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stageContract locates the chaincode a stage of the supply chain is recorded with.
+type stageContract struct {
+	stage         routes.Stage
+	channelID     string
+	chaincodeName string
+}
+
+// stageContracts lists the stages in the order an oil batch passes them. A batch stored on channel2 goes
+// on to a factory on channel3 or to a pump on channel4.
+var stageContracts = []stageContract{
+	{routes.DrillToRefinery, "channel1", "basic_channel1"},
+	{routes.RefineryToStorage, "channel2", "basic_channel2"},
+	{routes.StorageToFactory, "channel3", "basic_channel3"},
+	{routes.StorageToPump, "channel4", "basic_channel4"},
+	{routes.PumpToCustomer, "channel5", "basic_channel5"},
+}
+
+// The reconciler keeps the main chain summary of each oil batch on channel6, under the batch ID with a prefix.
+const (
+	summaryChannel   = "channel6"
+	summaryChaincode = "basic_channel6"
+	summaryIDPrefix  = "MAIN-"
+)
+
+const defaultTelemetryPageSize = 100
+
+// billSearchPageSize is the number of shipments GetBill reads from a stage at a time.
+const billSearchPageSize = 100
+
+func stageContractOf(stage routes.Stage) (stageContract, bool) {
+	for _, contract := range stageContracts {
+		if contract.stage == stage {
+			return contract, true
+		}
+	}
+	return stageContract{}, false
+}
+
+// supplyChain implements the typed resources of swagger.yaml, whose routes and models are generated into
+// package routes, by evaluating the stage chaincodes.
+type supplyChain struct{}
+
+var _ routes.StrictServerInterface = supplyChain{}
+
+type setupKey struct{}
+
+// setupFrom returns the setup of the identity selected by a supply chain request.
+func setupFrom(ctx context.Context) *OrgSetup {
+	return ctx.Value(setupKey{}).(*OrgSetup)
+}
+
+// handleSupplyChain registers the supply chain resources on the mux, together with the spec they are
+// generated from at /openapi.json. Their identity is selected by the X-Fabric-Identity header.
+func (server *Server) handleSupplyChain(mux *http.ServeMux) {
+	badRequest := func(w http.ResponseWriter, r *http.Request, err error) {
+		writeError(w, newError(http.StatusBadRequest, CodeBadRequest, err.Error()))
+	}
+	handler := routes.NewStrictHandlerWithOptions(supplyChain{}, nil, routes.StrictHTTPServerOptions{
+		RequestErrorHandlerFunc: badRequest,
+		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			writeError(w, err)
+		},
+	})
+	routes.HandlerWithOptions(handler, routes.StdHTTPServerOptions{
+		BaseRouter:       mux,
+		Middlewares:      []routes.MiddlewareFunc{server.withSetup},
+		ErrorHandlerFunc: badRequest,
+	})
+	mux.HandleFunc("GET /openapi.json", serveSpec)
+}
+
+// withSetup passes the setup of the identity the request selects to the supply chain handlers.
+func (server *Server) withSetup(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setup, err := server.selectSetup(r, headerIdentity(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), setupKey{}, setup)))
+	})
+}
+
+func serveSpec(w http.ResponseWriter, r *http.Request) {
+	spec, err := routes.GetSwagger()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(spec); err != nil {
+		log.Printf("Failed to write spec: %s\n", err)
+	}
+}
+
+// evaluate checks that the caller may call the function and evaluates it.
+func evaluate(ctx context.Context, channelID string, chaincodeName string, function string, args ...string) ([]byte, error) {
+	if err := authorizeTransaction(ctx, channelID, chaincodeName, function); err != nil {
+		return nil, err
+	}
+	return readContract(ctx, channelID, chaincodeName, function, args...)
+}
+
+// readContract evaluates a function the caller is known to be allowed to call.
+func readContract(ctx context.Context, channelID string, chaincodeName string, function string, args ...string) ([]byte, error) {
+	fmt.Printf("channel: %s, chaincode: %s, function: %s, args: %s\n", channelID, chaincodeName, function, args)
+	contract := setupFrom(ctx).Gateway.GetNetwork(channelID).GetContract(chaincodeName)
+	result, err := contract.EvaluateTransaction(function, args...)
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+	return result, nil
+}
+
+// readableStages returns the stages whose function the caller may call, and records them in the audit log.
+// It fails when the caller may call it on none of them.
+func readableStages(ctx context.Context, function string) ([]stageContract, error) {
+	var readable []stageContract
+	var channelIDs, chaincodeNames []string
+	for _, contract := range stageContracts {
+		if mayCall(ctx, contract.channelID, contract.chaincodeName, function) {
+			readable = append(readable, contract)
+			channelIDs = append(channelIDs, contract.channelID)
+			chaincodeNames = append(chaincodeNames, contract.chaincodeName)
+		}
+	}
+	if len(readable) == 0 {
+		first := stageContracts[0]
+		return nil, authorizeTransaction(ctx, first.channelID, first.chaincodeName, function)
+	}
+	auditFrom(ctx).transaction(strings.Join(channelIDs, ","), strings.Join(chaincodeNames, ","), function)
+	return readable, nil
+}
+
+// notFound reports whether the chaincode rejected an evaluation because the asset or oil batch it reads
+// does not exist.
+func notFound(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != CodeEvaluateFailed {
+		return false
+	}
+	if strings.Contains(apiErr.Message, "does not exist") {
+		return true
+	}
+	for _, detail := range apiErr.Details {
+		if strings.Contains(detail.Message, "does not exist") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBatch returns the lifecycle state of the oil batch on each stage the caller may read, and its main
+// chain summary.
+func (supplyChain) GetBatch(ctx context.Context, request routes.GetBatchRequestObject) (routes.GetBatchResponseObject, error) {
+	oilID := request.OilBatchId
+	stages, err := readableStages(ctx, "GetBatchState")
+	if err != nil {
+		return nil, err
+	}
+
+	batch := routes.Batch{OilBatchId: oilID, Stages: []routes.BatchStage{}}
+	for _, contract := range stages {
+		stateJSON, err := readContract(ctx, contract.channelID, contract.chaincodeName, "GetBatchState", oilID)
+		if notFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var state batchState
+		if err := json.Unmarshal(stateJSON, &state); err != nil {
+			return nil, fmt.Errorf("failed to parse the state of oil batch %s on %s: %w", oilID, contract.channelID, err)
+		}
+		stage, err := state.batchStage(contract)
+		if err != nil {
+			return nil, err
+		}
+		batch.Stages = append(batch.Stages, stage)
+	}
+
+	if mayCall(ctx, summaryChannel, summaryChaincode, "ReadAsset") {
+		summaryJSON, err := readContract(ctx, summaryChannel, summaryChaincode, "ReadAsset", summaryIDPrefix+oilID)
+		switch {
+		case notFound(err):
+		case err != nil:
+			return nil, err
+		default:
+			var summary mainChainSummary
+			if err := json.Unmarshal(summaryJSON, &summary); err != nil {
+				return nil, fmt.Errorf("failed to parse the summary of oil batch %s: %w", oilID, err)
+			}
+			batch.Summary = summary.batchSummary()
+		}
+	}
+
+	if len(batch.Stages) == 0 && batch.Summary == nil {
+		return nil, newError(http.StatusNotFound, CodeNotFound, fmt.Sprintf("the oil batch %s does not exist", oilID))
+	}
+	return routes.GetBatch200JSONResponse{BatchSuccessJSONResponse: routes.BatchSuccessJSONResponse{Result: batch}}, nil
+}
+
+// GetShipment returns the asset recorded for a handover on a stage.
+func (supplyChain) GetShipment(ctx context.Context, request routes.GetShipmentRequestObject) (routes.GetShipmentResponseObject, error) {
+	contract, ok := stageContractOf(request.Stage)
+	if !ok {
+		return nil, newError(http.StatusNotFound, CodeNotFound, fmt.Sprintf("unknown stage %s", request.Stage))
+	}
+	assetJSON, err := evaluate(ctx, contract.channelID, contract.chaincodeName, "ReadAsset", request.Id)
+	if notFound(err) {
+		return nil, newError(http.StatusNotFound, CodeNotFound, fmt.Sprintf("the shipment %s does not exist on %s", request.Id, request.Stage))
+	}
+	if err != nil {
+		return nil, err
+	}
+	shipment, err := newShipment(contract, assetJSON)
+	if err != nil {
+		return nil, err
+	}
+	return routes.GetShipment200JSONResponse{ShipmentSuccessJSONResponse: routes.ShipmentSuccessJSONResponse{Result: *shipment}}, nil
+}
+
+// GetShipmentTelemetry returns a page of the telemetry series of a shipment.
+func (supplyChain) GetShipmentTelemetry(ctx context.Context, request routes.GetShipmentTelemetryRequestObject) (routes.GetShipmentTelemetryResponseObject, error) {
+	params := request.Params
+	contract, ok := stageContractOf(params.Stage)
+	if !ok {
+		return nil, newError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("unknown stage %s", params.Stage))
+	}
+	var from, to, bookmark string
+	if params.From != nil {
+		from = params.From.Format(time.RFC3339Nano)
+	}
+	if params.To != nil {
+		to = params.To.Format(time.RFC3339Nano)
+	}
+	if params.Bookmark != nil {
+		bookmark = *params.Bookmark
+	}
+	pageSize := defaultTelemetryPageSize
+	if params.PageSize != nil {
+		if *params.PageSize < 1 || *params.PageSize > 1000 {
+			return nil, newError(http.StatusBadRequest, CodeBadRequest, "pageSize must be between 1 and 1000")
+		}
+		pageSize = int(*params.PageSize)
+	}
+
+	pageJSON, err := evaluate(ctx, contract.channelID, contract.chaincodeName, "GetTelemetry", request.Id, from, to, strconv.Itoa(pageSize), bookmark)
+	if err != nil {
+		return nil, err
+	}
+	var page telemetryPage
+	if err := json.Unmarshal(pageJSON, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse the telemetry of shipment %s: %w", request.Id, err)
+	}
+	result := routes.TelemetryPage{Readings: make([]routes.Reading, 0, len(page.Records)), Bookmark: page.Bookmark}
+	for _, record := range page.Records {
+		reading, err := record.reading()
+		if err != nil {
+			return nil, err
+		}
+		result.Readings = append(result.Readings, *reading)
+	}
+	return routes.GetShipmentTelemetry200JSONResponse{TelemetrySuccessJSONResponse: routes.TelemetrySuccessJSONResponse{Result: result}}, nil
+}
+
+// GetBill searches the shipments of every stage the caller may read for the bill. Bills are not indexed
+// by number on the ledger, so each stage is read a page at a time with GetAssetsPage, which keeps every
+// response within the gRPC message size limit and stops the search at the page holding the bill.
+func (supplyChain) GetBill(ctx context.Context, request routes.GetBillRequestObject) (routes.GetBillResponseObject, error) {
+	stages, err := readableStages(ctx, "GetAssetsPage")
+	if err != nil {
+		return nil, err
+	}
+	for _, contract := range stages {
+		bookmark := ""
+		for {
+			pageJSON, err := readContract(ctx, contract.channelID, contract.chaincodeName, "GetAssetsPage", strconv.Itoa(billSearchPageSize), bookmark, "", "", "", "")
+			if err != nil {
+				return nil, err
+			}
+			var page stageRecordPage
+			if err := json.Unmarshal(pageJSON, &page); err != nil {
+				return nil, fmt.Errorf("failed to parse the shipments on %s: %w", contract.channelID, err)
+			}
+			for _, record := range page.Records {
+				if record.Bill.BillNumber == request.BillNumber {
+					bill := record.bill(contract)
+					return routes.GetBill200JSONResponse{BillSuccessJSONResponse: routes.BillSuccessJSONResponse{Result: bill}}, nil
+				}
+			}
+			if page.Bookmark == "" || len(page.Records) == 0 {
+				break
+			}
+			bookmark = page.Bookmark
+		}
+	}
+	return nil, newError(http.StatusNotFound, CodeNotFound, fmt.Sprintf("the bill %s does not exist", request.BillNumber))
+}
+
+// batchState is the BatchState returned by GetBatchState on every stage.
+type batchState struct {
+	OilID        string `json:"Oil_Batch_ID"`
+	State        string `json:"State"`
+	Custodian    string `json:"Custodian"`
+	AssetID      string `json:"Asset_ID"`
+	HandedOverAt string `json:"Handed_Over_At"`
+	AcceptedAt   string `json:"Accepted_At"`
+}
+
+func (state *batchState) batchStage(contract stageContract) (routes.BatchStage, error) {
+	handedOverAt, err := optionalTime(state.HandedOverAt)
+	if err != nil {
+		return routes.BatchStage{}, err
+	}
+	acceptedAt, err := optionalTime(state.AcceptedAt)
+	if err != nil {
+		return routes.BatchStage{}, err
+	}
+	return routes.BatchStage{
+		Stage:        contract.stage,
+		ChannelId:    contract.channelID,
+		State:        state.State,
+		Custodian:    state.Custodian,
+		ShipmentId:   optional(state.AssetID),
+		HandedOverAt: handedOverAt,
+		AcceptedAt:   acceptedAt,
+	}, nil
+}
+
+// mainChainSummary holds the fields of the channel6 asset the batch resource reports.
+type mainChainSummary struct {
+	ID                 string `json:"ID"`
+	QualityCertificate string `json:"Oil_Quality_Certificate"`
+	Quantity           string `json:"Oil_Quantity"`
+	ComplianceReport   string `json:"Compliance_Report"`
+	Payment            string `json:"Payment"`
+	TimeToComplete     string `json:"Time_To_Complete"`
+}
+
+func (summary *mainChainSummary) batchSummary() *routes.BatchSummary {
+	return &routes.BatchSummary{
+		Id:                 summary.ID,
+		QualityCertificate: optional(summary.QualityCertificate),
+		Quantity:           optional(summary.Quantity),
+		ComplianceReport:   optional(summary.ComplianceReport),
+		Payment:            optional(summary.Payment),
+		TimeToComplete:     optional(summary.TimeToComplete),
+	}
+}
+
+// stageRecordPage is the AssetPage GetAssetsPage returns on every stage.
+type stageRecordPage struct {
+	Records  []stageRecord `json:"Records"`
+	Bookmark string        `json:"Bookmark"`
+}
+
+// stageRecord holds the fields the Asset of every stage chaincode has.
+type stageRecord struct {
+	ID                 string    `json:"ID"`
+	OilID              string    `json:"Oil_Batch_ID"`
+	QualityCertificate string    `json:"Oil_Quality_Certificate"`
+	Bill               stageBill `json:"Bill"`
+	// The latest reading is named IoTData on channel1 and Iot_Data on the other stages.
+	IoTData *stageReading `json:"IoTData"`
+	IotData *stageReading `json:"Iot_Data"`
+}
+
+type stageBill struct {
+	BillNumber     string `json:"Bill_Number"`
+	TotalPayment   string `json:"Total_Payment"`
+	CarrierName    string `json:"Carrier_Name"`
+	CarrierAddress string `json:"Carrier_Address"`
+	Date           string `json:"Date"`
+	Payee          string `json:"Payee"`
+	PaymentStatus  string `json:"Payment_Status"`
+	PaymentTxID    string `json:"Payment_Tx_ID"`
+}
+
+type stageReading struct {
+	DeviceID    string        `json:"Device_ID"`
+	Timestamp   string        `json:"Timestamp"`
+	Temperature stageMeasure  `json:"Temperature"`
+	Pressure    stageMeasure  `json:"Pressure"`
+	Quantity    stageMeasure  `json:"Quantity"`
+	Quality     string        `json:"Quality"`
+	Location    string        `json:"Location"`
+	Coordinates stageLocation `json:"Coordinates"`
+}
+
+type stageMeasure struct {
+	Value float64 `json:"Value"`
+	Unit  string  `json:"Unit"`
+}
+
+type stageLocation struct {
+	Latitude  float64 `json:"Latitude"`
+	Longitude float64 `json:"Longitude"`
+}
+
+// telemetryPage is the TelemetryPage returned by GetTelemetry.
+type telemetryPage struct {
+	Records  []*stageReading `json:"Records"`
+	Bookmark string          `json:"Bookmark"`
+}
+
+func newShipment(contract stageContract, assetJSON []byte) (*routes.Shipment, error) {
+	var record stageRecord
+	if err := json.Unmarshal(assetJSON, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse the shipment: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(assetJSON, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse the shipment: %w", err)
+	}
+	shipment := &routes.Shipment{
+		Id:                 record.ID,
+		Stage:              contract.stage,
+		ChannelId:          contract.channelID,
+		OilBatchId:         record.OilID,
+		QualityCertificate: optional(record.QualityCertificate),
+		Bill:               record.bill(contract),
+		Record:             fields,
+	}
+	latest := record.IotData
+	if record.IoTData != nil {
+		latest = record.IoTData
+	}
+	if latest != nil && latest.DeviceID != "" {
+		reading, err := latest.reading()
+		if err != nil {
+			return nil, err
+		}
+		shipment.LatestReading = reading
+	}
+	return shipment, nil
+}
+
+func (record *stageRecord) bill(contract stageContract) routes.Bill {
+	bill := routes.Bill{
+		BillNumber:     record.Bill.BillNumber,
+		TotalPayment:   record.Bill.TotalPayment,
+		CarrierName:    record.Bill.CarrierName,
+		CarrierAddress: optional(record.Bill.CarrierAddress),
+		Date:           optional(record.Bill.Date),
+		Payee:          optional(record.Bill.Payee),
+		PaymentTxId:    optional(record.Bill.PaymentTxID),
+		Stage:          &contract.stage,
+		ShipmentId:     optional(record.ID),
+		OilBatchId:     optional(record.OilID),
+	}
+	if record.Bill.PaymentStatus != "" {
+		status := routes.BillPaymentStatus(record.Bill.PaymentStatus)
+		bill.PaymentStatus = &status
+	}
+	return bill
+}
+
+func (reading *stageReading) reading() (*routes.Reading, error) {
+	timestamp, err := time.Parse(time.RFC3339, reading.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("the reading of device %s has an invalid timestamp: %w", reading.DeviceID, err)
+	}
+	return &routes.Reading{
+		DeviceId:    reading.DeviceID,
+		Timestamp:   timestamp,
+		Temperature: &routes.Measurement{Value: reading.Temperature.Value, Unit: reading.Temperature.Unit},
+		Pressure:    &routes.Measurement{Value: reading.Pressure.Value, Unit: reading.Pressure.Unit},
+		Quantity:    &routes.Measurement{Value: reading.Quantity.Value, Unit: reading.Quantity.Unit},
+		Quality:     optional(reading.Quality),
+		Location:    optional(reading.Location),
+		Latitude:    &reading.Coordinates.Latitude,
+		Longitude:   &reading.Coordinates.Longitude,
+	}, nil
+}
+
+// optional returns nil for an empty string, which the generated models leave out of the response.
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func optionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q: %w", value, err)
+	}
+	return &t, nil
+}
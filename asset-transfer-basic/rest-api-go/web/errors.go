@@ -0,0 +1,129 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error codes of the error envelope, which clients can branch on instead of matching the message.
+const (
+	CodeBadRequest         = "BAD_REQUEST"
+	CodeUnknownIdentity    = "UNKNOWN_IDENTITY"
+	CodeUnknownTransaction = "UNKNOWN_TRANSACTION"
+	CodeNotFound           = "NOT_FOUND"
+	CodeEvaluateFailed     = "EVALUATE_FAILED"
+	CodeEndorsementFailed  = "ENDORSEMENT_FAILED"
+	CodeSubmitFailed       = "SUBMIT_FAILED"
+	CodeCommitStatusFailed = "COMMIT_STATUS_FAILED"
+	CodeMVCCConflict       = "MVCC_CONFLICT"
+	CodeTransactionInvalid = "TRANSACTION_INVALID"
+	CodeTimeout            = "TIMEOUT"
+	CodeAccessDenied       = "ACCESS_DENIED"
+	CodeUnauthenticated    = "UNAUTHENTICATED"
+	CodeUnavailable        = "UNAVAILABLE"
+	CodeInternal           = "INTERNAL"
+)
+
+// Error describes why a request failed.
+type Error struct {
+	Status         int           `json:"-"`
+	Code           string        `json:"code"`
+	Message        string        `json:"message"`
+	TransactionID  string        `json:"transactionId,omitempty"`
+	GRPCStatus     string        `json:"grpcStatus,omitempty"`
+	ValidationCode string        `json:"validationCode,omitempty"`
+	Details        []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail is the error a single peer or orderer returned to the gateway.
+type ErrorDetail struct {
+	Address string `json:"address"`
+	MSPID   string `json:"mspId"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(httpStatus int, code string, message string) *Error {
+	return &Error{Status: httpStatus, Code: code, Message: message}
+}
+
+// commitFailure describes a transaction that was ordered but failed validation with the given code.
+func commitFailure(transactionID string, code peer.TxValidationCode) *Error {
+	apiErr := newError(http.StatusUnprocessableEntity, CodeTransactionInvalid, fmt.Sprintf("transaction %s failed to commit with status code %d (%s)", transactionID, int32(code), code))
+	apiErr.TransactionID = transactionID
+	apiErr.ValidationCode = code.String()
+	if code == peer.TxValidationCode_MVCC_READ_CONFLICT || code == peer.TxValidationCode_PHANTOM_READ_CONFLICT {
+		apiErr.Status, apiErr.Code = http.StatusConflict, CodeMVCCConflict
+	}
+	return apiErr
+}
+
+// gatewayError maps a failure of the Fabric gateway to an HTTP status and error code, together with the
+// error details of every peer the gateway called.
+func gatewayError(err error) *Error {
+	apiErr := newError(http.StatusInternalServerError, CodeInternal, err.Error())
+
+	var endorseErr *client.EndorseError
+	var submitErr *client.SubmitError
+	var commitStatusErr *client.CommitStatusError
+	var commitErr *client.CommitError
+	switch {
+	case errors.As(err, &commitErr):
+		// The transaction was ordered but failed validation; there is no gRPC status to report.
+		return commitFailure(commitErr.TransactionID, commitErr.Code)
+	case errors.As(err, &endorseErr):
+		apiErr.TransactionID = endorseErr.TransactionID
+		apiErr.Status, apiErr.Code = http.StatusUnprocessableEntity, CodeEndorsementFailed
+	case errors.As(err, &submitErr):
+		apiErr.TransactionID = submitErr.TransactionID
+		apiErr.Status, apiErr.Code = http.StatusBadGateway, CodeSubmitFailed
+	case errors.As(err, &commitStatusErr):
+		apiErr.TransactionID = commitStatusErr.TransactionID
+		apiErr.Status, apiErr.Code = http.StatusBadGateway, CodeCommitStatusFailed
+	}
+
+	grpcStatus, ok := status.FromError(err)
+	if !ok {
+		if errors.Is(err, context.DeadlineExceeded) {
+			apiErr.Status, apiErr.Code = http.StatusGatewayTimeout, CodeTimeout
+		}
+		return apiErr
+	}
+	apiErr.GRPCStatus = grpcStatus.Code().String()
+	for _, detail := range grpcStatus.Details() {
+		if errorDetail, ok := detail.(*gateway.ErrorDetail); ok {
+			apiErr.Details = append(apiErr.Details, ErrorDetail{
+				Address: errorDetail.GetAddress(),
+				MSPID:   errorDetail.GetMspId(),
+				Message: errorDetail.GetMessage(),
+			})
+		}
+	}
+
+	// Failures of the call itself take precedence over the step of the transaction that failed.
+	switch grpcStatus.Code() {
+	case codes.DeadlineExceeded:
+		apiErr.Status, apiErr.Code = http.StatusGatewayTimeout, CodeTimeout
+	case codes.PermissionDenied, codes.Unauthenticated:
+		apiErr.Status, apiErr.Code = http.StatusForbidden, CodeAccessDenied
+	case codes.Unavailable:
+		apiErr.Status, apiErr.Code = http.StatusServiceUnavailable, CodeUnavailable
+	case codes.Unknown, codes.Aborted:
+		// The gateway reports a chaincode that rejects an evaluation this way.
+		if apiErr.Code == CodeInternal {
+			apiErr.Status, apiErr.Code = http.StatusUnprocessableEntity, CodeEvaluateFailed
+		}
+	}
+	return apiErr
+}
@@ -0,0 +1,208 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// Event streams send a comment this often, so proxies do not close a stream that has no events.
+const eventKeepAlive = 15 * time.Second
+
+// ChaincodeEvent is the data of a chaincode event on the stream. Its event ID is "<blockNumber>:<transactionId>".
+type ChaincodeEvent struct {
+	BlockNumber   uint64          `json:"blockNumber"`
+	TransactionID string          `json:"transactionId"`
+	ChaincodeName string          `json:"chaincodeId"`
+	EventName     string          `json:"eventName"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+}
+
+// BlockEvent is the data of a block on the stream. Its event ID is the block number.
+type BlockEvent struct {
+	BlockNumber  uint64             `json:"blockNumber"`
+	Transactions []BlockTransaction `json:"transactions"`
+}
+
+// BlockTransaction is a transaction of a block with the outcome of its validation.
+type BlockTransaction struct {
+	TransactionID  string `json:"transactionId"`
+	ValidationCode string `json:"validationCode"`
+}
+
+// ChaincodeEvents streams the events of a chaincode as server-sent events. The stream starts at the
+// startBlock query parameter, or with the next committed block, and resumes after the event named by the
+// Last-Event-ID header, which browsers send when they reconnect, or the lastEventId query parameter.
+func (setup *OrgSetup) ChaincodeEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	channelID := query.Get("channel")
+	chaincodeName := query.Get("chaincode")
+	if channelID == "" || chaincodeName == "" {
+		writeError(w, newError(http.StatusBadRequest, CodeBadRequest, "channel and chaincode are required"))
+		return
+	}
+	if err := authorizeEvents(r.Context(), channelID, chaincodeName); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var options []client.ChaincodeEventsOption
+	startBlock, ok, err := parseStartBlock(query.Get("startBlock"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if ok {
+		options = append(options, client.WithStartBlock(startBlock))
+	}
+	if lastEventID := lastEventID(r); lastEventID != "" {
+		blockNumber, transactionID, ok := strings.Cut(lastEventID, ":")
+		number, err := strconv.ParseUint(blockNumber, 10, 64)
+		if !ok || err != nil {
+			writeError(w, newError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("invalid event ID %q, expected <blockNumber>:<transactionId>", lastEventID)))
+			return
+		}
+		// The checkpoint takes precedence over the start block.
+		checkpoint := new(client.InMemoryCheckpointer)
+		checkpoint.CheckpointTransaction(number, transactionID)
+		options = append(options, client.WithCheckpoint(checkpoint))
+	}
+
+	events, err := setup.Gateway.GetNetwork(channelID).ChaincodeEvents(r.Context(), chaincodeName, options...)
+	if err != nil {
+		writeError(w, gatewayError(err))
+		return
+	}
+	streamEvents(w, r, events, func(event *client.ChaincodeEvent) (string, string, any) {
+		data := ChaincodeEvent{
+			BlockNumber:   event.BlockNumber,
+			TransactionID: event.TransactionID,
+			ChaincodeName: event.ChaincodeName,
+			EventName:     event.EventName,
+			Payload:       resultJSON(event.Payload),
+		}
+		return fmt.Sprintf("%d:%s", event.BlockNumber, event.TransactionID), event.EventName, data
+	})
+}
+
+// BlockEvents streams the blocks committed on a channel as server-sent events, with the validation code of
+// each transaction. Filtered blocks are used, so the identity needs no access to the block contents. The
+// stream starts and resumes like ChaincodeEvents; the event ID is the block number.
+func (setup *OrgSetup) BlockEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	channelID := query.Get("channel")
+	if channelID == "" {
+		writeError(w, newError(http.StatusBadRequest, CodeBadRequest, "channel is required"))
+		return
+	}
+	if err := authorizeEvents(r.Context(), channelID, ""); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var options []client.BlockEventsOption
+	startBlock, ok, err := parseStartBlock(query.Get("startBlock"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if ok {
+		options = append(options, client.WithStartBlock(startBlock))
+	}
+	if lastEventID := lastEventID(r); lastEventID != "" {
+		number, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			writeError(w, newError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("invalid event ID %q, expected a block number", lastEventID)))
+			return
+		}
+		checkpoint := new(client.InMemoryCheckpointer)
+		checkpoint.CheckpointBlock(number)
+		options = append(options, client.WithCheckpoint(checkpoint))
+	}
+
+	blocks, err := setup.Gateway.GetNetwork(channelID).FilteredBlockEvents(r.Context(), options...)
+	if err != nil {
+		writeError(w, gatewayError(err))
+		return
+	}
+	streamEvents(w, r, blocks, func(block *peer.FilteredBlock) (string, string, any) {
+		data := BlockEvent{BlockNumber: block.GetNumber(), Transactions: []BlockTransaction{}}
+		for _, transaction := range block.GetFilteredTransactions() {
+			data.Transactions = append(data.Transactions, BlockTransaction{
+				TransactionID:  transaction.GetTxid(),
+				ValidationCode: transaction.GetTxValidationCode().String(),
+			})
+		}
+		return strconv.FormatUint(block.GetNumber(), 10), "block", data
+	})
+}
+
+// parseStartBlock parses the startBlock query parameter. It reports false when the parameter is not set.
+func parseStartBlock(startBlock string) (uint64, bool, error) {
+	if startBlock == "" {
+		return 0, false, nil
+	}
+	number, err := strconv.ParseUint(startBlock, 10, 64)
+	if err != nil {
+		return 0, false, newError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("invalid startBlock %q", startBlock))
+	}
+	return number, true, nil
+}
+
+func lastEventID(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("lastEventId")
+}
+
+// streamEvents writes each event as a server-sent event until the client disconnects or the gateway ends
+// the stream, which is reported with an error event so the client can reconnect from its last event ID.
+func streamEvents[T any](w http.ResponseWriter, r *http.Request, events <-chan T, format func(T) (id string, name string, data any)) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, newError(http.StatusInternalServerError, CodeInternal, "streaming is not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				if r.Context().Err() == nil {
+					errorJSON, _ := json.Marshal(newError(http.StatusBadGateway, CodeUnavailable, "the gateway closed the event stream"))
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", errorJSON)
+					flusher.Flush()
+				}
+				return
+			}
+			id, name, data := format(event)
+			dataJSON, err := json.Marshal(data)
+			if err != nil {
+				log.Printf("Failed to marshal event %s: %s\n", id, err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, name, dataJSON)
+			flusher.Flush()
+		}
+	}
+}
@@ -15,12 +15,17 @@ import (
 	"google.golang.org/grpc/credentials"
 )
 
-// Initialize the setup for the organization.
-func Initialize(setup OrgSetup) (*OrgSetup, error) {
-	log.Printf("Initializing connection for %s...\n", setup.OrgName)
-	clientConnection := setup.newGrpcConnection()
-	id := setup.newIdentity()
-	sign := setup.newSign()
+// Initialize the setup for the organization user over a gRPC connection to its gateway peer.
+func Initialize(setup OrgSetup, clientConnection *grpc.ClientConn) (*OrgSetup, error) {
+	log.Printf("Initializing connection for %s as %s...\n", setup.OrgName, setup.UserName)
+	id, err := setup.newIdentity()
+	if err != nil {
+		return nil, err
+	}
+	sign, err := setup.newSign()
+	if err != nil {
+		return nil, err
+	}
 
 	gateway, err := client.Connect(
 		id,
@@ -33,7 +38,7 @@ func Initialize(setup OrgSetup) (*OrgSetup, error) {
 		client.WithCommitStatusTimeout(1*time.Minute),
 	)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	setup.Gateway = *gateway
 	log.Println("Initialization complete")
@@ -41,10 +46,10 @@ func Initialize(setup OrgSetup) (*OrgSetup, error) {
 }
 
 // newGrpcConnection creates a gRPC connection to the Gateway server.
-func (setup OrgSetup) newGrpcConnection() *grpc.ClientConn {
+func (setup OrgSetup) newGrpcConnection() (*grpc.ClientConn, error) {
 	certificate, err := loadCertificate(setup.TLSCertPath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	certPool := x509.NewCertPool()
@@ -53,50 +58,42 @@ func (setup OrgSetup) newGrpcConnection() *grpc.ClientConn {
 
 	connection, err := grpc.NewClient(setup.PeerEndpoint, grpc.WithTransportCredentials(transportCredentials))
 	if err != nil {
-		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
+		return nil, fmt.Errorf("failed to create gRPC connection: %w", err)
 	}
 
-	return connection
+	return connection, nil
 }
 
 // newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
-func (setup OrgSetup) newIdentity() *identity.X509Identity {
+func (setup OrgSetup) newIdentity() (*identity.X509Identity, error) {
 	certificate, err := loadCertificate(setup.CertPath)
 	if err != nil {
-		panic(err)
-	}
-
-	id, err := identity.NewX509Identity(setup.MSPID, certificate)
-	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return id
+	return identity.NewX509Identity(setup.MSPID, certificate)
 }
 
 // newSign creates a function that generates a digital signature from a message digest using a private key.
-func (setup OrgSetup) newSign() identity.Sign {
+func (setup OrgSetup) newSign() (identity.Sign, error) {
 	files, err := os.ReadDir(setup.KeyPath)
 	if err != nil {
-		panic(fmt.Errorf("failed to read private key directory: %w", err))
+		return nil, fmt.Errorf("failed to read private key directory: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no private key found in %s", setup.KeyPath)
 	}
 	privateKeyPEM, err := os.ReadFile(path.Join(setup.KeyPath, files[0].Name()))
-
 	if err != nil {
-		panic(fmt.Errorf("failed to read private key file: %w", err))
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
 	}
 
 	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
 	if err != nil {
-		panic(err)
-	}
-
-	sign, err := identity.NewPrivateKeySign(privateKey)
-	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return sign
+	return identity.NewPrivateKeySign(privateKey)
 }
 
 func loadCertificate(filename string) (*x509.Certificate, error) {
@@ -7,34 +7,49 @@ import (
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 )
 
-// Invoke handles chaincode invoke requests.
+// Invoke handles chaincode invoke requests. It responds once the transaction is committed, or with the
+// reason it failed to endorse, order or commit.
 func (setup *OrgSetup) Invoke(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("Received Invoke request")
-	if err := r.ParseForm(); err != nil {
-		fmt.Fprintf(w, "ParseForm() err: %s", err)
-		return
-	}
-	chainCodeName := r.FormValue("chaincodeid")
-	channelID := r.FormValue("channelid")
-	function := r.FormValue("function")
-	args := r.Form["args"]
-	fmt.Printf("channel: %s, chaincode: %s, function: %s, args: %s\n", channelID, chainCodeName, function, args)
-	network := setup.Gateway.GetNetwork(channelID)
-	contract := network.GetContract(chainCodeName)
-	txn_proposal, err := contract.NewProposal(function, client.WithArguments(args...))
+	if r.Method != http.MethodPost {
+		writeError(w, newError(http.StatusMethodNotAllowed, CodeBadRequest, "invoke requests must be POST"))
+		return
+	}
+	request, err := readTransactionRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := authorizeTransaction(r.Context(), request.ChannelID, request.ChaincodeName, request.Function); err != nil {
+		writeError(w, err)
+		return
+	}
+	fmt.Printf("channel: %s, chaincode: %s, function: %s, args: %s\n", request.ChannelID, request.ChaincodeName, request.Function, request.Args)
+	network := setup.Gateway.GetNetwork(request.ChannelID)
+	contract := network.GetContract(request.ChaincodeName)
+	txn_proposal, err := contract.NewProposal(request.Function, client.WithArguments(request.Args...))
 	if err != nil {
-		fmt.Fprintf(w, "Error creating txn proposal: %s", err)
+		writeError(w, newError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("Error creating txn proposal: %s", err)))
 		return
 	}
 	txn_endorsed, err := txn_proposal.Endorse()
 	if err != nil {
-		fmt.Fprintf(w, "Error endorsing txn: %s", err)
+		writeError(w, gatewayError(err))
 		return
 	}
 	txn_committed, err := txn_endorsed.Submit()
 	if err != nil {
-		fmt.Fprintf(w, "Error submitting transaction: %s", err)
+		writeError(w, gatewayError(err))
+		return
+	}
+	status, err := txn_committed.Status()
+	if err != nil {
+		writeError(w, gatewayError(err))
+		return
+	}
+	if !status.Successful {
+		writeError(w, commitFailure(status.TransactionID, status.Code))
 		return
 	}
-	fmt.Fprintf(w, "Transaction ID : %s Response: %s", txn_committed.TransactionID(), txn_endorsed.Result())
+	writeResponse(w, http.StatusOK, Response{TransactionID: status.TransactionID, Result: resultJSON(txn_endorsed.Result())})
 }
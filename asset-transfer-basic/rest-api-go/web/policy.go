@@ -0,0 +1,131 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+)
+
+// wildcard matches any identity, channel, chaincode or function in a policy.
+const wildcard = "*"
+
+// Policy allowlists, for each caller role, the identities the role may sign as and the transactions it may
+// call. Anything not allowed is denied.
+type Policy struct {
+	Roles map[string]RolePolicy `json:"roles"`
+}
+
+// RolePolicy is what a role is allowed to do.
+type RolePolicy struct {
+	Identities []string     `json:"identities"`
+	Allow      []PolicyRule `json:"allow"`
+}
+
+// PolicyRule allows the functions of a chaincode on a channel. Events allows streaming the chaincode's
+// events; a rule for every chaincode ("*") with Events also allows streaming the channel's blocks.
+type PolicyRule struct {
+	Channel   string   `json:"channel"`
+	Chaincode string   `json:"chaincode"`
+	Functions []string `json:"functions"`
+	Events    bool     `json:"events,omitempty"`
+}
+
+// LoadPolicy reads a policy file.
+func LoadPolicy(filename string) (*Policy, error) {
+	policyJSON, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	var policy Policy
+	if err := json.Unmarshal(policyJSON, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", filename, err)
+	}
+	for role, rolePolicy := range policy.Roles {
+		for _, rule := range rolePolicy.Allow {
+			if rule.Channel == "" || rule.Chaincode == "" {
+				return nil, fmt.Errorf("a rule of role %s needs a channel and chaincode", role)
+			}
+		}
+	}
+	return &policy, nil
+}
+
+func matches(patterns []string, value string) bool {
+	return slices.Contains(patterns, wildcard) || slices.Contains(patterns, value)
+}
+
+func matchesOne(pattern string, value string) bool {
+	return pattern == wildcard || pattern == value
+}
+
+// allowIdentity reports whether the role may sign with the identity.
+func (policy *Policy) allowIdentity(role string, identity string) bool {
+	return matches(policy.Roles[role].Identities, identity)
+}
+
+// allowTransaction reports whether the role may call the function.
+func (policy *Policy) allowTransaction(role string, channelID string, chaincodeName string, function string) bool {
+	for _, rule := range policy.Roles[role].Allow {
+		if matchesOne(rule.Channel, channelID) && matchesOne(rule.Chaincode, chaincodeName) && matches(rule.Functions, function) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowEvents reports whether the role may stream the events of the chaincode, or the blocks of the channel
+// when chaincodeName is empty.
+func (policy *Policy) allowEvents(role string, channelID string, chaincodeName string) bool {
+	for _, rule := range policy.Roles[role].Allow {
+		if !rule.Events || !matchesOne(rule.Channel, channelID) {
+			continue
+		}
+		if rule.Chaincode == wildcard || (chaincodeName != "" && rule.Chaincode == chaincodeName) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizeTransaction checks that the caller of the request may call the function, and records the
+// transaction in the audit log. Every caller is allowed when authentication is disabled.
+func authorizeTransaction(ctx context.Context, channelID string, chaincodeName string, function string) error {
+	auditFrom(ctx).transaction(channelID, chaincodeName, function)
+	if mayCall(ctx, channelID, chaincodeName, function) {
+		return nil
+	}
+	return newError(http.StatusForbidden, CodeAccessDenied, fmt.Sprintf("role %s may not call %s on %s/%s", callerFrom(ctx).Role, function, channelID, chaincodeName))
+}
+
+// mayCall reports whether the caller of the request may call the function, without recording it.
+func mayCall(ctx context.Context, channelID string, chaincodeName string, function string) bool {
+	caller := callerFrom(ctx)
+	return caller == nil || caller.policy.allowTransaction(caller.Role, channelID, chaincodeName, function)
+}
+
+// authorizeEvents checks that the caller of the request may stream the events of the chaincode, or the
+// blocks of the channel when chaincodeName is empty.
+func authorizeEvents(ctx context.Context, channelID string, chaincodeName string) error {
+	auditFrom(ctx).transaction(channelID, chaincodeName, "")
+	caller := callerFrom(ctx)
+	if caller == nil || caller.policy.allowEvents(caller.Role, channelID, chaincodeName) {
+		return nil
+	}
+	if chaincodeName == "" {
+		return newError(http.StatusForbidden, CodeAccessDenied, fmt.Sprintf("role %s may not stream the blocks of %s", caller.Role, channelID))
+	}
+	return newError(http.StatusForbidden, CodeAccessDenied, fmt.Sprintf("role %s may not stream the events of %s/%s", caller.Role, channelID, chaincodeName))
+}
+
+// authorizeIdentity checks that the caller of the request may sign with the identity.
+func authorizeIdentity(ctx context.Context, identity string) error {
+	auditFrom(ctx).Identity = identity
+	caller := callerFrom(ctx)
+	if caller == nil || caller.policy.allowIdentity(caller.Role, identity) {
+		return nil
+	}
+	return newError(http.StatusForbidden, CodeAccessDenied, fmt.Sprintf("role %s may not sign as %s", caller.Role, identity))
+}
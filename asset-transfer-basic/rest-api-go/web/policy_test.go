@@ -0,0 +1,129 @@
+package web
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestPolicy() *Policy {
+	return &Policy{Roles: map[string]RolePolicy{
+		"driller": {
+			Identities: []string{"Org1/User1"},
+			Allow:      []PolicyRule{{Channel: "channel1", Chaincode: "basic_channel1", Functions: []string{"CreateAsset", "ReadAsset"}}},
+		},
+		"watcher": {
+			Allow: []PolicyRule{{Channel: "channel1", Chaincode: "basic_channel1", Functions: []string{"ReadAsset"}, Events: true}},
+		},
+		"viewer": {
+			Identities: []string{"Org1/User1"},
+			Allow:      []PolicyRule{{Channel: wildcard, Chaincode: wildcard, Functions: []string{"ReadAsset"}, Events: true}},
+		},
+		"admin": {
+			Identities: []string{wildcard},
+			Allow:      []PolicyRule{{Channel: wildcard, Chaincode: wildcard, Functions: []string{wildcard}, Events: true}},
+		},
+	}}
+}
+
+func TestAllowTransaction(t *testing.T) {
+	tests := []struct {
+		name      string
+		role      string
+		channelID string
+		chaincode string
+		function  string
+		allowed   bool
+	}{
+		{name: "allowed function", role: "driller", channelID: "channel1", chaincode: "basic_channel1", function: "CreateAsset", allowed: true},
+		{name: "other function", role: "driller", channelID: "channel1", chaincode: "basic_channel1", function: "DeleteAsset"},
+		{name: "other channel", role: "driller", channelID: "channel2", chaincode: "basic_channel1", function: "CreateAsset"},
+		{name: "other chaincode", role: "driller", channelID: "channel1", chaincode: "basic_channel2", function: "CreateAsset"},
+		{name: "wildcard channel and chaincode", role: "viewer", channelID: "channel5", chaincode: "basic_channel5", function: "ReadAsset", allowed: true},
+		{name: "function outside wildcard rule", role: "viewer", channelID: "channel5", chaincode: "basic_channel5", function: "CreateAsset"},
+		{name: "wildcard function", role: "admin", channelID: "channel6", chaincode: "token_erc20", function: "Mint", allowed: true},
+		{name: "unknown role", role: "auditor", channelID: "channel1", chaincode: "basic_channel1", function: "ReadAsset"},
+		{name: "no role", channelID: "channel1", chaincode: "basic_channel1", function: "ReadAsset"},
+	}
+
+	policy := newTestPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allowed := policy.allowTransaction(tt.role, tt.channelID, tt.chaincode, tt.function); allowed != tt.allowed {
+				t.Errorf("allowTransaction(%q, %q, %q, %q) = %v, want %v", tt.role, tt.channelID, tt.chaincode, tt.function, allowed, tt.allowed)
+			}
+		})
+	}
+
+	if (&Policy{}).allowTransaction("admin", "channel1", "basic_channel1", "ReadAsset") {
+		t.Errorf("an empty policy allowed a transaction")
+	}
+}
+
+func TestAllowEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		role      string
+		channelID string
+		chaincode string
+		allowed   bool
+	}{
+		{name: "chaincode events", role: "watcher", channelID: "channel1", chaincode: "basic_channel1", allowed: true},
+		{name: "blocks need a rule for every chaincode", role: "watcher", channelID: "channel1"},
+		{name: "other chaincode", role: "watcher", channelID: "channel1", chaincode: "basic_channel2"},
+		{name: "rule without events", role: "driller", channelID: "channel1", chaincode: "basic_channel1"},
+		{name: "wildcard blocks", role: "viewer", channelID: "channel3", allowed: true},
+		{name: "unknown role", role: "auditor", channelID: "channel1", chaincode: "basic_channel1"},
+	}
+
+	policy := newTestPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allowed := policy.allowEvents(tt.role, tt.channelID, tt.chaincode); allowed != tt.allowed {
+				t.Errorf("allowEvents(%q, %q, %q) = %v, want %v", tt.role, tt.channelID, tt.chaincode, allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestAllowIdentity(t *testing.T) {
+	tests := []struct {
+		role     string
+		identity string
+		allowed  bool
+	}{
+		{role: "driller", identity: "Org1/User1", allowed: true},
+		{role: "driller", identity: "Org2/User1"},
+		{role: "watcher", identity: "Org1/User1"},
+		{role: "admin", identity: "Org2/Admin", allowed: true},
+		{role: "auditor", identity: "Org1/User1"},
+	}
+
+	policy := newTestPolicy()
+	for _, tt := range tests {
+		if allowed := policy.allowIdentity(tt.role, tt.identity); allowed != tt.allowed {
+			t.Errorf("allowIdentity(%q, %q) = %v, want %v", tt.role, tt.identity, allowed, tt.allowed)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	policy, err := LoadPolicy(filepath.Join("..", "policy.json"))
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+	if !policy.allowTransaction("driller", "channel1", "basic_channel1", "DrillBatch") {
+		t.Errorf("the driller may not drill batches")
+	}
+	if policy.allowTransaction("refinery", "channel1", "basic_channel1", "DrillBatch") {
+		t.Errorf("the refinery may drill batches")
+	}
+
+	filename := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(filename, []byte(`{"roles":{"driller":{"allow":[{"chaincode":"basic_channel1","functions":["CreateAsset"]}]}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(filename); err == nil || err.Error() != "a rule of role driller needs a channel and chaincode" {
+		t.Errorf("LoadPolicy() error = %v, want a missing channel", err)
+	}
+}
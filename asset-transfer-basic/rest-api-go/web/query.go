@@ -5,21 +5,25 @@ import (
 	"net/http"
 )
 
-// Query handles chaincode query requests.
-func (setup OrgSetup) Query(w http.ResponseWriter, r *http.Request) {
+// Query handles chaincode query requests, sent as GET with query parameters or as POST with a JSON body.
+func (setup *OrgSetup) Query(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("Received Query request")
-	queryParams := r.URL.Query()
-	chainCodeName := queryParams.Get("chaincodeid")
-	channelID := queryParams.Get("channelid")
-	function := queryParams.Get("function")
-	args := r.URL.Query()["args"]
-	fmt.Printf("channel: %s, chaincode: %s, function: %s, args: %s\n", channelID, chainCodeName, function, args)
-	network := setup.Gateway.GetNetwork(channelID)
-	contract := network.GetContract(chainCodeName)
-	evaluateResponse, err := contract.EvaluateTransaction(function, args...)
+	request, err := readTransactionRequest(r)
 	if err != nil {
-		fmt.Fprintf(w, "Error: %s", err)
+		writeError(w, err)
 		return
 	}
-	fmt.Fprintf(w, "Response: %s", evaluateResponse)
+	if err := authorizeTransaction(r.Context(), request.ChannelID, request.ChaincodeName, request.Function); err != nil {
+		writeError(w, err)
+		return
+	}
+	fmt.Printf("channel: %s, chaincode: %s, function: %s, args: %s\n", request.ChannelID, request.ChaincodeName, request.Function, request.Args)
+	network := setup.Gateway.GetNetwork(request.ChannelID)
+	contract := network.GetContract(request.ChaincodeName)
+	evaluateResponse, err := contract.EvaluateTransaction(request.Function, request.Args...)
+	if err != nil {
+		writeError(w, gatewayError(err))
+		return
+	}
+	writeResponse(w, http.StatusOK, Response{Result: resultJSON(evaluateResponse)})
 }
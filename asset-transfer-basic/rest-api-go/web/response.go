@@ -0,0 +1,99 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+)
+
+// TransactionRequest is the JSON body of a query or invoke request.
+type TransactionRequest struct {
+	ChannelID     string   `json:"channelId"`
+	ChaincodeName string   `json:"chaincodeId"`
+	Function      string   `json:"function"`
+	Args          []string `json:"args"`
+}
+
+// Response is the JSON envelope of every response. Either Result or Error is set.
+type Response struct {
+	TransactionID string          `json:"transactionId,omitempty"`
+	Result        json.RawMessage `json:"result,omitempty"`
+	Error         *Error          `json:"error,omitempty"`
+}
+
+// readTransactionRequest reads a JSON request body. Form encoded bodies and query parameters, named
+// channelid, chaincodeid, function and args, are still accepted.
+func readTransactionRequest(r *http.Request) (*TransactionRequest, error) {
+	var request TransactionRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.Method == http.MethodPost && mediaType == "application/json" {
+		if err := decodeJSONBody(r, &request); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, newError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("invalid request: %s", err))
+		}
+		request = TransactionRequest{
+			ChannelID:     r.FormValue("channelid"),
+			ChaincodeName: r.FormValue("chaincodeid"),
+			Function:      r.FormValue("function"),
+			Args:          r.Form["args"],
+		}
+	}
+
+	if err := request.validate(); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// decodeJSONBody reads the JSON request body into v, rejecting fields v does not have.
+func decodeJSONBody(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return newError(http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("invalid request body: %s", err))
+	}
+	return nil
+}
+
+func (request *TransactionRequest) validate() error {
+	if request.ChannelID == "" || request.ChaincodeName == "" || request.Function == "" {
+		return newError(http.StatusBadRequest, CodeBadRequest, "channelId, chaincodeId and function are required")
+	}
+	return nil
+}
+
+// resultJSON returns a transaction result as JSON. Results that are not JSON are returned as a string.
+func resultJSON(result []byte) json.RawMessage {
+	if len(result) == 0 {
+		return nil
+	}
+	if json.Valid(result) {
+		return result
+	}
+	resultJSON, _ := json.Marshal(string(result))
+	return resultJSON
+}
+
+func writeResponse(w http.ResponseWriter, httpStatus int, response Response) {
+	recordResponse(w, response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to write response: %s\n", err)
+	}
+}
+
+// writeError writes the error envelope. Errors other than *Error are reported as internal errors.
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = newError(http.StatusInternalServerError, CodeInternal, err.Error())
+	}
+	writeResponse(w, apiErr.Status, Response{TransactionID: apiErr.TransactionID, Error: apiErr})
+}
@@ -0,0 +1,248 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Commit states of a submitted transaction.
+const (
+	TransactionPending   = "PENDING"
+	TransactionCommitted = "COMMITTED"
+	TransactionInvalid   = "INVALID"
+	// TransactionUnknown means the commit status could not be obtained; the transaction may still commit.
+	TransactionUnknown = "UNKNOWN"
+)
+
+const (
+	// Submitted transactions are kept this long after they were submitted, so clients can poll for them.
+	transactionRetention = time.Hour
+	// CommitStatus calls that time out are retried this many times before the state becomes UNKNOWN.
+	commitStatusAttempts = 5
+	callbackAttempts     = 3
+	callbackTimeout      = 10 * time.Second
+)
+
+// SubmitRequest is the JSON body of an asynchronous submit. The callback URL, when given, is sent the
+// Transaction as a JSON POST once its commit status is known.
+type SubmitRequest struct {
+	TransactionRequest
+	CallbackURL string `json:"callbackUrl,omitempty"`
+}
+
+// Transaction reports a transaction submitted through POST /transactions.
+type Transaction struct {
+	TransactionID  string          `json:"transactionId"`
+	Caller         string          `json:"caller,omitempty"`
+	Identity       string          `json:"identity"`
+	ChannelID      string          `json:"channelId"`
+	ChaincodeName  string          `json:"chaincodeId"`
+	Function       string          `json:"function"`
+	Status         string          `json:"status"`
+	ValidationCode string          `json:"validationCode,omitempty"`
+	BlockNumber    uint64          `json:"blockNumber,omitempty"`
+	Result         json.RawMessage `json:"result,omitempty"`
+	Error          *Error          `json:"error,omitempty"`
+	SubmittedAt    time.Time       `json:"submittedAt"`
+	CompletedAt    *time.Time      `json:"completedAt,omitempty"`
+}
+
+// transactionTracker keeps the submitted transactions until their retention expires.
+type transactionTracker struct {
+	mu           sync.Mutex
+	transactions map[string]*Transaction
+	guard        *callbackGuard
+	callbacks    *http.Client
+}
+
+// newTransactionTracker returns a tracker that only calls back the callback hosts, or any public host when
+// callbackHosts is empty.
+func newTransactionTracker(callbackHosts []string) *transactionTracker {
+	guard := newCallbackGuard(callbackHosts)
+	return &transactionTracker{
+		transactions: make(map[string]*Transaction),
+		guard:        guard,
+		callbacks:    guard.newClient(),
+	}
+}
+
+func (tracker *transactionTracker) add(transaction *Transaction) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	for id, tracked := range tracker.transactions {
+		if time.Since(tracked.SubmittedAt) > transactionRetention {
+			delete(tracker.transactions, id)
+		}
+	}
+	tracker.transactions[transaction.TransactionID] = transaction
+}
+
+// get returns a copy of the transaction, so it can be written while the commit status is being recorded.
+func (tracker *transactionTracker) get(transactionID string) (Transaction, bool) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	transaction, ok := tracker.transactions[transactionID]
+	if !ok {
+		return Transaction{}, false
+	}
+	return *transaction, true
+}
+
+// complete records the outcome of the transaction and returns a copy of it.
+func (tracker *transactionTracker) complete(transaction *Transaction, status *client.Status, err *Error) Transaction {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	completedAt := time.Now().UTC()
+	transaction.CompletedAt = &completedAt
+	switch {
+	case err != nil:
+		transaction.Status = TransactionUnknown
+		transaction.Error = err
+	case status.Successful:
+		transaction.Status = TransactionCommitted
+	default:
+		transaction.Status = TransactionInvalid
+		transaction.Error = commitFailure(status.TransactionID, status.Code)
+	}
+	if status != nil {
+		transaction.ValidationCode = status.Code.String()
+		transaction.BlockNumber = status.BlockNumber
+	}
+	return *transaction
+}
+
+// SubmitTransaction handles asynchronous invoke requests. It endorses the transaction and submits it to
+// the orderer, then responds with the transaction ID without waiting for the commit, which is reported by
+// GET /transactions/{id} and, when requested, the callback URL.
+func (server *Server) SubmitTransaction(setup *OrgSetup, w http.ResponseWriter, r *http.Request) {
+	var request SubmitRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := request.validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := authorizeTransaction(r.Context(), request.ChannelID, request.ChaincodeName, request.Function); err != nil {
+		writeError(w, err)
+		return
+	}
+	if request.CallbackURL != "" {
+		if err := server.transactions.guard.checkURL(r.Context(), request.CallbackURL); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	fmt.Printf("channel: %s, chaincode: %s, function: %s, args: %s\n", request.ChannelID, request.ChaincodeName, request.Function, request.Args)
+
+	contract := setup.Gateway.GetNetwork(request.ChannelID).GetContract(request.ChaincodeName)
+	result, commit, err := contract.SubmitAsync(request.Function, client.WithArguments(request.Args...))
+	if err != nil {
+		writeError(w, gatewayError(err))
+		return
+	}
+
+	transaction := &Transaction{
+		TransactionID: commit.TransactionID(),
+		Caller:        callerName(r),
+		Identity:      setup.identityName(),
+		ChannelID:     request.ChannelID,
+		ChaincodeName: request.ChaincodeName,
+		Function:      request.Function,
+		Status:        TransactionPending,
+		Result:        resultJSON(result),
+		SubmittedAt:   time.Now().UTC(),
+	}
+	server.transactions.add(transaction)
+	go server.awaitCommit(transaction, commit, request.CallbackURL)
+
+	w.Header().Set("Location", "/transactions/"+transaction.TransactionID)
+	writeResponse(w, http.StatusAccepted, Response{TransactionID: transaction.TransactionID, Result: transaction.Result})
+}
+
+// GetTransaction handles commit status requests for transactions the caller submitted with the identity.
+func (server *Server) GetTransaction(setup *OrgSetup, w http.ResponseWriter, r *http.Request) {
+	transactionID := r.PathValue("id")
+	transaction, ok := server.transactions.get(transactionID)
+	if !ok || transaction.Identity != setup.identityName() || transaction.Caller != callerName(r) {
+		writeError(w, newError(http.StatusNotFound, CodeUnknownTransaction, fmt.Sprintf("no transaction %s was submitted by %s", transactionID, setup.identityName())))
+		return
+	}
+	auditFrom(r.Context()).transaction(transaction.ChannelID, transaction.ChaincodeName, transaction.Function)
+	transactionJSON, err := json.Marshal(transaction)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResponse(w, http.StatusOK, Response{TransactionID: transaction.TransactionID, Result: transactionJSON})
+}
+
+// awaitCommit waits for the commit status of the transaction through the gateway's CommitStatus API, records
+// it and calls back the client.
+func (server *Server) awaitCommit(transaction *Transaction, commit *client.Commit, callbackURL string) {
+	var status *client.Status
+	var err error
+	for attempt := 1; attempt <= commitStatusAttempts; attempt++ {
+		status, err = commit.Status()
+		if err == nil || gatewayError(err).Code != CodeTimeout {
+			break
+		}
+	}
+
+	var completed Transaction
+	if err != nil {
+		completed = server.transactions.complete(transaction, nil, gatewayError(err))
+	} else {
+		completed = server.transactions.complete(transaction, status, nil)
+	}
+	log.Printf("Transaction %s is %s\n", completed.TransactionID, completed.Status)
+
+	if callbackURL != "" {
+		server.transactions.callBack(callbackURL, completed)
+	}
+}
+
+// callBack posts the transaction to the callback URL, retrying when the receiver fails.
+func (tracker *transactionTracker) callBack(callbackURL string, transaction Transaction) {
+	transactionJSON, err := json.Marshal(transaction)
+	if err != nil {
+		log.Printf("Failed to marshal callback for transaction %s: %s\n", transaction.TransactionID, err)
+		return
+	}
+	for attempt := 1; attempt <= callbackAttempts; attempt++ {
+		err = tracker.post(callbackURL, transactionJSON)
+		if err == nil {
+			return
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+	log.Printf("Failed to call back %s for transaction %s: %s\n", callbackURL, transaction.TransactionID, err)
+}
+
+func (tracker *transactionTracker) post(callbackURL string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), callbackTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := tracker.callbacks.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("callback returned %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright IBM Corp. All Rights Reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+'use strict';
+
+const { Gateway, Wallets } = require('fabric-network');
+const path = require('path');
+const { buildCCPOrg1, buildCCPOrg2, buildWallet, prettyJSONString } = require('../../test-application/javascript/AppUtil.js');
+
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction';
+
+async function acceptPrice (ccp, wallet, user, orgMSP, auctionID, quantity) {
+	try {
+		const gateway = new Gateway();
+		// connect using Discovery enabled
+
+		await gateway.connect(ccp,
+			{ wallet: wallet, identity: user, discovery: { enabled: true, asLocalhost: true } });
+
+		const network = await gateway.getNetwork(myChannel);
+		const contract = network.getContract(myChaincodeName);
+
+		// Query the auction to get the list of endorsing orgs and the current clock price.
+		const auctionString = await contract.evaluateTransaction('QueryAuction', auctionID);
+		const auctionJSON = JSON.parse(auctionString);
+		const price = await contract.evaluateTransaction('QueryClockPrice', auctionID);
+		console.log('*** Result: clock price: ' + price.toString());
+
+		const statefulTxn = contract.createTransaction('AcceptPrice');
+
+		const endorsingOrgs = auctionJSON.organizations.slice();
+		if (!endorsingOrgs.includes(orgMSP)) {
+			endorsingOrgs.push(orgMSP);
+		}
+		statefulTxn.setEndorsingOrganizations(...endorsingOrgs);
+
+		console.log('\n--> Submit Transaction: accept the clock price');
+		await statefulTxn.submit(auctionID, parseInt(quantity), parseInt(price.toString()));
+		console.log('*** Result: committed');
+
+		console.log('\n--> Evaluate Transaction: query the updated auction');
+		const result = await contract.evaluateTransaction('QueryAuction', auctionID);
+		console.log('*** Result: Auction: ' + prettyJSONString(result.toString()));
+
+		gateway.disconnect();
+	} catch (error) {
+		console.error(`******** FAILED to accept price: ${error}`);
+		process.exit(1);
+	}
+}
+
+async function main () {
+	try {
+		if (process.argv[2] === undefined || process.argv[3] === undefined ||
+            process.argv[4] === undefined || process.argv[5] === undefined) {
+			console.log('Usage: node acceptPrice.js org userID auctionID quantity');
+			process.exit(1);
+		}
+
+		const org = process.argv[2];
+		const user = process.argv[3];
+		const auctionID = process.argv[4];
+		const quantity = process.argv[5];
+
+		if (org === 'Org1' || org === 'org1') {
+			const orgMSP = 'Org1MSP';
+			const ccp = buildCCPOrg1();
+			const walletPath = path.join(__dirname, 'wallet/org1');
+			const wallet = await buildWallet(Wallets, walletPath);
+			await acceptPrice(ccp, wallet, user, orgMSP, auctionID, quantity);
+		} else if (org === 'Org2' || org === 'org2') {
+			const orgMSP = 'Org2MSP';
+			const ccp = buildCCPOrg2();
+			const walletPath = path.join(__dirname, 'wallet/org2');
+			const wallet = await buildWallet(Wallets, walletPath);
+			await acceptPrice(ccp, wallet, user, orgMSP, auctionID, quantity);
+		} else {
+			console.log('Usage: node acceptPrice.js org userID auctionID quantity');
+			console.log('Org must be Org1 or Org2');
+		}
+	} catch (error) {
+		console.error(`******** FAILED to run the application: ${error}`);
+	}
+}
+
+main();
@@ -13,7 +13,7 @@ const { buildCCPOrg1, buildCCPOrg2, buildWallet, prettyJSONString } = require('.
 const myChannel = 'mychannel';
 const myChaincodeName = 'auction';
 
-async function createAuction (ccp, wallet, user, auctionID, item, quantity, auditor) {
+async function createAuction (ccp, wallet, user, auctionID, item, quantity, biddingMinutes, revealMinutes, auditors, auditorThreshold, assetChaincode, tokenChaincode, assets) {
 	try {
 		const gateway = new Gateway();
 		// connect using Discovery enabled
@@ -24,10 +24,15 @@ async function createAuction (ccp, wallet, user, auctionID, item, quantity, audi
 		const network = await gateway.getNetwork(myChannel);
 		const contract = network.getContract(myChaincodeName);
 
+		// the deadlines are counted from now, and passed as RFC 3339 timestamps
+		const biddingDeadline = new Date(Date.now() + parseInt(biddingMinutes) * 60000);
+		const revealDeadline = new Date(biddingDeadline.getTime() + parseInt(revealMinutes) * 60000);
+
 		const statefulTxn = contract.createTransaction('CreateAuction');
 
 		console.log('\n--> Submit Transaction: Propose a new auction');
-		await statefulTxn.submit(auctionID, item, parseInt(quantity), auditor);
+		await statefulTxn.submit(auctionID, item, parseInt(quantity), biddingDeadline.toISOString(), revealDeadline.toISOString(),
+			assetChaincode, JSON.stringify(assets), tokenChaincode, JSON.stringify(auditors), parseInt(auditorThreshold));
 		console.log('*** Result: committed');
 
 		console.log('\n--> Evaluate Transaction: query the auction that was just created');
@@ -44,8 +49,9 @@ async function main () {
 	try {
 		if (process.argv[2] === undefined || process.argv[3] === undefined ||
             process.argv[4] === undefined || process.argv[5] === undefined ||
-            process.argv[6] === undefined) {
-			console.log('Usage: node createAuction.js org userID auctionID item quantity');
+            process.argv[6] === undefined || process.argv[7] === undefined ||
+            process.argv[8] === undefined) {
+			console.log('Usage: node createAuction.js org userID auctionID item quantity biddingMinutes revealMinutes [auditors auditorThreshold [assetChaincode tokenChaincode assetIDs]]');
 			process.exit(1);
 		}
 
@@ -54,20 +60,28 @@ async function main () {
 		const auctionID = process.argv[4];
 		const item = process.argv[5];
 		const quantity = process.argv[6];
-		const auditor = process.argv[7];
+		const biddingMinutes = process.argv[7];
+		const revealMinutes = process.argv[8];
+		// the auditors are passed as a comma separated list of MSP IDs, or none
+		const auditors = process.argv[9] && process.argv[9] !== 'none' ? process.argv[9].split(',') : [];
+		const auditorThreshold = process.argv[10] || '0';
+		// the assets that the seller locks in escrow are passed as a comma separated list
+		const assetChaincode = process.argv[11] || '';
+		const tokenChaincode = process.argv[12] || '';
+		const assets = process.argv[13] ? process.argv[13].split(',') : [];
 
 		if (org === 'Org1' || org === 'org1') {
 			const ccp = buildCCPOrg1();
 			const walletPath = path.join(__dirname, 'wallet/org1');
 			const wallet = await buildWallet(Wallets, walletPath);
-			await createAuction(ccp, wallet, user, auctionID, item, quantity, auditor);
+			await createAuction(ccp, wallet, user, auctionID, item, quantity, biddingMinutes, revealMinutes, auditors, auditorThreshold, assetChaincode, tokenChaincode, assets);
 		} else if (org === 'Org2' || org === 'org2') {
 			const ccp = buildCCPOrg2();
 			const walletPath = path.join(__dirname, 'wallet/org2');
 			const wallet = await buildWallet(Wallets, walletPath);
-			await createAuction(ccp, wallet, user, auctionID, item, quantity, auditor);
+			await createAuction(ccp, wallet, user, auctionID, item, quantity, biddingMinutes, revealMinutes, auditors, auditorThreshold, assetChaincode, tokenChaincode, assets);
 		} else {
-			console.log('Usage: node createAuction.js org userID auctionID item quantity');
+			console.log('Usage: node createAuction.js org userID auctionID item quantity biddingMinutes revealMinutes [auditors auditorThreshold [assetChaincode tokenChaincode assetIDs]]');
 			console.log('Org must be Org1 or Org2');
 		}
 	} catch (error) {
@@ -24,9 +24,14 @@ async function endAuction (ccp, wallet, org, user, auctionID) {
 		const network = await gateway.getNetwork(myChannel);
 		const contract = network.getContract(myChaincodeName);
 
+		// Query the auction to get the auditors, enough of which need to endorse
+		const auctionString = await contract.evaluateTransaction('QueryAuction', auctionID);
+		const auctionJSON = JSON.parse(auctionString);
+		const auditors = auctionJSON.auditors.slice(0, auctionJSON.auditorThreshold);
+
 		const statefulTxn = contract.createTransaction('EndAuction');
 
-		statefulTxn.setEndorsingOrganizations(org, 'Org3MSP');
+		statefulTxn.setEndorsingOrganizations(org, ...auditors);
 
 		console.log('\n--> Submit the transaction to end the auction');
 		await statefulTxn.submit(auctionID);
@@ -0,0 +1,69 @@
+/*
+ * Copyright IBM Corp. All Rights Reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+'use strict';
+
+const { Gateway, Wallets } = require('fabric-network');
+const path = require('path');
+const { buildCCPOrg1, buildCCPOrg2, buildWallet, prettyJSONString } = require('../../../test-application/javascript/AppUtil.js');
+
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction';
+
+async function listAuctions (ccp, wallet, user, status, itemFilter, pageSize, bookmark) {
+	try {
+		const gateway = new Gateway();
+		// connect using Discovery enabled
+		await gateway.connect(ccp,
+			{ wallet: wallet, identity: user, discovery: { enabled: true, asLocalhost: true } });
+
+		const network = await gateway.getNetwork(myChannel);
+		const contract = network.getContract(myChaincodeName);
+
+		console.log('\n--> Evaluate Transaction: list the auctions');
+		const result = await contract.evaluateTransaction('ListAuctions', status, itemFilter, pageSize, bookmark);
+		console.log('*** Result: Auctions: ' + prettyJSONString(result.toString()));
+
+		gateway.disconnect();
+	} catch (error) {
+		console.error(`******** FAILED to list auctions: ${error}`);
+	}
+}
+
+async function main () {
+	try {
+		if (process.argv[2] === undefined || process.argv[3] === undefined) {
+			console.log('Usage: node listAuctions.js org userID [status [itemFilter [pageSize [bookmark]]]]');
+			process.exit(1);
+		}
+
+		const org = process.argv[2];
+		const user = process.argv[3];
+		const status = process.argv[4] || '';
+		const itemFilter = process.argv[5] || '';
+		const pageSize = process.argv[6] || '10';
+		const bookmark = process.argv[7] || '';
+
+		if (org === 'Org1' || org === 'org1') {
+			const ccp = buildCCPOrg1();
+			const walletPath = path.join(__dirname, 'wallet/org1');
+			const wallet = await buildWallet(Wallets, walletPath);
+			await listAuctions(ccp, wallet, user, status, itemFilter, pageSize, bookmark);
+		} else if (org === 'Org2' || org === 'org2') {
+			const ccp = buildCCPOrg2();
+			const walletPath = path.join(__dirname, 'wallet/org2');
+			const wallet = await buildWallet(Wallets, walletPath);
+			await listAuctions(ccp, wallet, user, status, itemFilter, pageSize, bookmark);
+		} else {
+			console.log('Usage: node listAuctions.js org userID [status [itemFilter [pageSize [bookmark]]]]');
+			console.log('Org must be Org1 or Org2');
+		}
+	} catch (error) {
+		console.error(`******** FAILED to run the application: ${error}`);
+	}
+}
+
+main();
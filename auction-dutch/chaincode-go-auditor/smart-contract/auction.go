@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
@@ -31,7 +32,21 @@ type Auction struct {
 	Winners      []Winners          `json:"winners"`
 	Price        int                `json:"price"`
 	Status       string             `json:"status"`
-	Auditor      bool               `json:"auditor"`
+	Clock        *Clock             `json:"clock,omitempty" metadata:",optional"`
+	// Auditors are the organizations that can update the auction together
+	// with one participant, if AuditorThreshold of them endorse the update
+	Auditors         []string `json:"auditors"`
+	AuditorThreshold int      `json:"auditorThreshold"`
+	// BiddingDeadline ends the bidding period, and RevealDeadline ends the
+	// period in which bids can be revealed. Clock auctions have no deadlines
+	BiddingDeadline time.Time `json:"biddingDeadline"`
+	RevealDeadline  time.Time `json:"revealDeadline"`
+	// AssetChaincode holds the units of the item in escrow, one of the Assets
+	// for each unit, and buyers pay with the tokens of TokenChaincode. They are
+	// empty if the auction does not settle itself
+	AssetChaincode string   `json:"assetChaincode"`
+	Assets         []string `json:"assets"`
+	TokenChaincode string   `json:"tokenChaincode"`
 }
 
 // FullBid is the structure of a revealed bid
@@ -49,14 +64,25 @@ type BidHash struct {
 	Hash string `json:"hash"`
 }
 
-// Winners stores the winners of the auction
+// Winners stores the winners of the auction. In a clock auction, each winner
+// also records the price at which they accepted their units. If the auction
+// settles itself, each winner records the assets they received
 type Winners struct {
-	Buyer    string `json:"buyer"`
-	Quantity int    `json:"quantity"`
+	Buyer    string   `json:"buyer"`
+	Quantity int      `json:"quantity"`
+	Price    int      `json:"price,omitempty" metadata:",optional"`
+	Assets   []string `json:"assets,omitempty" metadata:",optional"`
 }
 
 const bidKeyType = "bid"
 
+// Names of the chaincode events that clients can subscribe to. Each event
+// carries an AuctionRecord with the auction after the transaction
+const (
+	auctionClosedEvent = "AuctionClosed"
+	auctionEndedEvent  = "AuctionEnded"
+)
+
 // SubmitBid is used by the bidder to add the hash of that bid stored in private data to the
 // auction. Note that this function alters the auction in private state, and needs
 // to meet the auction endorsement policy. Transaction ID is used identify the bid
@@ -74,12 +100,39 @@ func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("failed to get auction from public state %v", err)
 	}
 
+	// the auditor only endorses updates to the auctions that name its
+	// organization as an auditor
+	err = verifyPeerOrgIsAuditor(auction)
+	if err != nil {
+		return err
+	}
+
+	// a clock auction is won by accepting its price, not by bidding
+	if auction.Clock != nil {
+		return fmt.Errorf("cannot add a sealed bid to a clock auction")
+	}
+
+	// the auditors of the auction cannot bid in it
+	if contains(auction.Auditors, clientOrgID) {
+		return fmt.Errorf("auditor %s cannot join the auction", clientOrgID)
+	}
+
 	// the auction needs to be open for users to add their bid
 	status := auction.Status
 	if status != "open" {
 		return fmt.Errorf("cannot join closed or ended auction")
 	}
 
+	// bids can only be added until the bidding deadline, even if the
+	// auction has not been closed yet
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !now.Before(auction.BiddingDeadline) {
+		return fmt.Errorf("cannot join auction after the bidding deadline %v", auction.BiddingDeadline)
+	}
+
 	// get the inplicit collection name of bidder's org
 	collection, err := getCollectionName(ctx)
 	if err != nil {
@@ -115,7 +168,7 @@ func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, a
 		newOrgs := append(orgs, clientOrgID)
 		auction.Orgs = newOrgs
 
-		err = setAssetStateBasedEndorsement(ctx, auctionID, newOrgs, auction.Auditor)
+		err = setAssetStateBasedEndorsement(ctx, auctionID, newOrgs, auction.Auditors, auction.AuditorThreshold)
 		if err != nil {
 			return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
 		}
@@ -178,6 +231,13 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("failed to get auction from public state %v", err)
 	}
 
+	// the auditor only endorses updates to the auctions that name its
+	// organization as an auditor
+	err = verifyPeerOrgIsAuditor(auction)
+	if err != nil {
+		return err
+	}
+
 	// check that the bidders org is a participant in the auction
 	orgs := auction.Orgs
 	if !(contains(orgs, clientOrgID)) {
@@ -186,12 +246,23 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 
 	// Complete a series of three checks before we add the bid to the auction
 
-	// check 1: check that the auction is closed. We cannot reveal an
-	// bid to an open auction
+	if auction.Clock != nil {
+		return fmt.Errorf("cannot reveal a sealed bid to a clock auction")
+	}
+
+	// check 1: check that the auction is closed and the reveal deadline has
+	// not passed. We cannot reveal an bid to an open auction
 	status := auction.Status
 	if status != "closed" {
 		return errors.New("cannot reveal bid for open or ended auction")
 	}
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !now.Before(auction.RevealDeadline) {
+		return fmt.Errorf("cannot reveal bid after the reveal deadline %v", auction.RevealDeadline)
+	}
 
 	// check 2: check that hash of revealed bid matches hash of private bid
 	// on the public ledger. This checks that the bidder is telling the truth
@@ -240,6 +311,14 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("failed to unmarshal JSON: %v", err)
 	}
 
+	// a bid buys at least one unit, at a price that cannot be negative
+	if bidInput.Quantity < 1 {
+		return fmt.Errorf("bid quantity must be at least 1, not %d", bidInput.Quantity)
+	}
+	if bidInput.Price < 0 {
+		return fmt.Errorf("bid price cannot be negative: %d", bidInput.Price)
+	}
+
 	// get ID of submitting client
 	clientID, err := s.GetSubmittingClientIdentity(ctx)
 	if err != nil {
@@ -260,6 +339,14 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("permission denied, client id %v is not the owner of the bid", clientID)
 	}
 
+	// the bidder locks the price of their bid when the auction settles itself
+	if auction.TokenChaincode != "" {
+		err = lockPayment(ctx, auction, txID, newBid)
+		if err != nil {
+			return err
+		}
+	}
+
 	auction.RevealedBids[bidKey] = newBid
 
 	auctionJSON, _ := json.Marshal(auction)
@@ -273,8 +360,9 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 	return nil
 }
 
-// CloseAuction can be used by the seller to close the auction. This prevents
-// bids from being added to the auction, and allows users to reveal their bid
+// CloseAuction can be used by any participant to close the auction once the
+// bidding deadline has passed. This prevents bids from being added to the
+// auction, and allows users to reveal their bid
 func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
 
 	// get the MSP ID of the bidder's org
@@ -289,23 +377,32 @@ func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface
 		return fmt.Errorf("failed to get auction from public state %v", err)
 	}
 
+	// the auditor only endorses updates to the auctions that name its
+	// organization as an auditor
+	err = verifyPeerOrgIsAuditor(auction)
+	if err != nil {
+		return err
+	}
+
 	// check that the bidders org is a participant in the auction
 	orgs := auction.Orgs
 	if !(contains(orgs, clientOrgID)) {
 		return fmt.Errorf("particiant %s is not a member of the auction", clientOrgID)
 	}
 
-	// the auction can only be closed by the seller
+	// a clock auction has no bidding phase to close
+	if auction.Clock != nil {
+		return fmt.Errorf("cannot close a clock auction, end it to withdraw the units that are not sold")
+	}
 
-	// get ID of submitting client
-	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	// the auction cannot be closed before the bidding deadline, so that the
+	// seller cannot cut the bidding short
+	now, err := getTxTime(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get client identity %v", err)
+		return err
 	}
-
-	seller := auction.Seller
-	if seller != clientID {
-		return fmt.Errorf("auction can only be closed by seller: %v", err)
+	if now.Before(auction.BiddingDeadline) {
+		return fmt.Errorf("cannot close auction before the bidding deadline %v", auction.BiddingDeadline)
 	}
 
 	status := auction.Status
@@ -322,11 +419,12 @@ func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface
 		return fmt.Errorf("failed to close auction: %v", err)
 	}
 
-	return nil
+	return setAuctionEvent(ctx, auctionClosedEvent, auctionID, auction)
 }
 
 // EndAuction both changes the auction status to closed and calculates the winners
-// of the auction
+// of the auction. The seller can end a closed auction at any time, and any
+// participant can end it once the reveal deadline has passed
 func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
 
 	// get the MSP ID of the bidder's org
@@ -341,23 +439,44 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("failed to get auction from public state %v", err)
 	}
 
+	// the auditor only endorses updates to the auctions that name its
+	// organization as an auditor
+	err = verifyPeerOrgIsAuditor(auction)
+	if err != nil {
+		return err
+	}
+
 	// check that the bidders org is a participant in the auction
 	orgs := auction.Orgs
 	if !(contains(orgs, clientOrgID)) {
 		return fmt.Errorf("particiant %s is not a member of the auction", clientOrgID)
 	}
 
-	// Check that the auction is being ended by the seller
-
 	// get ID of submitting client
 	clientID, err := s.GetSubmittingClientIdentity(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get client identity %v", err)
 	}
 
+	// the units of a clock auction are allocated as buyers accept the price,
+	// so ending it only withdraws the units that are left, which only the
+	// seller can do
+	if auction.Clock != nil {
+		if auction.Seller != clientID {
+			return errors.New("clock auction can only be ended by seller")
+		}
+		return endClockAuction(ctx, auctionID, auction)
+	}
+
+	// Check that the auction is being ended by the seller if the reveal
+	// deadline has not passed
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
 	seller := auction.Seller
-	if seller != clientID {
-		return fmt.Errorf("auction can only be ended by seller: %v", err)
+	if now.Before(auction.RevealDeadline) && seller != clientID {
+		return fmt.Errorf("auction can only be ended by seller before the reveal deadline %v", auction.RevealDeadline)
 	}
 
 	status := auction.Status
@@ -365,33 +484,47 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return errors.New("can only end a closed auction")
 	}
 
-	// get the list of revealed bids
+	// get the list of revealed bids. An auction that settles itself can end
+	// without revealed bids, so that the seller gets the assets back
 
 	revealedBidMap := auction.RevealedBids
-	if len(auction.RevealedBids) == 0 {
+	if len(auction.RevealedBids) == 0 && auction.AssetChaincode == "" {
 		return fmt.Errorf("no bids have been revealed, cannot end auction: %v", err)
 	}
 
 	// sort the map of revealed bids to make it easier to calculate winners
-	// if bids are tied, fill smaller bids first
-	var bidders []FullBid
+	// if bids are tied, fill smaller bids first. The bids are visited in the
+	// order of their keys, so that every peer breaks the remaining ties the
+	// same way
+	var bidKeys []string
 
-	for _, bid := range revealedBidMap {
-		bidders = append(bidders, bid)
+	for bidKey := range revealedBidMap {
+		bidKeys = append(bidKeys, bidKey)
 	}
+	sort.Strings(bidKeys)
 
-	sort.Slice(bidders, func(p, q int) bool {
-		if bidders[p].Price > bidders[q].Price {
+	sort.SliceStable(bidKeys, func(p, q int) bool {
+		bidP := revealedBidMap[bidKeys[p]]
+		bidQ := revealedBidMap[bidKeys[q]]
+		if bidP.Price > bidQ.Price {
 			return true
 		}
-		if bidders[p].Price < bidders[q].Price {
+		if bidP.Price < bidQ.Price {
 			return false
 		}
-		return bidders[p].Quantity < bidders[q].Quantity
+		return bidP.Quantity < bidQ.Quantity
 	})
 
+	var bidders []FullBid
+	for _, bidKey := range bidKeys {
+		bidders = append(bidders, revealedBidMap[bidKey])
+	}
+
 	i := 0
 	remainingQuantity := auction.Quantity
+	if len(bidders) == 0 {
+		remainingQuantity = 0
+	}
 
 	// calculate the winners
 	for remainingQuantity > 0 {
@@ -422,10 +555,23 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		}
 	}
 
-	// check if there is a winning bid that has yet to be revealed
-	err = checkForHigherBid(ctx, auction.Price, auction.RevealedBids, auction.PrivateBids)
-	if err != nil {
-		return fmt.Errorf("cannot end auction: %v", err)
+	// check if there is a winning bid that has yet to be revealed. Bids that
+	// were not revealed by the reveal deadline are dropped, so that a bidder
+	// cannot keep the auction from ending by never revealing their bid
+	if now.Before(auction.RevealDeadline) {
+		err = checkForHigherBid(ctx, auction.Price, auction.RevealedBids, auction.PrivateBids)
+		if err != nil {
+			return fmt.Errorf("cannot end auction: %v", err)
+		}
+	}
+
+	// transfer the assets to the winners, and pay the seller from the
+	// payments that the winners locked with their bids
+	if auction.AssetChaincode != "" {
+		err = settleSealedBidAuction(ctx, auction, bidKeys)
+		if err != nil {
+			return err
+		}
 	}
 
 	auction.Status = "ended"
@@ -436,5 +582,6 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 	if err != nil {
 		return fmt.Errorf("failed to end auction: %v", err)
 	}
-	return nil
+
+	return setAuctionEvent(ctx, auctionEndedEvent, auctionID, auction)
 }
@@ -32,6 +32,13 @@ func (s *SmartContract) QueryAuction(ctx contractapi.TransactionContextInterface
 	return auction, nil
 }
 
+// AuctionRecord is an auction together with its ID, as carried by the auction
+// events
+type AuctionRecord struct {
+	AuctionID string   `json:"auctionID"`
+	Auction   *Auction `json:"auction"`
+}
+
 // checkForHigherBid is an internal function that is used to determine if a winning bid has yet to be revealed
 func checkForHigherBid(ctx contractapi.TransactionContextInterface, auctionPrice int, revealedBidders map[string]FullBid, bidders map[string]BidHash) error {
 
@@ -0,0 +1,51 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Clock is the price schedule of a clock auction. The auditor does not run the
+// clock, but needs the schedule to write back the auctions it ends
+type Clock struct {
+	StartPrice  int   `json:"startPrice"`
+	FloorPrice  int   `json:"floorPrice"`
+	Decrement   int   `json:"decrement"`
+	TickSeconds int   `json:"tickSeconds"`
+	StartTime   int64 `json:"startTime"`
+}
+
+// endClockAuction is used by EndAuction to let the seller withdraw the units of
+// a clock auction that have not been sold. If the auction settles itself, the
+// assets of these units are returned to the seller
+func endClockAuction(ctx contractapi.TransactionContextInterface, auctionID string, auction *Auction) error {
+
+	if auction.Status != "open" {
+		return errors.New("can only end an open clock auction")
+	}
+
+	if auction.AssetChaincode != "" {
+		err := refundUnsoldAssets(ctx, auction)
+		if err != nil {
+			return err
+		}
+	}
+
+	auction.Status = "ended"
+
+	endedAuctionJSON, _ := json.Marshal(auction)
+
+	err := ctx.GetStub().PutState(auctionID, endedAuctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to end auction: %v", err)
+	}
+
+	return setAuctionEvent(ctx, auctionEndedEvent, auctionID, auction)
+}
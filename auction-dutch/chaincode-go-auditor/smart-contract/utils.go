@@ -6,7 +6,9 @@ package auction
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
@@ -62,6 +64,54 @@ func verifyClientOrgMatchesPeerOrg(ctx contractapi.TransactionContextInterface)
 	return nil
 }
 
+// getTxTime is an internal function that returns the timestamp of the
+// transaction. The timestamp is set by the client that creates the transaction,
+// so that all endorsing peers use the same time
+func getTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+
+	return timestamp.AsTime(), nil
+}
+
+// verifyPeerOrgIsAuditor is an internal function used to verify that the
+// organization of the auditor peer is one of the auditors named by the auction.
+// Auditing is enforced by the organization of the endorsing peer, not of the
+// caller: the caller is the auction participant that appeals to the auditor,
+// and the endorsement of the auditor peer is what the auditor part of the
+// auction endorsement policy requires
+func verifyPeerOrgIsAuditor(auction *Auction) error {
+	peerMSPID, err := shim.GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed getting the peer's MSPID: %v", err)
+	}
+
+	if !contains(auction.Auditors, peerMSPID) {
+		return fmt.Errorf("organization %s is not an auditor of the auction", peerMSPID)
+	}
+
+	return nil
+}
+
+// setAuctionEvent is an internal function that sets the chaincode event of the
+// transaction, so that clients can follow the auction without polling it. A
+// transaction only emits the last event that it sets
+func setAuctionEvent(ctx contractapi.TransactionContextInterface, eventName string, auctionID string, auction *Auction) error {
+	eventJSON, err := json.Marshal(AuctionRecord{AuctionID: auctionID, Auction: auction})
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetEvent(eventName, eventJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set event %v: %v", eventName, err)
+	}
+
+	return nil
+}
+
 func contains(sli []string, str string) bool {
 	for _, a := range sli {
 		if a == str {
@@ -71,12 +121,19 @@ func contains(sli []string, str string) bool {
 	return false
 }
 
-func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, assetId string, mspids []string, auditor bool) error {
+// setAssetStateBasedEndorsement sets the endorsement policy of the auction. All
+// participating organizations can update the auction together. If the auction
+// names auditors, the threshold of auditors and one participant can also update
+// the auction
+func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, assetId string, mspids []string, auditors []string, auditorThreshold int) error {
 
-	principals := make([]*msp.MSPPrincipal, len(mspids))
-	participantSigsPolicy := make([]*common.SignaturePolicy, len(mspids))
+	principals := make([]*msp.MSPPrincipal, 0, len(mspids)+len(auditors))
+	participantSigsPolicy := make([]*common.SignaturePolicy, 0, len(mspids))
+	auditorSigsPolicy := make([]*common.SignaturePolicy, 0, len(auditors))
 
-	for i, id := range mspids {
+	// the participants are listed first in the identities of the policy,
+	// followed by the auditors
+	for _, id := range append(append([]string{}, mspids...), auditors...) {
 		principal, err := proto.Marshal(
 			&msp.MSPRole{
 				Role:          msp.MSPRole_PEER,
@@ -86,66 +143,43 @@ func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface,
 		if err != nil {
 			return err
 		}
-		principals[i] = &msp.MSPPrincipal{
-			PrincipalClassification: msp.MSPPrincipal_ROLE,
-			Principal:               principal,
-		}
-		participantSigsPolicy[i] = &common.SignaturePolicy{
+		signedBy := &common.SignaturePolicy{
 			Type: &common.SignaturePolicy_SignedBy{
-				SignedBy: int32(i),
+				SignedBy: int32(len(principals)),
 			},
 		}
+		principals = append(principals, &msp.MSPPrincipal{
+			PrincipalClassification: msp.MSPPrincipal_ROLE,
+			Principal:               principal,
+		})
+		if len(participantSigsPolicy) < len(mspids) {
+			participantSigsPolicy = append(participantSigsPolicy, signedBy)
+		} else {
+			auditorSigsPolicy = append(auditorSigsPolicy, signedBy)
+		}
 	}
 
-	if auditor == false {
-		// create the defalt policy for an auction without an auditor
-
-		policy := &common.SignaturePolicyEnvelope{
-			Version: 0,
-			Rule: &common.SignaturePolicy{
-				Type: &common.SignaturePolicy_NOutOf_{
-					NOutOf: &common.SignaturePolicy_NOutOf{
-						N:     int32(len(mspids)),
-						Rules: participantSigsPolicy,
-					},
-				},
+	// create the default policy, which all participants need to endorse
+	rule := &common.SignaturePolicy{
+		Type: &common.SignaturePolicy_NOutOf_{
+			NOutOf: &common.SignaturePolicy_NOutOf{
+				N:     int32(len(mspids)),
+				Rules: participantSigsPolicy,
 			},
-			Identities: principals,
-		}
-
-		spBytes, err := proto.Marshal(policy)
-		if err != nil {
-			return err
-		}
-		err = ctx.GetStub().SetStateValidationParameter(assetId, spBytes)
-		if err != nil {
-			return fmt.Errorf("failed to set validation parameter on auction: %v", err)
-		}
-	} else {
+		},
+	}
 
-		// create the defalt policy for an auction with an auditor
+	if len(auditors) > 0 {
 
-		// create the auditor identity and signature policy
-		auditorMSP, err := proto.Marshal(
-			&msp.MSPRole{
-				Role:          msp.MSPRole_PEER,
-				MspIdentifier: "Org3MSP",
-			},
-		)
-		if err != nil {
-			return err
-		}
-		principals = append(principals, &msp.MSPPrincipal{
-			PrincipalClassification: msp.MSPPrincipal_ROLE,
-			Principal:               auditorMSP,
-		},
-		)
-		// Create the policies in case the auditor is needed. In this case, an
-		// auditor and 1 participant can update the auction.
+		// Create the policies in case the auditors are needed. In this case,
+		// the threshold of auditors and 1 participant can update the auction
 		auditorPolicies := make([]*common.SignaturePolicy, 2)
 		auditorPolicies[0] = &common.SignaturePolicy{
-			Type: &common.SignaturePolicy_SignedBy{
-				SignedBy: int32(len(principals) - 1),
+			Type: &common.SignaturePolicy_NOutOf_{
+				NOutOf: &common.SignaturePolicy_NOutOf{
+					N:     int32(auditorThreshold),
+					Rules: auditorSigsPolicy,
+				},
 			},
 		}
 		auditorPolicies[1] = &common.SignaturePolicy{
@@ -157,47 +191,44 @@ func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface,
 			},
 		}
 
-		// The auditor policy below is equivilent to AND(auditor, OR(participants))
-		policies := make([]*common.SignaturePolicy, 2)
-		policies[0] = &common.SignaturePolicy{
-			Type: &common.SignaturePolicy_NOutOf_{
-				NOutOf: &common.SignaturePolicy_NOutOf{
-					N:     2,
-					Rules: auditorPolicies,
-				},
-			},
-		}
-		// Participants can also update the auction without an auditor
-		policies[1] = &common.SignaturePolicy{
+		// Either the auditor policy or the participant policy can update
+		// the auction. For example, for two organizations and a single
+		// auditor, the full policy would be equivilent to
+		// OR(AND(Org1, Org2), AND(auditor, OR(Org1, Org2)))
+		rule = &common.SignaturePolicy{
 			Type: &common.SignaturePolicy_NOutOf_{
 				NOutOf: &common.SignaturePolicy_NOutOf{
-					N:     int32(len(mspids)),
-					Rules: participantSigsPolicy,
-				},
-			},
-		}
-		// Either the auditor policy or the participant policy can update
-		// the auction
-		policy := &common.SignaturePolicyEnvelope{
-			Version: 0,
-			Rule: &common.SignaturePolicy{
-				Type: &common.SignaturePolicy_NOutOf_{
-					NOutOf: &common.SignaturePolicy_NOutOf{
-						N:     1,
-						Rules: policies,
+					N: 1,
+					Rules: []*common.SignaturePolicy{
+						rule,
+						{
+							Type: &common.SignaturePolicy_NOutOf_{
+								NOutOf: &common.SignaturePolicy_NOutOf{
+									N:     2,
+									Rules: auditorPolicies,
+								},
+							},
+						},
 					},
 				},
 			},
-			Identities: principals,
-		}
-		spBytes, err := proto.Marshal(policy)
-		if err != nil {
-			return err
-		}
-		err = ctx.GetStub().SetStateValidationParameter(assetId, spBytes)
-		if err != nil {
-			return fmt.Errorf("failed to set validation parameter on auction: %v", err)
 		}
 	}
+
+	policy := &common.SignaturePolicyEnvelope{
+		Version:    0,
+		Rule:       rule,
+		Identities: principals,
+	}
+
+	spBytes, err := proto.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().SetStateValidationParameter(assetId, spBytes)
+	if err != nil {
+		return fmt.Errorf("failed to set validation parameter on auction: %v", err)
+	}
+
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
@@ -30,7 +31,21 @@ type Auction struct {
 	Winners      []Winners          `json:"winners"`
 	Price        int                `json:"price"`
 	Status       string             `json:"status"`
-	Auditor      bool               `json:"auditor"`
+	Clock        *Clock             `json:"clock,omitempty" metadata:",optional"`
+	// Auditors are the organizations that can update the auction together
+	// with one participant, if AuditorThreshold of them endorse the update
+	Auditors         []string `json:"auditors"`
+	AuditorThreshold int      `json:"auditorThreshold"`
+	// BiddingDeadline ends the bidding period, and RevealDeadline ends the
+	// period in which bids can be revealed. Clock auctions have no deadlines
+	BiddingDeadline time.Time `json:"biddingDeadline"`
+	RevealDeadline  time.Time `json:"revealDeadline"`
+	// AssetChaincode holds the units of the item in escrow, one of the Assets
+	// for each unit, and buyers pay with the tokens of TokenChaincode. They are
+	// empty if the auction does not settle itself
+	AssetChaincode string   `json:"assetChaincode"`
+	Assets         []string `json:"assets"`
+	TokenChaincode string   `json:"tokenChaincode"`
 }
 
 // FullBid is the structure of a revealed bid
@@ -48,17 +63,58 @@ type BidHash struct {
 	Hash string `json:"hash"`
 }
 
-// Winners stores the winners of the auction
+// Winners stores the winners of the auction. In a clock auction, each winner
+// also records the price at which they accepted their units. If the auction
+// settles itself, each winner records the assets they received
 type Winners struct {
-	Buyer    string `json:"buyer"`
-	Quantity int    `json:"quantity"`
+	Buyer    string   `json:"buyer"`
+	Quantity int      `json:"quantity"`
+	Price    int      `json:"price,omitempty" metadata:",optional"`
+	Assets   []string `json:"assets,omitempty" metadata:",optional"`
 }
 
 const bidKeyType = "bid"
 
+// Names of the chaincode events that clients can subscribe to. Each event
+// carries an AuctionRecord with the auction after the transaction
+const (
+	auctionCreatedEvent = "AuctionCreated"
+	auctionClosedEvent  = "AuctionClosed"
+	auctionEndedEvent   = "AuctionEnded"
+)
+
 // CreateAuction creates on auction on the public channel. The identity that
-// submits the transacion becomes the seller of the auction
-func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, quantity int, withAuditor string) error {
+// submits the transacion becomes the seller of the auction. Bids can be added
+// until the bidding deadline, and revealed until the reveal deadline, which are
+// RFC 3339 timestamps such as 2024-06-01T12:00:00Z. To have the auction settle
+// itself, the seller locks one of the assets of the asset chaincode for each
+// unit, and names the token chaincode that buyers pay with. The seller can name
+// the MSP IDs of auditor organizations, auditorThreshold of which can update
+// the auction together with one participant
+func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, quantity int, biddingDeadline string, revealDeadline string, assetChaincode string, assets []string, tokenChaincode string, auditors []string, auditorThreshold int) error {
+
+	err := checkSettlement(quantity, assetChaincode, assets, tokenChaincode)
+	if err != nil {
+		return err
+	}
+
+	existingAuctionJSON, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to read auction %v: %v", auctionID, err)
+	}
+	if existingAuctionJSON != nil {
+		return fmt.Errorf("auction %v already exists", auctionID)
+	}
+
+	// the deadlines are checked against the timestamp of the transaction
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	biddingTime, revealTime, err := parseDeadlines(now, biddingDeadline, revealDeadline)
+	if err != nil {
+		return err
+	}
 
 	// get ID of submitting client
 	clientID, err := s.GetSubmittingClientIdentity(ctx)
@@ -72,10 +128,9 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 		return fmt.Errorf("failed to get client identity %v", err)
 	}
 
-	auditor := false
-
-	if withAuditor == "withAuditor" {
-		auditor = true
+	err = checkAuditors(auditors, auditorThreshold, clientOrgID)
+	if err != nil {
+		return err
 	}
 
 	// Create auction
@@ -83,17 +138,30 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 	revealedBids := make(map[string]FullBid)
 
 	auction := Auction{
-		Type:         "auction",
-		ItemSold:     itemsold,
-		Quantity:     quantity,
-		Price:        0,
-		Seller:       clientID,
-		Orgs:         []string{clientOrgID},
-		PrivateBids:  bidders,
-		RevealedBids: revealedBids,
-		Winners:      []Winners{},
-		Status:       "open",
-		Auditor:      auditor,
+		Type:             "auction",
+		ItemSold:         itemsold,
+		Quantity:         quantity,
+		Price:            0,
+		Seller:           clientID,
+		Orgs:             []string{clientOrgID},
+		PrivateBids:      bidders,
+		RevealedBids:     revealedBids,
+		Winners:          []Winners{},
+		Status:           "open",
+		Auditors:         auditors,
+		AuditorThreshold: auditorThreshold,
+		BiddingDeadline:  biddingTime,
+		RevealDeadline:   revealTime,
+		AssetChaincode:   assetChaincode,
+		Assets:           assets,
+		TokenChaincode:   tokenChaincode,
+	}
+
+	if assetChaincode != "" {
+		err = lockAssets(ctx, &auction)
+		if err != nil {
+			return err
+		}
 	}
 
 	auctionJSON, err := json.Marshal(auction)
@@ -108,12 +176,12 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 	}
 
 	// set the seller of the auction as an endorser
-	err = setAssetStateBasedEndorsement(ctx, auctionID, []string{clientOrgID}, auditor)
+	err = setAssetStateBasedEndorsement(ctx, auctionID, []string{clientOrgID}, auditors, auditorThreshold)
 	if err != nil {
 		return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
 	}
 
-	return nil
+	return setAuctionEvent(ctx, auctionCreatedEvent, auctionID, &auction)
 }
 
 // Bid is used to add a users bid to the auction. The bid is stored in the private
@@ -180,12 +248,32 @@ func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("failed to get auction from public state %v", err)
 	}
 
+	// a clock auction is won by accepting its price, not by bidding
+	if auction.Clock != nil {
+		return fmt.Errorf("cannot add a sealed bid to a clock auction")
+	}
+
+	// the auditors of the auction cannot bid in it
+	if contains(auction.Auditors, clientOrgID) {
+		return fmt.Errorf("auditor %s cannot join the auction", clientOrgID)
+	}
+
 	// the auction needs to be open for users to add their bid
 	status := auction.Status
 	if status != "open" {
 		return fmt.Errorf("cannot join closed or ended auction")
 	}
 
+	// bids can only be added until the bidding deadline, even if the
+	// auction has not been closed yet
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !now.Before(auction.BiddingDeadline) {
+		return fmt.Errorf("cannot join auction after the bidding deadline %v", auction.BiddingDeadline)
+	}
+
 	// get the inplicit collection name of bidder's org
 	collection, err := getCollectionName(ctx)
 	if err != nil {
@@ -221,7 +309,7 @@ func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, a
 		newOrgs := append(orgs, clientOrgID)
 		auction.Orgs = newOrgs
 
-		err = setAssetStateBasedEndorsement(ctx, auctionID, newOrgs, auction.Auditor)
+		err = setAssetStateBasedEndorsement(ctx, auctionID, newOrgs, auction.Auditors, auction.AuditorThreshold)
 		if err != nil {
 			return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
 		}
@@ -280,12 +368,23 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 
 	// Complete a series of three checks before we add the bid to the auction
 
-	// check 1: check that the auction is closed. We cannot reveal an
-	// bid to an open auction
+	if auction.Clock != nil {
+		return fmt.Errorf("cannot reveal a sealed bid to a clock auction")
+	}
+
+	// check 1: check that the auction is closed and the reveal deadline has
+	// not passed. We cannot reveal an bid to an open auction
 	status := auction.Status
 	if status != "closed" {
 		return fmt.Errorf("cannot reveal bid for open or ended auction")
 	}
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !now.Before(auction.RevealDeadline) {
+		return fmt.Errorf("cannot reveal bid after the reveal deadline %v", auction.RevealDeadline)
+	}
 
 	// check 2: check that hash of revealed bid matches hash of private bid
 	// on the public ledger. This checks that the bidder is telling the truth
@@ -334,6 +433,14 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("failed to unmarshal JSON: %v", err)
 	}
 
+	// a bid buys at least one unit, at a price that cannot be negative
+	if bidInput.Quantity < 1 {
+		return fmt.Errorf("bid quantity must be at least 1, not %d", bidInput.Quantity)
+	}
+	if bidInput.Price < 0 {
+		return fmt.Errorf("bid price cannot be negative: %d", bidInput.Price)
+	}
+
 	// get ID of submitting client
 	clientID, err := s.GetSubmittingClientIdentity(ctx)
 	if err != nil {
@@ -354,6 +461,14 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("permission denied, client id %v is not the owner of the bid", clientID)
 	}
 
+	// the bidder locks the price of their bid when the auction settles itself
+	if auction.TokenChaincode != "" {
+		err = lockPayment(ctx, auction, txID, newBid)
+		if err != nil {
+			return err
+		}
+	}
+
 	revealedBids := auction.RevealedBids
 	revealedBids[bidKey] = newBid
 	auction.RevealedBids = revealedBids
@@ -369,8 +484,9 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 	return nil
 }
 
-// CloseAuction can be used by the seller to close the auction. This prevents
-// bids from being added to the auction, and allows users to reveal their bid
+// CloseAuction can be used by any participant to close the auction once the
+// bidding deadline has passed. This prevents bids from being added to the
+// auction, and allows users to reveal their bid
 func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
 
 	// get auction from public state
@@ -379,17 +495,24 @@ func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface
 		return fmt.Errorf("failed to get auction from public state %v", err)
 	}
 
-	// the auction can only be closed by the seller
+	// a clock auction has no bidding phase to close
+	if auction.Clock != nil {
+		return fmt.Errorf("cannot close a clock auction, end it to withdraw the units that are not sold")
+	}
 
-	// get ID of submitting client
-	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	// the auction can be closed by any participant, but not before the
+	// bidding deadline, so that the seller cannot cut the bidding short
+	err = verifyClientOrgIsParticipant(ctx, auction.Orgs)
 	if err != nil {
-		return fmt.Errorf("failed to get client identity %v", err)
+		return err
 	}
 
-	seller := auction.Seller
-	if seller != clientID {
-		return fmt.Errorf("auction can only be closed by seller: %v", err)
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if now.Before(auction.BiddingDeadline) {
+		return fmt.Errorf("cannot close auction before the bidding deadline %v", auction.BiddingDeadline)
 	}
 
 	status := auction.Status
@@ -406,11 +529,12 @@ func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface
 		return fmt.Errorf("failed to close auction: %v", err)
 	}
 
-	return nil
+	return setAuctionEvent(ctx, auctionClosedEvent, auctionID, auction)
 }
 
 // EndAuction both changes the auction status to closed and calculates the winners
-// of the auction
+// of the auction. The seller can end a closed auction at any time, and any
+// participant can end it once the reveal deadline has passed
 func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
 
 	// get auction from public state
@@ -419,7 +543,10 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("failed to get auction from public state %v", err)
 	}
 
-	// Check that the auction is being ended by the seller
+	err = verifyClientOrgIsParticipant(ctx, auction.Orgs)
+	if err != nil {
+		return err
+	}
 
 	// get ID of submitting client
 	clientID, err := s.GetSubmittingClientIdentity(ctx)
@@ -427,9 +554,25 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("failed to get client identity %v", err)
 	}
 
+	// the units of a clock auction are allocated as buyers accept the price,
+	// so ending it only withdraws the units that are left, which only the
+	// seller can do
+	if auction.Clock != nil {
+		if auction.Seller != clientID {
+			return fmt.Errorf("clock auction can only be ended by seller")
+		}
+		return endClockAuction(ctx, auctionID, auction)
+	}
+
+	// Check that the auction is being ended by the seller if the reveal
+	// deadline has not passed
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
 	seller := auction.Seller
-	if seller != clientID {
-		return fmt.Errorf("auction can only be ended by seller: %v", err)
+	if now.Before(auction.RevealDeadline) && seller != clientID {
+		return fmt.Errorf("auction can only be ended by seller before the reveal deadline %v", auction.RevealDeadline)
 	}
 
 	status := auction.Status
@@ -437,35 +580,86 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("can only end a closed auction")
 	}
 
-	// get the list of revealed bids
-
-	revealedBidMap := auction.RevealedBids
-	if len(auction.RevealedBids) == 0 {
+	// check the list of revealed bids. An auction that settles itself can end
+	// without revealed bids, so that the seller gets the assets back
+	if len(auction.RevealedBids) == 0 && auction.AssetChaincode == "" {
 		return fmt.Errorf("no bids have been revealed, cannot end auction: %v", err)
 	}
 
+	// calculate the winners and the clearing price
+	bidKeys := allocateUnits(auction)
+
+	// check if there is a winning bid that has yet to be revealed. Bids that
+	// were not revealed by the reveal deadline are dropped, so that a bidder
+	// cannot keep the auction from ending by never revealing their bid
+	if now.Before(auction.RevealDeadline) {
+		err = checkForHigherBid(ctx, auction.Price, auction.RevealedBids, auction.PrivateBids)
+		if err != nil {
+			return fmt.Errorf("cannot end auction: %v", err)
+		}
+	}
+
+	// transfer the assets to the winners, and pay the seller from the
+	// payments that the winners locked with their bids
+	if auction.AssetChaincode != "" {
+		err = settleSealedBidAuction(ctx, auction, bidKeys)
+		if err != nil {
+			return err
+		}
+	}
+
+	auction.Status = "ended"
+
+	endedAuctionJSON, _ := json.Marshal(auction)
+
+	err = ctx.GetStub().PutState(auctionID, endedAuctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to end auction: %v", err)
+	}
+
+	return setAuctionEvent(ctx, auctionEndedEvent, auctionID, auction)
+}
+
+// allocateUnits is an internal function that fills the revealed bids of a
+// sealed bid auction, highest price first, until the units of the auction run
+// out. It sets the winners and the clearing price of the auction, and returns
+// the keys of the revealed bids in the order in which they were filled
+func allocateUnits(auction *Auction) []string {
+
 	// sort the map of revealed bids to make it easier to calculate winners
-	// if bids are tied, fill smaller bids first
-	var bidders []FullBid
+	// if bids are tied, fill smaller bids first. The bids are visited in the
+	// order of their keys, so that every peer breaks the remaining ties the
+	// same way
+	var bidKeys []string
 
-	for _, bid := range revealedBidMap {
-		bidders = append(bidders, bid)
+	for bidKey := range auction.RevealedBids {
+		bidKeys = append(bidKeys, bidKey)
 	}
+	sort.Strings(bidKeys)
 
-	sort.Slice(bidders, func(p, q int) bool {
-		if bidders[p].Price > bidders[q].Price {
+	sort.SliceStable(bidKeys, func(p, q int) bool {
+		bidP := auction.RevealedBids[bidKeys[p]]
+		bidQ := auction.RevealedBids[bidKeys[q]]
+		if bidP.Price > bidQ.Price {
 			return true
 		}
-		if bidders[p].Price < bidders[q].Price {
+		if bidP.Price < bidQ.Price {
 			return false
 		}
-		return bidders[p].Quantity < bidders[q].Quantity
+		return bidP.Quantity < bidQ.Quantity
 	})
 
+	var bidders []FullBid
+	for _, bidKey := range bidKeys {
+		bidders = append(bidders, auction.RevealedBids[bidKey])
+	}
+
 	i := 0
 	remainingQuantity := auction.Quantity
+	if len(bidders) == 0 {
+		remainingQuantity = 0
+	}
 
-	// calculate the winners
 	for remainingQuantity > 0 {
 
 		// create the next winning bid
@@ -494,19 +688,5 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		}
 	}
 
-	// check if there is a winning bid that has yet to be revealed
-	err = checkForHigherBid(ctx, auction.Price, auction.RevealedBids, auction.PrivateBids)
-	if err != nil {
-		return fmt.Errorf("cannot end auction: %v", err)
-	}
-
-	auction.Status = "ended"
-
-	endedAuctionJSON, _ := json.Marshal(auction)
-
-	err = ctx.GetStub().PutState(auctionID, endedAuctionJSON)
-	if err != nil {
-		return fmt.Errorf("failed to end auction: %v", err)
-	}
-	return nil
+	return bidKeys
 }
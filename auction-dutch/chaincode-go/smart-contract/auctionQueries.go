@@ -7,6 +7,7 @@ package auction
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
@@ -77,6 +78,167 @@ func (s *SmartContract) QueryBid(ctx contractapi.TransactionContextInterface, au
 	return bid, nil
 }
 
+// AuctionRecord is an auction together with its ID, as returned by the
+// auction lists and carried by the auction events
+type AuctionRecord struct {
+	AuctionID string   `json:"auctionID"`
+	Auction   *Auction `json:"auction"`
+}
+
+// AuctionPage is one page of auctions. Pass the bookmark to ListAuctions to
+// read the next page; an empty bookmark means every auction has been read
+type AuctionPage struct {
+	Records             []*AuctionRecord `json:"records"`
+	FetchedRecordsCount int32            `json:"fetchedRecordsCount"`
+	Bookmark            string           `json:"bookmark"`
+}
+
+// BidRecord is a bid in the private data of the bidder's organization,
+// together with the auction and transaction that identify it
+type BidRecord struct {
+	AuctionID string   `json:"auctionID"`
+	TxID      string   `json:"txID"`
+	Bid       *FullBid `json:"bid"`
+}
+
+// ListAuctions allows all members of the channel to page through the public
+// auctions. The page holds at most pageSize auctions, starting at the bookmark
+// of the previous page. Only auctions with the given status (open, closed or
+// ended) whose item contains itemFilter are listed; leave either empty to not
+// filter on it. Paginated queries are only valid for read only transactions
+func (s *SmartContract) ListAuctions(ctx contractapi.TransactionContextInterface, status string, itemFilter string, pageSize int, bookmark string) (*AuctionPage, error) {
+
+	if pageSize < 1 {
+		return nil, fmt.Errorf("the page size must be at least 1")
+	}
+	if status != "" && status != "open" && status != "closed" && status != "ended" {
+		return nil, fmt.Errorf("unknown auction status %v, use open, closed or ended", status)
+	}
+
+	matches := func(auction *Auction) bool {
+		return (status == "" || auction.Status == status) && strings.Contains(auction.ItemSold, itemFilter)
+	}
+
+	// auctions the filter skips still count against the page size of a range
+	// query, so read on until the page is full or the range is exhausted
+	page := &AuctionPage{Records: []*AuctionRecord{}, Bookmark: bookmark}
+	for {
+		resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", int32(pageSize-len(page.Records)), page.Bookmark)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read auctions: %v", err)
+		}
+		records, err := matchingAuctions(resultsIterator, matches)
+		if err != nil {
+			return nil, err
+		}
+		page.Records = append(page.Records, records...)
+		page.Bookmark = responseMetadata.Bookmark
+		if len(page.Records) == pageSize || page.Bookmark == "" {
+			break
+		}
+	}
+	page.FetchedRecordsCount = int32(len(page.Records))
+
+	return page, nil
+}
+
+// MyAuctions returns the auctions that the submitting client sells
+func (s *SmartContract) MyAuctions(ctx contractapi.TransactionContextInterface) ([]*AuctionRecord, error) {
+
+	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auctions: %v", err)
+	}
+
+	return matchingAuctions(resultsIterator, func(auction *Auction) bool {
+		return auction.Seller == clientID
+	})
+}
+
+// MyBids returns the bids of the submitting client, which are read from the
+// implicit collection of the client's organization. The client needs to
+// target a peer of their own organization
+func (s *SmartContract) MyBids(ctx contractapi.TransactionContextInterface) ([]*BidRecord, error) {
+
+	err := verifyClientOrgMatchesPeerOrg(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get implicit collection name: %v", err)
+	}
+
+	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	collection, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get implicit collection name: %v", err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(collection, bidKeyType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bids: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	bids := []*BidRecord{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var bid *FullBid
+		err = json.Unmarshal(queryResponse.Value, &bid)
+		if err != nil {
+			return nil, err
+		}
+
+		// the collection holds the bids of every client of the organization
+		if bid.Buyer != clientID {
+			continue
+		}
+
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split composite key: %v", err)
+		}
+		bids = append(bids, &BidRecord{AuctionID: keyParts[0], TxID: keyParts[1], Bid: bid})
+	}
+
+	return bids, nil
+}
+
+// matchingAuctions is an internal function that reads the auctions of a range
+// query and returns those that match
+func matchingAuctions(resultsIterator shim.StateQueryIteratorInterface, matches func(*Auction) bool) ([]*AuctionRecord, error) {
+	defer resultsIterator.Close()
+
+	records := []*AuctionRecord{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var auction *Auction
+		err = json.Unmarshal(queryResponse.Value, &auction)
+		if err != nil {
+			return nil, err
+		}
+		if auction.Type == "auction" && matches(auction) {
+			records = append(records, &AuctionRecord{AuctionID: queryResponse.Key, Auction: auction})
+		}
+	}
+
+	return records, nil
+}
+
 // checkForHigherBid is an internal function that is used to determine if a winning bid has yet to be revealed
 func checkForHigherBid(ctx contractapi.TransactionContextInterface, auctionPrice int, revealedBidders map[string]FullBid, bidders map[string]BidHash) error {
 
@@ -0,0 +1,83 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocateUnits(t *testing.T) {
+	bid := func(buyer string, quantity int, price int) FullBid {
+		return FullBid{Type: bidKeyType, Quantity: quantity, Price: price, Buyer: buyer}
+	}
+
+	tests := []struct {
+		name     string
+		quantity int
+		bids     map[string]FullBid
+		bidKeys  []string
+		winners  []Winners
+		price    int
+	}{
+		{
+			name:     "highest price first",
+			quantity: 5,
+			bids:     map[string]FullBid{"bid1": bid("a", 3, 50), "bid2": bid("b", 3, 70), "bid3": bid("c", 3, 60)},
+			bidKeys:  []string{"bid2", "bid3", "bid1"},
+			winners:  []Winners{{Buyer: "b", Quantity: 3}, {Buyer: "c", Quantity: 2}},
+			price:    60,
+		},
+		{
+			name:     "tied price fills smaller bid first",
+			quantity: 4,
+			bids:     map[string]FullBid{"bid1": bid("a", 3, 60), "bid2": bid("b", 2, 60)},
+			bidKeys:  []string{"bid2", "bid1"},
+			winners:  []Winners{{Buyer: "b", Quantity: 2}, {Buyer: "a", Quantity: 2}},
+			price:    60,
+		},
+		{
+			name:     "tied price and quantity fills by bid key",
+			quantity: 3,
+			bids:     map[string]FullBid{"bid3": bid("c", 2, 60), "bid1": bid("a", 2, 60), "bid2": bid("b", 2, 60)},
+			bidKeys:  []string{"bid1", "bid2", "bid3"},
+			winners:  []Winners{{Buyer: "a", Quantity: 2}, {Buyer: "b", Quantity: 1}},
+			price:    60,
+		},
+		{
+			name:     "bids short of the quantity",
+			quantity: 10,
+			bids:     map[string]FullBid{"bid1": bid("a", 3, 50), "bid2": bid("b", 4, 70)},
+			bidKeys:  []string{"bid2", "bid1"},
+			winners:  []Winners{{Buyer: "b", Quantity: 4}, {Buyer: "a", Quantity: 3}},
+			price:    50,
+		},
+		{
+			name:     "bid fills the quantity exactly",
+			quantity: 3,
+			bids:     map[string]FullBid{"bid1": bid("a", 3, 50), "bid2": bid("b", 3, 40)},
+			bidKeys:  []string{"bid1", "bid2"},
+			winners:  []Winners{{Buyer: "a", Quantity: 3}},
+			price:    50,
+		},
+		{
+			name:     "no bids",
+			quantity: 3,
+			bids:     map[string]FullBid{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auction := &Auction{Quantity: tt.quantity, RevealedBids: tt.bids}
+
+			bidKeys := allocateUnits(auction)
+			require.Equal(t, tt.bidKeys, bidKeys)
+			require.Equal(t, tt.winners, auction.Winners)
+			require.Equal(t, tt.price, auction.Price)
+		})
+	}
+}
@@ -0,0 +1,324 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Clock is the price schedule of a clock auction. The price starts at
+// StartPrice when the auction is created and falls by Decrement every
+// TickSeconds until it reaches FloorPrice
+type Clock struct {
+	StartPrice  int   `json:"startPrice"`
+	FloorPrice  int   `json:"floorPrice"`
+	Decrement   int   `json:"decrement"`
+	TickSeconds int   `json:"tickSeconds"`
+	StartTime   int64 `json:"startTime"`
+}
+
+// CreateClockAuction creates a descending price auction on the public channel.
+// Instead of collecting sealed bids, the price of the auction falls on a clock
+// and buyers claim units with AcceptPrice. The clock runs on the timestamps of
+// the transactions, starting with the timestamp of this one. The identity that
+// submits the transaction becomes the seller of the auction. The seller can lock
+// the units in escrow to have the auction settle itself, as with CreateAuction.
+// A clock auction has no auditors: the auditor chaincode does not implement
+// AcceptPrice, so an auditor could never endorse a purchase
+func (s *SmartContract) CreateClockAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, quantity int, startPrice int, floorPrice int, decrement int, tickSeconds int, assetChaincode string, assets []string, tokenChaincode string) error {
+
+	if quantity < 1 {
+		return fmt.Errorf("the quantity must be at least 1")
+	}
+	if floorPrice < 0 || startPrice < floorPrice {
+		return fmt.Errorf("the start price %d must not be below the floor price %d, which must not be negative", startPrice, floorPrice)
+	}
+	if decrement < 1 || tickSeconds < 1 {
+		return fmt.Errorf("the decrement and the tick interval must be at least 1")
+	}
+
+	err := checkSettlement(quantity, assetChaincode, assets, tokenChaincode)
+	if err != nil {
+		return err
+	}
+
+	existingAuctionJSON, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to read auction %v: %v", auctionID, err)
+	}
+	if existingAuctionJSON != nil {
+		return fmt.Errorf("auction %v already exists", auctionID)
+	}
+
+	// get ID of submitting client
+	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	// get org of submitting client
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	// the clock starts at the timestamp of the transaction that creates the auction
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	auction := Auction{
+		Type:         "auction",
+		ItemSold:     itemsold,
+		Quantity:     quantity,
+		Price:        startPrice,
+		Seller:       clientID,
+		Orgs:         []string{clientOrgID},
+		PrivateBids:  make(map[string]BidHash),
+		RevealedBids: make(map[string]FullBid),
+		Winners:      []Winners{},
+		Status:       "open",
+		Auditors:     []string{},
+		Clock: &Clock{
+			StartPrice:  startPrice,
+			FloorPrice:  floorPrice,
+			Decrement:   decrement,
+			TickSeconds: tickSeconds,
+			StartTime:   now.Unix(),
+		},
+		AssetChaincode: assetChaincode,
+		Assets:         assets,
+		TokenChaincode: tokenChaincode,
+	}
+
+	if assetChaincode != "" {
+		err = lockAssets(ctx, &auction)
+		if err != nil {
+			return err
+		}
+	}
+
+	auctionJSON, err := json.Marshal(auction)
+	if err != nil {
+		return err
+	}
+
+	// put auction into state
+	err = ctx.GetStub().PutState(auctionID, auctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put auction in public data: %v", err)
+	}
+
+	// set the seller of the auction as an endorser
+	err = setAssetStateBasedEndorsement(ctx, auctionID, []string{clientOrgID}, nil, 0)
+	if err != nil {
+		return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
+	}
+
+	return setAuctionEvent(ctx, auctionCreatedEvent, auctionID, &auction)
+}
+
+// AcceptPrice is used by a buyer to claim units of a clock auction at the
+// current clock price. The buyer passes the price they accept, which has to be
+// the clock price at the timestamp of the transaction, so that a buyer never
+// pays a price they have not seen. The timestamp is set by the buyer, and
+// cannot be ahead of the clock of an endorsing peer by more than maxClockSkew The buyer receives the quantity they ask for,
+// or the units that are left if there are fewer. The auction ends once all
+// units are sold. If the auction settles itself, the buyer pays the seller and
+// receives their assets in the same transaction
+func (s *SmartContract) AcceptPrice(ctx contractapi.TransactionContextInterface, auctionID string, quantity int, price int) error {
+
+	if quantity < 1 {
+		return fmt.Errorf("the quantity must be at least 1")
+	}
+
+	// get auction from public state
+	auction, err := s.QueryAuction(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to get auction from public state %v", err)
+	}
+
+	if auction.Clock == nil {
+		return fmt.Errorf("auction %v is a sealed bid auction, submit a bid instead", auctionID)
+	}
+	if auction.Status != "open" {
+		return fmt.Errorf("cannot accept the price of a closed or ended auction")
+	}
+
+	// a buyer could date their transaction ahead to buy at a lower price, so
+	// the endorsing peers check the timestamp against their own clocks
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	err = checkClockSkew(now, peerTime())
+	if err != nil {
+		return err
+	}
+	clockPrice := auction.Clock.priceAt(now.Unix())
+	if price != clockPrice {
+		return fmt.Errorf("the clock price of auction %v is %d, not %d", auctionID, clockPrice, price)
+	}
+
+	// get ID and org of submitting client
+	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+
+	remainingQuantity := auction.Quantity
+	for _, winner := range auction.Winners {
+		remainingQuantity -= winner.Quantity
+	}
+	if quantity > remainingQuantity {
+		quantity = remainingQuantity
+	}
+
+	winner := Winners{
+		Buyer:    clientID,
+		Quantity: quantity,
+		Price:    clockPrice,
+	}
+
+	if auction.AssetChaincode != "" {
+		err = settleClockPurchase(ctx, auction, &winner)
+		if err != nil {
+			return err
+		}
+	}
+
+	auction.Winners = append(auction.Winners, winner)
+	auction.Price = clockPrice
+	if quantity == remainingQuantity {
+		auction.Status = "ended"
+	}
+
+	// Add the buyer's organization to the list of participating organization's if it is not already
+	if !(contains(auction.Orgs, clientOrgID)) {
+		auction.Orgs = append(auction.Orgs, clientOrgID)
+
+		err = setAssetStateBasedEndorsement(ctx, auctionID, auction.Orgs, auction.Auditors, auction.AuditorThreshold)
+		if err != nil {
+			return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
+		}
+	}
+
+	auctionJSON, _ := json.Marshal(auction)
+
+	err = ctx.GetStub().PutState(auctionID, auctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to update auction: %v", err)
+	}
+
+	// the auction ends once every unit is sold
+	if auction.Status == "ended" {
+		return setAuctionEvent(ctx, auctionEndedEvent, auctionID, auction)
+	}
+
+	return nil
+}
+
+// QueryClockPrice returns the price of a clock auction at the timestamp of the
+// query
+func (s *SmartContract) QueryClockPrice(ctx contractapi.TransactionContextInterface, auctionID string) (int, error) {
+
+	auction, err := s.QueryAuction(ctx, auctionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get auction from public state %v", err)
+	}
+	if auction.Clock == nil {
+		return 0, fmt.Errorf("auction %v is a sealed bid auction and has no clock price", auctionID)
+	}
+	if auction.Status != "open" {
+		return auction.Price, nil
+	}
+
+	return currentClockPrice(ctx, auction.Clock)
+}
+
+// endClockAuction is used by EndAuction to let the seller withdraw the units of
+// a clock auction that have not been sold. If the auction settles itself, the
+// assets of these units are returned to the seller
+func endClockAuction(ctx contractapi.TransactionContextInterface, auctionID string, auction *Auction) error {
+
+	if auction.Status != "open" {
+		return fmt.Errorf("can only end an open clock auction")
+	}
+
+	if auction.AssetChaincode != "" {
+		err := refundUnsoldAssets(ctx, auction)
+		if err != nil {
+			return err
+		}
+	}
+
+	auction.Status = "ended"
+
+	endedAuctionJSON, _ := json.Marshal(auction)
+
+	err := ctx.GetStub().PutState(auctionID, endedAuctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to end auction: %v", err)
+	}
+
+	return setAuctionEvent(ctx, auctionEndedEvent, auctionID, auction)
+}
+
+// maxClockSkew is how far the timestamp of an AcceptPrice transaction can be
+// ahead of the clock of the endorsing peer. It allows for clocks that are not
+// quite in step, and a buyer can gain at most the ticks that fit in it
+const maxClockSkew = 30 * time.Second
+
+// peerTime returns the time on the clock of the endorsing peer
+var peerTime = time.Now
+
+// checkClockSkew is an internal function that rejects a transaction timestamp
+// that is ahead of the clock of the endorsing peer by more than maxClockSkew.
+// Peers whose clocks differ may disagree, in which case the transaction fails
+// to gather its endorsements and the buyer can try again
+func checkClockSkew(txTime time.Time, peerNow time.Time) error {
+	if txTime.After(peerNow.Add(maxClockSkew)) {
+		return fmt.Errorf("the transaction timestamp %v is ahead of the clock of the endorsing peer, %v", txTime.UTC(), peerNow.UTC())
+	}
+	return nil
+}
+
+// currentClockPrice is an internal function that calculates the clock price at
+// the timestamp of the transaction
+func currentClockPrice(ctx contractapi.TransactionContextInterface, clock *Clock) (int, error) {
+
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return clock.priceAt(now.Unix()), nil
+}
+
+// priceAt returns the clock price at a time, in seconds since the epoch
+func (clock *Clock) priceAt(seconds int64) int {
+
+	elapsed := seconds - clock.StartTime
+	if elapsed < 0 {
+		return clock.StartPrice
+	}
+
+	ticks := elapsed / int64(clock.TickSeconds)
+	drop := int64(clock.StartPrice - clock.FloorPrice)
+	if ticks*int64(clock.Decrement) >= drop {
+		return clock.FloorPrice
+	}
+
+	return clock.StartPrice - int(ticks)*clock.Decrement
+}
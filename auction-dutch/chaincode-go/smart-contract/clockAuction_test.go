@@ -0,0 +1,67 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceAt(t *testing.T) {
+	clock := &Clock{StartPrice: 90, FloorPrice: 60, Decrement: 5, TickSeconds: 60, StartTime: 1700000000}
+
+	tests := []struct {
+		name    string
+		elapsed int64
+		price   int
+	}{
+		{name: "before the start", elapsed: -120, price: 90},
+		{name: "at the start", elapsed: 0, price: 90},
+		{name: "within the first tick", elapsed: 59, price: 90},
+		{name: "first tick", elapsed: 60, price: 85},
+		{name: "fifth tick", elapsed: 330, price: 65},
+		{name: "reaches the floor", elapsed: 360, price: 60},
+		{name: "stays at the floor", elapsed: 86400, price: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.price, clock.priceAt(clock.StartTime+tt.elapsed))
+		})
+	}
+
+	// a decrement that does not divide the drop stops at the floor
+	clock = &Clock{StartPrice: 100, FloorPrice: 10, Decrement: 40, TickSeconds: 10, StartTime: 1700000000}
+	require.Equal(t, 20, clock.priceAt(clock.StartTime+20))
+	require.Equal(t, 10, clock.priceAt(clock.StartTime+30))
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	peerNow := time.Date(2024, 12, 13, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		txTime time.Time
+		err    bool
+	}{
+		{name: "behind the peer", txTime: peerNow.Add(-time.Hour)},
+		{name: "in step", txTime: peerNow},
+		{name: "within the skew", txTime: peerNow.Add(maxClockSkew)},
+		{name: "dated ahead", txTime: peerNow.Add(maxClockSkew + time.Second), err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkClockSkew(tt.txTime, peerNow)
+			if tt.err {
+				require.EqualError(t, err, "the transaction timestamp 2024-12-13 09:00:31 +0000 UTC is ahead of the clock of the endorsing peer, 2024-12-13 09:00:00 +0000 UTC")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// An auction settles itself if the seller locks the units of the item in the
+// escrow of an asset chaincode, one asset per unit, and names the ERC-20 token
+// chaincode that buyers pay with. Both chaincodes are called from the auction
+// chaincode with the identity of the client that submits the transaction, and
+// keep the escrows of the auction under the name of the auction chaincode
+
+// checkSettlement is an internal function that checks the settlement
+// parameters of a new auction. An auction without an asset chaincode, assets
+// and token chaincode does not settle itself
+func checkSettlement(quantity int, assetChaincode string, assets []string, tokenChaincode string) error {
+
+	if assetChaincode == "" && tokenChaincode == "" && len(assets) == 0 {
+		return nil
+	}
+	if assetChaincode == "" || tokenChaincode == "" {
+		return fmt.Errorf("an auction that settles itself needs both an asset chaincode and a token chaincode")
+	}
+	if len(assets) != quantity {
+		return fmt.Errorf("the seller needs to lock one asset for each of the %d units, not %d assets", quantity, len(assets))
+	}
+
+	seen := make(map[string]bool)
+	for _, asset := range assets {
+		if asset == "" || seen[asset] {
+			return fmt.Errorf("the assets of an auction need to be distinct and not empty")
+		}
+		seen[asset] = true
+	}
+
+	return nil
+}
+
+// lockAssets is an internal function that locks the assets of a new auction
+// in the escrow of the asset chaincode. The escrow of each asset is identified
+// by the ID of the asset. The asset chaincode is called with the identity of
+// the seller, who needs to own the assets
+func lockAssets(ctx contractapi.TransactionContextInterface, auction *Auction) error {
+
+	for _, asset := range auction.Assets {
+		err := invokeChaincode(ctx, auction.AssetChaincode, "LockEscrow", asset, asset)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lockPayment is an internal function that locks the price of a revealed bid
+// in the escrow of the token chaincode, identified by the transaction ID of the
+// bid. The token chaincode is called with the identity of the bidder, so the
+// payment is locked from the bidder's account
+func lockPayment(ctx contractapi.TransactionContextInterface, auction *Auction, txID string, bid FullBid) error {
+	return invokeChaincode(ctx, auction.TokenChaincode, "LockEscrow", txID, strconv.Itoa(bid.Price*bid.Quantity))
+}
+
+// settleClockPurchase is an internal function that settles the units that a
+// buyer accepts in a clock auction. The buyer submits the transaction, so the
+// price is paid directly from the buyer's account to the seller
+func settleClockPurchase(ctx contractapi.TransactionContextInterface, auction *Auction, winner *Winners) error {
+
+	sold := 0
+	for _, previous := range auction.Winners {
+		sold += len(previous.Assets)
+	}
+	winner.Assets = auction.Assets[sold : sold+winner.Quantity]
+
+	err := invokeChaincode(ctx, auction.TokenChaincode, "Transfer", sellerAccount(auction), strconv.Itoa(winner.Price*winner.Quantity))
+	if err != nil {
+		return err
+	}
+
+	return releaseAssets(ctx, auction, winner)
+}
+
+// settleSealedBidAuction is an internal function that settles an auction once
+// the winners are calculated. bidKeys are the keys of the revealed bids in the
+// order in which they were filled, so that each winner is paid for from the
+// escrow of their bid. Each winner pays the clearing price for their units, and
+// the rest of the price they locked is returned to them. The payments of the
+// bids that did not win and the assets that were not sold are returned
+func settleSealedBidAuction(ctx contractapi.TransactionContextInterface, auction *Auction, bidKeys []string) error {
+
+	sold := 0
+	for i, bidKey := range bidKeys {
+
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(bidKey)
+		if err != nil {
+			return fmt.Errorf("failed to split composite key: %v", err)
+		}
+		txID := keyParts[1]
+
+		if i >= len(auction.Winners) {
+			err = invokeChaincode(ctx, auction.TokenChaincode, "RefundEscrow", txID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		winner := &auction.Winners[i]
+		winner.Assets = auction.Assets[sold : sold+winner.Quantity]
+		sold += winner.Quantity
+
+		err = invokeChaincode(ctx, auction.TokenChaincode, "SettleEscrow", txID, sellerAccount(auction), strconv.Itoa(auction.Price*winner.Quantity))
+		if err != nil {
+			return err
+		}
+		err = releaseAssets(ctx, auction, winner)
+		if err != nil {
+			return err
+		}
+	}
+
+	return refundUnsoldAssets(ctx, auction)
+}
+
+// refundUnsoldAssets is an internal function that returns the assets that
+// were not sold to the seller
+func refundUnsoldAssets(ctx contractapi.TransactionContextInterface, auction *Auction) error {
+
+	sold := 0
+	for _, winner := range auction.Winners {
+		sold += len(winner.Assets)
+	}
+
+	for _, asset := range auction.Assets[sold:] {
+		err := invokeChaincode(ctx, auction.AssetChaincode, "RefundEscrow", asset)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseAssets is an internal function that transfers the assets of a winner
+// from escrow to the winner
+func releaseAssets(ctx contractapi.TransactionContextInterface, auction *Auction, winner *Winners) error {
+
+	for _, asset := range winner.Assets {
+		err := invokeChaincode(ctx, auction.AssetChaincode, "ReleaseEscrow", asset, winner.Buyer)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sellerAccount returns the account of the seller in the token chaincode,
+// which identifies accounts by the base64 encoded client ID
+func sellerAccount(auction *Auction) string {
+	return base64.StdEncoding.EncodeToString([]byte(auction.Seller))
+}
+
+// invokeChaincode is an internal function that calls a function of the asset
+// or token chaincode on the channel of the auction
+func invokeChaincode(ctx contractapi.TransactionContextInterface, chaincodeName string, function string, args ...string) error {
+
+	invokeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+
+	response := ctx.GetStub().InvokeChaincode(chaincodeName, invokeArgs, "")
+	if response.Status != shim.OK {
+		return fmt.Errorf("failed to call %s on chaincode %s: %s", function, chaincodeName, response.Message)
+	}
+
+	return nil
+}
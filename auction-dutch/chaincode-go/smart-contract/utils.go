@@ -6,7 +6,9 @@ package auction
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
@@ -62,6 +64,100 @@ func verifyClientOrgMatchesPeerOrg(ctx contractapi.TransactionContextInterface)
 	return nil
 }
 
+// verifyClientOrgIsParticipant is an internal function used to verify that the
+// client belongs to one of the organizations participating in the auction
+func verifyClientOrgIsParticipant(ctx contractapi.TransactionContextInterface, orgs []string) error {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed getting the client's MSPID: %v", err)
+	}
+
+	if !contains(orgs, clientMSPID) {
+		return fmt.Errorf("participant %s is not a member of the auction", clientMSPID)
+	}
+
+	return nil
+}
+
+// getTxTime is an internal function that returns the timestamp of the
+// transaction. The timestamp is set by the client that creates the transaction,
+// so that all endorsing peers use the same time
+func getTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+
+	return timestamp.AsTime(), nil
+}
+
+// parseDeadlines is an internal function that parses the bidding and reveal
+// deadlines of a new auction, and checks that they are in order and in the future
+func parseDeadlines(now time.Time, biddingDeadline string, revealDeadline string) (time.Time, time.Time, error) {
+	biddingTime, err := time.Parse(time.RFC3339, biddingDeadline)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("bidding deadline %q is not an RFC 3339 timestamp", biddingDeadline)
+	}
+	revealTime, err := time.Parse(time.RFC3339, revealDeadline)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("reveal deadline %q is not an RFC 3339 timestamp", revealDeadline)
+	}
+
+	if !biddingTime.After(now) {
+		return time.Time{}, time.Time{}, fmt.Errorf("bidding deadline %v has already passed", biddingDeadline)
+	}
+	if !revealTime.After(biddingTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("reveal deadline %v must be after the bidding deadline %v", revealDeadline, biddingDeadline)
+	}
+
+	return biddingTime.UTC(), revealTime.UTC(), nil
+}
+
+// checkAuditors is an internal function that checks the auditors of a new
+// auction. The auditors need to be distinct organizations other than the
+// seller's, and the threshold needs to be between 1 and the number of
+// auditors, or 0 if the auction has no auditors
+func checkAuditors(auditors []string, auditorThreshold int, sellerOrg string) error {
+
+	if len(auditors) == 0 {
+		if auditorThreshold != 0 {
+			return fmt.Errorf("an auction without auditors needs an auditor threshold of 0")
+		}
+		return nil
+	}
+	if auditorThreshold < 1 || auditorThreshold > len(auditors) {
+		return fmt.Errorf("the auditor threshold must be between 1 and the number of auditors %d", len(auditors))
+	}
+
+	for i, auditor := range auditors {
+		if auditor == "" || contains(auditors[:i], auditor) {
+			return fmt.Errorf("the auditors of an auction need to be distinct and not empty")
+		}
+		if auditor == sellerOrg {
+			return fmt.Errorf("the organization of the seller %s cannot audit the auction", sellerOrg)
+		}
+	}
+
+	return nil
+}
+
+// setAuctionEvent is an internal function that sets the chaincode event of the
+// transaction, so that clients can follow the auction without polling it. A
+// transaction only emits the last event that it sets
+func setAuctionEvent(ctx contractapi.TransactionContextInterface, eventName string, auctionID string, auction *Auction) error {
+	eventJSON, err := json.Marshal(AuctionRecord{AuctionID: auctionID, Auction: auction})
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetEvent(eventName, eventJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set event %v: %v", eventName, err)
+	}
+
+	return nil
+}
+
 func contains(sli []string, str string) bool {
 	for _, a := range sli {
 		if a == str {
@@ -71,12 +167,19 @@ func contains(sli []string, str string) bool {
 	return false
 }
 
-func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, assetId string, mspids []string, auditor bool) error {
+// setAssetStateBasedEndorsement sets the endorsement policy of the auction. All
+// participating organizations can update the auction together. If the auction
+// names auditors, the threshold of auditors and one participant can also update
+// the auction
+func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, assetId string, mspids []string, auditors []string, auditorThreshold int) error {
 
-	principals := make([]*msp.MSPPrincipal, len(mspids))
-	participantSigsPolicy := make([]*common.SignaturePolicy, len(mspids))
+	principals := make([]*msp.MSPPrincipal, 0, len(mspids)+len(auditors))
+	participantSigsPolicy := make([]*common.SignaturePolicy, 0, len(mspids))
+	auditorSigsPolicy := make([]*common.SignaturePolicy, 0, len(auditors))
 
-	for i, id := range mspids {
+	// the participants are listed first in the identities of the policy,
+	// followed by the auditors
+	for _, id := range append(append([]string{}, mspids...), auditors...) {
 		principal, err := proto.Marshal(
 			&msp.MSPRole{
 				Role:          msp.MSPRole_PEER,
@@ -86,66 +189,43 @@ func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface,
 		if err != nil {
 			return err
 		}
-		principals[i] = &msp.MSPPrincipal{
-			PrincipalClassification: msp.MSPPrincipal_ROLE,
-			Principal:               principal,
-		}
-		participantSigsPolicy[i] = &common.SignaturePolicy{
+		signedBy := &common.SignaturePolicy{
 			Type: &common.SignaturePolicy_SignedBy{
-				SignedBy: int32(i),
+				SignedBy: int32(len(principals)),
 			},
 		}
+		principals = append(principals, &msp.MSPPrincipal{
+			PrincipalClassification: msp.MSPPrincipal_ROLE,
+			Principal:               principal,
+		})
+		if len(participantSigsPolicy) < len(mspids) {
+			participantSigsPolicy = append(participantSigsPolicy, signedBy)
+		} else {
+			auditorSigsPolicy = append(auditorSigsPolicy, signedBy)
+		}
 	}
 
-	if !auditor {
-		// create the defalt policy for an auction without an auditor
-
-		policy := &common.SignaturePolicyEnvelope{
-			Version: 0,
-			Rule: &common.SignaturePolicy{
-				Type: &common.SignaturePolicy_NOutOf_{
-					NOutOf: &common.SignaturePolicy_NOutOf{
-						N:     int32(len(mspids)),
-						Rules: participantSigsPolicy,
-					},
-				},
+	// create the default policy, which all participants need to endorse
+	rule := &common.SignaturePolicy{
+		Type: &common.SignaturePolicy_NOutOf_{
+			NOutOf: &common.SignaturePolicy_NOutOf{
+				N:     int32(len(mspids)),
+				Rules: participantSigsPolicy,
 			},
-			Identities: principals,
-		}
-
-		spBytes, err := proto.Marshal(policy)
-		if err != nil {
-			return err
-		}
-		err = ctx.GetStub().SetStateValidationParameter(assetId, spBytes)
-		if err != nil {
-			return fmt.Errorf("failed to set validation parameter on auction: %v", err)
-		}
-	} else {
+		},
+	}
 
-		// create the defalt policy for an auction with an auditor
+	if len(auditors) > 0 {
 
-		// create the auditor identity and signature policy
-		auditorMSP, err := proto.Marshal(
-			&msp.MSPRole{
-				Role:          msp.MSPRole_PEER,
-				MspIdentifier: "Org3MSP",
-			},
-		)
-		if err != nil {
-			return err
-		}
-		principals = append(principals, &msp.MSPPrincipal{
-			PrincipalClassification: msp.MSPPrincipal_ROLE,
-			Principal:               auditorMSP,
-		},
-		)
-		// Create the policies in case the auditor is needed. In this case, an
-		// auditor and 1 participant can update the auction.
+		// Create the policies in case the auditors are needed. In this case,
+		// the threshold of auditors and 1 participant can update the auction
 		auditorPolicies := make([]*common.SignaturePolicy, 2)
 		auditorPolicies[0] = &common.SignaturePolicy{
-			Type: &common.SignaturePolicy_SignedBy{
-				SignedBy: int32(len(principals) - 1),
+			Type: &common.SignaturePolicy_NOutOf_{
+				NOutOf: &common.SignaturePolicy_NOutOf{
+					N:     int32(auditorThreshold),
+					Rules: auditorSigsPolicy,
+				},
 			},
 		}
 		auditorPolicies[1] = &common.SignaturePolicy{
@@ -157,49 +237,44 @@ func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface,
 			},
 		}
 
-		// For two organizations, the auditor policy below is equivilent to
-		// AND(auditor, OR(Org1, Org2))
-		policies := make([]*common.SignaturePolicy, 2)
-		policies[0] = &common.SignaturePolicy{
-			Type: &common.SignaturePolicy_NOutOf_{
-				NOutOf: &common.SignaturePolicy_NOutOf{
-					N:     2,
-					Rules: auditorPolicies,
-				},
-			},
-		}
-		// Participants can also update the auction without an auditor
-		policies[1] = &common.SignaturePolicy{
+		// Either the auditor policy or the participant policy can update
+		// the auction. For example, for two organizations and a single
+		// auditor, the full policy would be equivilent to
+		// OR(AND(Org1, Org2), AND(auditor, OR(Org1, Org2)))
+		rule = &common.SignaturePolicy{
 			Type: &common.SignaturePolicy_NOutOf_{
 				NOutOf: &common.SignaturePolicy_NOutOf{
-					N:     int32(len(mspids)),
-					Rules: participantSigsPolicy,
-				},
-			},
-		}
-		// Either the auditor policy or the participant policy can update
-		// the auction. For example, for two organizations, the full policy would be
-		// equivilent to OR(AND(Org1, Org2),AND(auditor, OR(Org1, Org2)))
-		policy := &common.SignaturePolicyEnvelope{
-			Version: 0,
-			Rule: &common.SignaturePolicy{
-				Type: &common.SignaturePolicy_NOutOf_{
-					NOutOf: &common.SignaturePolicy_NOutOf{
-						N:     1,
-						Rules: policies,
+					N: 1,
+					Rules: []*common.SignaturePolicy{
+						rule,
+						{
+							Type: &common.SignaturePolicy_NOutOf_{
+								NOutOf: &common.SignaturePolicy_NOutOf{
+									N:     2,
+									Rules: auditorPolicies,
+								},
+							},
+						},
 					},
 				},
 			},
-			Identities: principals,
-		}
-		spBytes, err := proto.Marshal(policy)
-		if err != nil {
-			return err
-		}
-		err = ctx.GetStub().SetStateValidationParameter(assetId, spBytes)
-		if err != nil {
-			return fmt.Errorf("failed to set validation parameter on auction: %v", err)
 		}
 	}
+
+	policy := &common.SignaturePolicyEnvelope{
+		Version:    0,
+		Rule:       rule,
+		Identities: principals,
+	}
+
+	spBytes, err := proto.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().SetStateValidationParameter(assetId, spBytes)
+	if err != nil {
+		return fmt.Errorf("failed to set validation parameter on auction: %v", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,74 @@
+/*
+ * Copyright IBM Corp. All Rights Reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+'use strict';
+
+const { Gateway, Wallets } = require('fabric-network');
+const path = require('path');
+const { buildCCPOrg1, buildCCPOrg2, buildWallet, prettyJSONString} = require('../../test-application/javascript/AppUtil.js');
+
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction';
+
+async function auctionEvents(ccp,wallet,user) {
+	try {
+
+		const gateway = new Gateway();
+
+		// Connect using Discovery enabled
+		await gateway.connect(ccp,
+			{ wallet: wallet, identity: user, discovery: { enabled: true, asLocalhost: true } });
+
+		const network = await gateway.getNetwork(myChannel);
+		const contract = network.getContract(myChaincodeName);
+
+		// the listener is called with the AuctionCreated, AuctionClosed and
+		// AuctionEnded events of the transactions that are committed
+		const listener = async (event) => {
+			console.log(`\n<-- Contract Event Received: ${event.eventName}`);
+			console.log('*** Event: ' + prettyJSONString(event.payload.toString()));
+		};
+
+		console.log('\n--> Listen for auction events, press Ctrl+C to stop');
+		await contract.addContractListener(listener);
+	} catch (error) {
+		console.error(`******** FAILED to listen for auction events: ${error}`);
+	}
+}
+
+async function main() {
+	try {
+
+		if (process.argv[2] === undefined || process.argv[3] === undefined) {
+			console.log('Usage: node auctionEvents.js org userID');
+			process.exit(1);
+		}
+
+		const org = process.argv[2];
+		const user = process.argv[3];
+
+		if (org === 'Org1' || org === 'org1') {
+			const ccp = buildCCPOrg1();
+			const walletPath = path.join(__dirname, 'wallet/org1');
+			const wallet = await buildWallet(Wallets, walletPath);
+			await auctionEvents(ccp,wallet,user);
+		}
+		else if (org === 'Org2' || org === 'org2') {
+			const ccp = buildCCPOrg2();
+			const walletPath = path.join(__dirname, 'wallet/org2');
+			const wallet = await buildWallet(Wallets, walletPath);
+			await auctionEvents(ccp,wallet,user);
+		}  else {
+			console.log('Usage: node auctionEvents.js org userID');
+			console.log('Org must be Org1 or Org2');
+		}
+	} catch (error) {
+		console.error(`******** FAILED to run the application: ${error}`);
+	}
+}
+
+
+main();
@@ -7,6 +7,7 @@
 'use strict';
 
 const { Gateway, Wallets } = require('fabric-network');
+const crypto = require('crypto');
 const path = require('path');
 const { buildCCPOrg1, buildCCPOrg2, buildWallet, prettyJSONString} = require('../../test-application/javascript/AppUtil.js');
 
@@ -29,7 +30,9 @@ async function bid(ccp,wallet,user,orgMSP,auctionID,price) {
 		let bidder = await contract.evaluateTransaction('GetSubmittingClientIdentity');
 		console.log('*** Result:  Bidder ID is ' + bidder.toString());
 
-		let bidData = { objectType: 'bid', price: parseInt(price), org: orgMSP, bidder: bidder.toString()};
+		// the random salt keeps others from guessing the bid from its hash on the public ledger
+		let salt = crypto.randomBytes(32).toString('hex');
+		let bidData = { objectType: 'bid', price: parseInt(price), org: orgMSP, bidder: bidder.toString(), salt: salt};
 
 		let statefulTxn = contract.createTransaction('Bid');
 		statefulTxn.setEndorsingOrganizations(orgMSP);
@@ -8,12 +8,13 @@
 
 const { Gateway, Wallets } = require('fabric-network');
 const path = require('path');
+const crypto = require('crypto');
 const { buildCCPOrg1, buildCCPOrg2, buildWallet, prettyJSONString} = require('../../test-application/javascript/AppUtil.js');
 
 const myChannel = 'mychannel';
 const myChaincodeName = 'auction';
 
-async function createAuction(ccp,wallet,user,auctionID,item) {
+async function createAuction(ccp,wallet,user,orgMSP,auctionID,item,biddingMinutes,revealMinutes,settlement,reserve,deposit,tokenChaincode) {
 	try {
 
 		const gateway = new Gateway();
@@ -25,10 +26,24 @@ async function createAuction(ccp,wallet,user,auctionID,item) {
 		const network = await gateway.getNetwork(myChannel);
 		const contract = network.getContract(myChaincodeName);
 
+		// the deadlines are counted from now, and passed as RFC 3339 timestamps
+		const biddingDeadline = new Date(Date.now() + parseInt(biddingMinutes) * 60000);
+		const revealDeadline = new Date(biddingDeadline.getTime() + parseInt(revealMinutes) * 60000);
+
 		let statefulTxn = contract.createTransaction('CreateAuction');
 
+		// the reserve price is committed in private, and stored on the peer of the seller's organization
+		if (parseInt(reserve) > 0) {
+			let salt = crypto.randomBytes(32).toString('hex');
+			let reserveData = { objectType: 'reserve', price: parseInt(reserve), salt: salt};
+			statefulTxn.setEndorsingOrganizations(orgMSP);
+			statefulTxn.setTransient({
+				reserve: Buffer.from(JSON.stringify(reserveData))
+			});
+		}
+
 		console.log('\n--> Submit Transaction: Propose a new auction');
-		await statefulTxn.submit(auctionID,item);
+		await statefulTxn.submit(auctionID,item,biddingDeadline.toISOString(),revealDeadline.toISOString(),settlement,parseInt(deposit),tokenChaincode);
 		console.log('*** Result: committed');
 
 		console.log('\n--> Evaluate Transaction: query the auction that was just created');
@@ -45,8 +60,10 @@ async function main() {
 	try {
 
 		if (process.argv[2] === undefined || process.argv[3] === undefined ||
-            process.argv[4] === undefined || process.argv[5] === undefined) {
-			console.log('Usage: node createAuction.js org userID auctionID item');
+            process.argv[4] === undefined || process.argv[5] === undefined ||
+            process.argv[6] === undefined || process.argv[7] === undefined ||
+            process.argv[8] === undefined) {
+			console.log('Usage: node createAuction.js org userID auctionID item biddingMinutes revealMinutes settlement [reserve [deposit tokenChaincode]]');
 			process.exit(1);
 		}
 
@@ -54,20 +71,26 @@ async function main() {
 		const user = process.argv[3];
 		const auctionID = process.argv[4];
 		const item = process.argv[5];
+		const biddingMinutes = process.argv[6];
+		const revealMinutes = process.argv[7];
+		const settlement = process.argv[8];
+		const reserve = process.argv[9] || '0';
+		const deposit = process.argv[10] || '0';
+		const tokenChaincode = process.argv[11] || '';
 
 		if (org === 'Org1' || org === 'org1') {
 			const ccp = buildCCPOrg1();
 			const walletPath = path.join(__dirname, 'wallet/org1');
 			const wallet = await buildWallet(Wallets, walletPath);
-			await createAuction(ccp,wallet,user,auctionID,item);
+			await createAuction(ccp,wallet,user,'Org1MSP',auctionID,item,biddingMinutes,revealMinutes,settlement,reserve,deposit,tokenChaincode);
 		}
 		else if (org === 'Org2' || org === 'org2') {
 			const ccp = buildCCPOrg2();
 			const walletPath = path.join(__dirname, 'wallet/org2');
 			const wallet = await buildWallet(Wallets, walletPath);
-			await createAuction(ccp,wallet,user,auctionID,item);
+			await createAuction(ccp,wallet,user,'Org2MSP',auctionID,item,biddingMinutes,revealMinutes,settlement,reserve,deposit,tokenChaincode);
 		}  else {
-			console.log('Usage: node createAuction.js org userID auctionID item');
+			console.log('Usage: node createAuction.js org userID auctionID item biddingMinutes revealMinutes settlement [reserve [deposit tokenChaincode]]');
 			console.log('Org must be Org1 or Org2');
 		}
 	} catch (error) {
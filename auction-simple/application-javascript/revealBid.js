@@ -32,7 +32,7 @@ async function addBid(ccp,wallet,user,auctionID,bidID) {
 		// console.log('*** Result:  Bid: ' + prettyJSONString(auctionString.toString()));
 		let auctionJSON = JSON.parse(auctionString);
 
-		let bidData = { objectType: 'bid', price: parseInt(bidJSON.price), org: bidJSON.org, bidder: bidJSON.bidder};
+		let bidData = { objectType: 'bid', price: parseInt(bidJSON.price), org: bidJSON.org, bidder: bidJSON.bidder, salt: bidJSON.salt};
 		console.log('*** Result:  Bid: ' + JSON.stringify(bidData,null,2));
 
 		let statefulTxn = contract.createTransaction('RevealBid');
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
@@ -29,14 +30,32 @@ type Auction struct {
 	Winner       string             `json:"winner"`
 	Price        int                `json:"price"`
 	Status       string             `json:"status"`
+	// BiddingDeadline ends the bidding period, and RevealDeadline ends the
+	// period in which bids can be revealed
+	BiddingDeadline time.Time `json:"biddingDeadline"`
+	RevealDeadline  time.Time `json:"revealDeadline"`
+	// Deposit is the number of tokens of the ERC-20 token chaincode
+	// TokenChaincode that a bidder locks with each bid, or 0 if bids need no
+	// deposit
+	Deposit        int    `json:"deposit"`
+	TokenChaincode string `json:"tokenChaincode"`
+	// Settlement is the rule that sets the price the winner pays, firstPrice or
+	// secondPrice. ReserveHash is the hash of the reserve price the seller
+	// committed to in private, and Reserve holds the price once it is revealed
+	Settlement      string `json:"settlement"`
+	ReserveHash     string `json:"reserveHash"`
+	Reserve         int    `json:"reserve"`
+	ReserveRevealed bool   `json:"reserveRevealed"`
 }
 
-// FullBid is the structure of a revealed bid
+// FullBid is the structure of a revealed bid. The salt is only kept in the
+// private bid, where it makes the hash of the bid impossible to guess
 type FullBid struct {
 	Type   string `json:"objectType"`
 	Price  int    `json:"price"`
 	Org    string `json:"org"`
 	Bidder string `json:"bidder"`
+	Salt   string `json:"salt,omitempty" metadata:",optional"`
 }
 
 // BidHash is the structure of a private bid
@@ -47,9 +66,47 @@ type BidHash struct {
 
 const bidKeyType = "bid"
 
+// Names of the chaincode events that clients can subscribe to. Each event
+// carries an AuctionRecord with the auction after the transaction
+const (
+	auctionCreatedEvent = "AuctionCreated"
+	auctionClosedEvent  = "AuctionClosed"
+	auctionEndedEvent   = "AuctionEnded"
+)
+
+// minSaltLength is the minimum length of the salt of a bid, which is long
+// enough for 16 random bytes in hex
+const minSaltLength = 32
+
 // CreateAuction creates on auction on the public channel. The identity that
-// submits the transacion becomes the seller of the auction
-func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string) error {
+// submits the transacion becomes the seller of the auction. Bids can be added
+// until the bidding deadline, and revealed until the reveal deadline, which are
+// RFC 3339 timestamps such as 2024-06-01T12:00:00Z. If the deposit is not 0,
+// each bidder locks that many tokens of the ERC-20 token chaincode with their
+// bid, and forfeits them to the seller if they do not reveal the bid in time.
+// The settlement rule is firstPrice or secondPrice. The seller can commit to a
+// reserve price by passing it under the "reserve" key of the transient map
+func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, biddingDeadline string, revealDeadline string, settlement string, deposit int, tokenChaincode string) error {
+
+	if settlement != firstPrice && settlement != secondPrice {
+		return fmt.Errorf("settlement must be %s or %s", firstPrice, secondPrice)
+	}
+	if deposit < 0 {
+		return errors.New("the deposit must not be negative")
+	}
+	if deposit > 0 && tokenChaincode == "" {
+		return errors.New("an auction with a deposit needs a token chaincode")
+	}
+
+	// the deadlines are checked against the timestamp of the transaction
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	biddingTime, revealTime, err := parseDeadlines(now, biddingDeadline, revealDeadline)
+	if err != nil {
+		return err
+	}
 
 	// get ID of submitting client
 	clientID, err := s.GetSubmittingClientIdentity(ctx)
@@ -63,20 +120,49 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 		return fmt.Errorf("failed to get client identity %v", err)
 	}
 
+	existingAuctionJSON, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to read auction: %v", err)
+	}
+	if existingAuctionJSON != nil {
+		return fmt.Errorf("auction %v already exists", auctionID)
+	}
+
+	// the seller can commit to a reserve price by passing it in the transient
+	// map. The price stays private until the seller reveals it
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient: %v", err)
+	}
+
+	reserveHash := ""
+	if reserveJSON, ok := transientMap["reserve"]; ok {
+		reserveHash, err = commitReserve(ctx, auctionID, reserveJSON)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create auction
 	bidders := make(map[string]BidHash)
 	revealedBids := make(map[string]FullBid)
 
 	auction := Auction{
-		Type:         "auction",
-		ItemSold:     itemsold,
-		Price:        0,
-		Seller:       clientID,
-		Orgs:         []string{clientOrgID},
-		PrivateBids:  bidders,
-		RevealedBids: revealedBids,
-		Winner:       "",
-		Status:       "open",
+		Type:            "auction",
+		ItemSold:        itemsold,
+		Price:           0,
+		Seller:          clientID,
+		Orgs:            []string{clientOrgID},
+		PrivateBids:     bidders,
+		RevealedBids:    revealedBids,
+		Winner:          "",
+		Status:          "open",
+		BiddingDeadline: biddingTime,
+		RevealDeadline:  revealTime,
+		Deposit:         deposit,
+		TokenChaincode:  tokenChaincode,
+		Settlement:      settlement,
+		ReserveHash:     reserveHash,
 	}
 
 	auctionJSON, err := json.Marshal(auction)
@@ -96,7 +182,7 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 		return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
 	}
 
-	return nil
+	return setAuctionEvent(ctx, auctionCreatedEvent, auctionID, &auction)
 }
 
 // Bid is used to add a user's bid to the auction. The bid is stored in the private
@@ -115,6 +201,17 @@ func (s *SmartContract) Bid(ctx contractapi.TransactionContextInterface, auction
 		return "", errors.New("bid key not found in the transient map")
 	}
 
+	// the bid needs a salt, or its hash on the public ledger could be
+	// reversed by hashing every likely price
+	var bid FullBid
+	err = json.Unmarshal(BidJSON, &bid)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+	if len(bid.Salt) < minSaltLength {
+		return "", fmt.Errorf("the bid needs a random salt of at least %d characters", minSaltLength)
+	}
+
 	// get the implicit collection name using the bidder's organization ID
 	collection, err := getCollectionName(ctx)
 	if err != nil {
@@ -169,6 +266,16 @@ func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, a
 		return errors.New("cannot join closed or ended auction")
 	}
 
+	// bids can only be added until the bidding deadline, even if the
+	// auction has not been closed yet
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !now.Before(auction.BiddingDeadline) {
+		return fmt.Errorf("cannot join auction after the bidding deadline %v", auction.BiddingDeadline)
+	}
+
 	// get the inplicit collection name of bidder's org
 	collection, err := getCollectionName(ctx)
 	if err != nil {
@@ -189,6 +296,17 @@ func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, a
 	if bidHash == nil {
 		return fmt.Errorf("bid hash does not exist: %s", bidKey)
 	}
+	if _, ok := auction.PrivateBids[bidKey]; ok {
+		return fmt.Errorf("bid %s has already been submitted", txID)
+	}
+
+	// lock the deposit of the bid, which is paid from the bidder's account
+	if auction.Deposit > 0 {
+		err = lockDeposit(ctx, auction, txID)
+		if err != nil {
+			return err
+		}
+	}
 
 	// store the hash along with the bidder's organization
 	NewHash := BidHash{
@@ -263,12 +381,19 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 
 	// Complete a series of three checks before we add the bid to the auction
 
-	// check 1: check that the auction is closed. We cannot reveal a
-	// bid to an open auction
+	// check 1: check that the auction is closed and the reveal deadline has
+	// not passed. We cannot reveal a bid to an open auction
 	Status := auction.Status
 	if Status != "closed" {
 		return errors.New("cannot reveal bid for open or ended auction")
 	}
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !now.Before(auction.RevealDeadline) {
+		return fmt.Errorf("cannot reveal bid after the reveal deadline %v", auction.RevealDeadline)
+	}
 
 	// check 2: check that hash of revealed bid matches hash of private bid
 	// on the public ledger. This checks that the bidder is telling the truth
@@ -337,6 +462,14 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 
 	auction.RevealedBids[bidKey] = NewBid
 
+	// the bid has been revealed in time, so its deposit is returned
+	if auction.Deposit > 0 {
+		err = refundDeposit(ctx, auction, txID)
+		if err != nil {
+			return err
+		}
+	}
+
 	newAuctionJSON, _ := json.Marshal(auction)
 
 	// put auction with bid added back into state
@@ -348,8 +481,9 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 	return nil
 }
 
-// CloseAuction can be used by the seller to close the auction. This prevents
-// bids from being added to the auction, and allows users to reveal their bid
+// CloseAuction can be used by any participant to close the auction once the
+// bidding deadline has passed. This prevents bids from being added to the
+// auction, and allows users to reveal their bid
 func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
 
 	// get auction from public state
@@ -358,17 +492,19 @@ func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface
 		return fmt.Errorf("failed to get auction from public state %v", err)
 	}
 
-	// the auction can only be closed by the seller
-
-	// get ID of submitting client
-	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	// the auction can be closed by any participant, but not before the
+	// bidding deadline, so that the seller cannot cut the bidding short
+	err = verifyClientOrgIsParticipant(ctx, auction.Orgs)
 	if err != nil {
-		return fmt.Errorf("failed to get client identity %v", err)
+		return err
 	}
 
-	Seller := auction.Seller
-	if Seller != clientID {
-		return fmt.Errorf("auction can only be closed by seller: %v", err)
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if now.Before(auction.BiddingDeadline) {
+		return fmt.Errorf("cannot close auction before the bidding deadline %v", auction.BiddingDeadline)
 	}
 
 	Status := auction.Status
@@ -385,11 +521,12 @@ func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface
 		return fmt.Errorf("failed to close auction: %v", err)
 	}
 
-	return nil
+	return setAuctionEvent(ctx, auctionClosedEvent, auctionID, auction)
 }
 
 // EndAuction both changes the auction status to closed and calculates the winners
-// of the auction
+// of the auction. The seller can end a closed auction at any time, and any
+// participant can end it once the reveal deadline has passed
 func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
 
 	// get auction from public state
@@ -398,17 +535,30 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("failed to get auction from public state %v", err)
 	}
 
-	// Check that the auction is being ended by the seller
+	err = verifyClientOrgIsParticipant(ctx, auction.Orgs)
+	if err != nil {
+		return err
+	}
 
-	// get ID of submitting client
-	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	// Check that the auction is being ended by the seller if the reveal
+	// deadline has not passed
+
+	now, err := getTxTime(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get client identity %v", err)
+		return err
 	}
+	if now.Before(auction.RevealDeadline) {
 
-	Seller := auction.Seller
-	if Seller != clientID {
-		return fmt.Errorf("auction can only be ended by seller: %v", err)
+		// get ID of submitting client
+		clientID, err := s.GetSubmittingClientIdentity(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get client identity %v", err)
+		}
+
+		Seller := auction.Seller
+		if Seller != clientID {
+			return fmt.Errorf("auction can only be ended by seller before the reveal deadline %v", auction.RevealDeadline)
+		}
 	}
 
 	Status := auction.Status
@@ -416,24 +566,35 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return errors.New("can only end a closed auction")
 	}
 
-	// get the list of revealed bids
-	revealedBidMap := auction.RevealedBids
-	if len(auction.RevealedBids) == 0 {
+	// get the list of revealed bids. After the reveal deadline, an auction
+	// without revealed bids ends without a winner
+	revealDeadlinePassed := !now.Before(auction.RevealDeadline)
+	if len(auction.RevealedBids) == 0 && !revealDeadlinePassed {
 		return fmt.Errorf("no bids have been revealed, cannot end auction: %v", err)
 	}
 
-	// determine the highest bid
-	for _, bid := range revealedBidMap {
-		if bid.Price > auction.Price {
-			auction.Winner = bid.Bidder
-			auction.Price = bid.Price
+	// determine the winner and the price they pay. An auction with a reserve
+	// price that the seller has not revealed ends without a sale
+	threshold := settleAuction(auction)
+
+	// check if there is a winning bid that has yet to be revealed. Bids that
+	// were not revealed by the reveal deadline are dropped, so that a bidder
+	// cannot keep the auction from ending by never revealing their bid
+	if !revealDeadlinePassed {
+		err = checkForHigherBid(ctx, threshold, auction.RevealedBids, auction.PrivateBids)
+		if err != nil {
+			return fmt.Errorf("cannot end auction: %v", err)
 		}
 	}
 
-	// check if there is a winning bid that has yet to be revealed
-	err = checkForHigherBid(ctx, auction.Price, auction.RevealedBids, auction.PrivateBids)
-	if err != nil {
-		return fmt.Errorf("cannot end auction: %v", err)
+	// the deposits of dropped bids are forfeited to the seller. If the seller
+	// ends the auction before the reveal deadline, or withheld the reserve
+	// price, they are returned instead
+	if auction.Deposit > 0 {
+		err = settleUnrevealedDeposits(ctx, auction, revealDeadlinePassed && !reserveWithheld(auction))
+		if err != nil {
+			return err
+		}
 	}
 
 	auction.Status = "ended"
@@ -444,5 +605,6 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 	if err != nil {
 		return fmt.Errorf("failed to end auction: %v", err)
 	}
-	return nil
+
+	return setAuctionEvent(ctx, auctionEndedEvent, auctionID, auction)
 }
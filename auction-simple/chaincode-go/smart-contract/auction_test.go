@@ -0,0 +1,402 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const seller = "x509::CN=seller,OU=client::CN=ca.org1.example.com"
+
+type MockStub struct {
+	shim.ChaincodeStubInterface
+	mock.Mock
+}
+
+func (ms *MockStub) GetState(key string) ([]byte, error) {
+	args := ms.Called(key)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (ms *MockStub) PutState(key string, value []byte) error {
+	args := ms.Called(key, value)
+	return args.Error(0)
+}
+
+func (ms *MockStub) SetEvent(name string, payload []byte) error {
+	args := ms.Called(name, payload)
+	return args.Error(0)
+}
+
+func (ms *MockStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	args := ms.Called()
+	return args.Get(0).(*timestamppb.Timestamp), args.Error(1)
+}
+
+func (ms *MockStub) GetTransient() (map[string][]byte, error) {
+	args := ms.Called()
+	return args.Get(0).(map[string][]byte), args.Error(1)
+}
+
+func (ms *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	args := ms.Called(objectType, attributes)
+	return args.Get(0).(string), args.Error(1)
+}
+
+func (ms *MockStub) GetPrivateData(collection string, key string) ([]byte, error) {
+	args := ms.Called(collection, key)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (ms *MockStub) GetPrivateDataHash(collection string, key string) ([]byte, error) {
+	args := ms.Called(collection, key)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (ms *MockStub) GetStateByRangeWithPagination(startKey string, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	args := ms.Called(startKey, endKey, pageSize, bookmark)
+	return args.Get(0).(shim.StateQueryIteratorInterface), args.Get(1).(*peer.QueryResponseMetadata), args.Error(2)
+}
+
+type MockClientIdentity struct {
+	cid.ClientIdentity
+	mock.Mock
+}
+
+func (mci *MockClientIdentity) GetID() (string, error) {
+	args := mci.Called()
+	return args.Get(0).(string), args.Error(1)
+}
+
+func (mci *MockClientIdentity) GetMSPID() (string, error) {
+	args := mci.Called()
+	return args.Get(0).(string), args.Error(1)
+}
+
+type MockContext struct {
+	contractapi.TransactionContextInterface
+	mock.Mock
+}
+
+func (mc *MockContext) GetStub() shim.ChaincodeStubInterface {
+	args := mc.Called()
+	return args.Get(0).(*MockStub)
+}
+
+func (mc *MockContext) GetClientIdentity() cid.ClientIdentity {
+	args := mc.Called()
+	return args.Get(0).(*MockClientIdentity)
+}
+
+// MockIterator returns the key value pairs it holds in order
+type MockIterator struct {
+	shim.StateQueryIteratorInterface
+	results []*queryresult.KV
+}
+
+func (it *MockIterator) HasNext() bool {
+	return len(it.results) > 0
+}
+
+func (it *MockIterator) Next() (*queryresult.KV, error) {
+	next := it.results[0]
+	it.results = it.results[1:]
+	return next, nil
+}
+
+func (it *MockIterator) Close() error {
+	return nil
+}
+
+// setupStub returns a transaction context of a client with the given ID from
+// Org1MSP, at the transaction time now
+func setupStub(clientID string, now time.Time) (*MockContext, *MockStub) {
+	ms := new(MockStub)
+	ms.On("GetTxTimestamp").Return(timestamppb.New(now), nil)
+	ms.On("PutState", mock.Anything, mock.Anything).Return(nil)
+	ms.On("SetEvent", mock.Anything, mock.Anything).Return(nil)
+
+	mci := new(MockClientIdentity)
+	mci.On("GetID").Return(base64.StdEncoding.EncodeToString([]byte(clientID)), nil)
+	mci.On("GetMSPID").Return("Org1MSP", nil)
+
+	mc := new(MockContext)
+	mc.On("GetStub").Return(ms)
+	mc.On("GetClientIdentity").Return(mci)
+
+	return mc, ms
+}
+
+// putAuction stores the auction in the state of the stub
+func putAuction(t *testing.T, ms *MockStub, auctionID string, auction *Auction) {
+	auctionJSON, err := json.Marshal(auction)
+	require.NoError(t, err)
+	ms.On("GetState", auctionID).Return(auctionJSON, nil)
+}
+
+func TestParseDeadlines(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		bidding string
+		reveal  string
+		err     string
+	}{
+		{name: "in order", bidding: "2024-06-01T13:00:00Z", reveal: "2024-06-01T14:00:00Z"},
+		{name: "time zone", bidding: "2024-06-01T15:00:00+02:00", reveal: "2024-06-01T14:00:00Z"},
+		{name: "bidding not a timestamp", bidding: "tomorrow", reveal: "2024-06-01T14:00:00Z", err: `bidding deadline "tomorrow" is not an RFC 3339 timestamp`},
+		{name: "reveal not a timestamp", bidding: "2024-06-01T13:00:00Z", reveal: "2024-06-01", err: `reveal deadline "2024-06-01" is not an RFC 3339 timestamp`},
+		{name: "bidding passed", bidding: "2024-06-01T11:00:00Z", reveal: "2024-06-01T14:00:00Z", err: "bidding deadline 2024-06-01T11:00:00Z has already passed"},
+		{name: "bidding now", bidding: "2024-06-01T12:00:00Z", reveal: "2024-06-01T14:00:00Z", err: "bidding deadline 2024-06-01T12:00:00Z has already passed"},
+		{name: "reveal before bidding", bidding: "2024-06-01T14:00:00Z", reveal: "2024-06-01T13:00:00Z", err: "reveal deadline 2024-06-01T13:00:00Z must be after the bidding deadline 2024-06-01T14:00:00Z"},
+		{name: "reveal at bidding", bidding: "2024-06-01T13:00:00Z", reveal: "2024-06-01T13:00:00Z", err: "reveal deadline 2024-06-01T13:00:00Z must be after the bidding deadline 2024-06-01T13:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			biddingTime, revealTime, err := parseDeadlines(now, tt.bidding, tt.reveal)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, time.UTC, biddingTime.Location())
+			require.Equal(t, time.UTC, revealTime.Location())
+			require.True(t, revealTime.After(biddingTime))
+		})
+	}
+}
+
+func TestAuctionDeadlines(t *testing.T) {
+	biddingDeadline := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	revealDeadline := biddingDeadline.Add(time.Hour)
+	bidJSON := []byte(`{"objectType":"bid","price":800,"org":"Org1MSP","bidder":"bidder","salt":"salt"}`)
+	bidHash := sha256.Sum256(bidJSON)
+
+	newAuction := func(status string) *Auction {
+		return &Auction{
+			Type:            "auction",
+			ItemSold:        "painting",
+			Seller:          seller,
+			Orgs:            []string{"Org1MSP"},
+			PrivateBids:     map[string]BidHash{"bid1": {Org: "Org1MSP", Hash: "unused"}},
+			RevealedBids:    map[string]FullBid{},
+			Status:          status,
+			BiddingDeadline: biddingDeadline,
+			RevealDeadline:  revealDeadline,
+			Settlement:      firstPrice,
+		}
+	}
+
+	submitBid := func(ctx contractapi.TransactionContextInterface) error {
+		return new(SmartContract).SubmitBid(ctx, "auction1", "tx1")
+	}
+	closeAuction := func(ctx contractapi.TransactionContextInterface) error {
+		return new(SmartContract).CloseAuction(ctx, "auction1")
+	}
+	revealBid := func(ctx contractapi.TransactionContextInterface) error {
+		return new(SmartContract).RevealBid(ctx, "auction1", "tx1")
+	}
+	endAuction := func(ctx contractapi.TransactionContextInterface) error {
+		return new(SmartContract).EndAuction(ctx, "auction1")
+	}
+
+	tests := []struct {
+		name     string
+		status   string
+		clientID string
+		now      time.Time
+		call     func(contractapi.TransactionContextInterface) error
+		err      string
+	}{
+		{name: "bid at the bidding deadline", status: "open", now: biddingDeadline, call: submitBid, err: "cannot join auction after the bidding deadline 2024-06-01 12:00:00 +0000 UTC"},
+		{name: "close before the bidding deadline", status: "open", now: biddingDeadline.Add(-time.Second), call: closeAuction, err: "cannot close auction before the bidding deadline 2024-06-01 12:00:00 +0000 UTC"},
+		{name: "close at the bidding deadline", status: "open", now: biddingDeadline, call: closeAuction},
+		{name: "reveal at the reveal deadline", status: "closed", now: revealDeadline, call: revealBid, err: "cannot reveal bid after the reveal deadline 2024-06-01 13:00:00 +0000 UTC"},
+		{name: "bidder ends before the reveal deadline", status: "closed", clientID: "bidder", now: revealDeadline.Add(-time.Second), call: endAuction, err: "auction can only be ended by seller before the reveal deadline 2024-06-01 13:00:00 +0000 UTC"},
+		{name: "bidder ends at the reveal deadline", status: "closed", clientID: "bidder", now: revealDeadline, call: endAuction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientID := tt.clientID
+			if clientID == "" {
+				clientID = seller
+			}
+			ctx, ms := setupStub(clientID, tt.now)
+			putAuction(t, ms, "auction1", newAuction(tt.status))
+			ms.On("CreateCompositeKey", bidKeyType, []string{"auction1", "tx1"}).Return("bid1", nil)
+			ms.On("GetPrivateDataHash", "_implicit_org_Org1MSP", "bid1").Return(bidHash[:], nil)
+			ms.On("GetTransient").Return(map[string][]byte{"bid": bidJSON}, nil)
+
+			err := tt.call(ctx)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				ms.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			ms.AssertCalled(t, "PutState", "auction1", mock.Anything)
+		})
+	}
+}
+
+func TestCheckForHigherBid(t *testing.T) {
+	t.Setenv("CORE_PEER_LOCALMSPID", "Org1MSP")
+
+	ownBid := func(price int) []byte {
+		bidJSON, err := json.Marshal(FullBid{Type: bidKeyType, Price: price, Org: "Org1MSP", Bidder: "bidder"})
+		require.NoError(t, err)
+		return bidJSON
+	}
+
+	tests := []struct {
+		name     string
+		org      string
+		price    int
+		revealed map[string]FullBid
+		private  []byte
+		hash     []byte
+		err      string
+	}{
+		{name: "unrevealed bid below the price", org: "Org1MSP", price: 700, private: ownBid(600)},
+		{name: "unrevealed bid at the price", org: "Org1MSP", price: 700, private: ownBid(700)},
+		{name: "unrevealed bid above the price", org: "Org1MSP", price: 700, private: ownBid(701), err: "cannot close auction, bidder has a higher price: <nil>"},
+		{name: "higher bid already revealed", org: "Org1MSP", price: 700, revealed: map[string]FullBid{"bid1": {Price: 900}}, private: ownBid(900)},
+		{name: "own bid missing", org: "Org1MSP", price: 700, err: "bid bid1 does not exist"},
+		{name: "bid of other org", org: "Org2MSP", price: 700, hash: []byte("hash")},
+		{name: "bid hash of other org missing", org: "Org2MSP", price: 700, err: "bid hash does not exist: bid1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, ms := setupStub(seller, time.Now())
+			ms.On("GetPrivateData", "_implicit_org_Org1MSP", "bid1").Return(tt.private, nil)
+			ms.On("GetPrivateDataHash", "_implicit_org_Org2MSP", "bid1").Return(tt.hash, nil)
+
+			bidders := map[string]BidHash{"bid1": {Org: tt.org, Hash: "hash"}}
+			err := checkForHigherBid(ctx, tt.price, tt.revealed, bidders)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestListAuctions(t *testing.T) {
+	auctionKV := func(auctionID string, item string, status string) *queryresult.KV {
+		auctionJSON, err := json.Marshal(Auction{Type: "auction", ItemSold: item, Status: status})
+		require.NoError(t, err)
+		return &queryresult.KV{Key: auctionID, Value: auctionJSON}
+	}
+	// rangeQuery is a page of the range query that ListAuctions reads, with the
+	// page size and bookmark it is read with, and the bookmark it returns
+	type rangeQuery struct {
+		pageSize int32
+		bookmark string
+		next     string
+		results  []*queryresult.KV
+	}
+
+	tests := []struct {
+		name     string
+		status   string
+		filter   string
+		pageSize int
+		bookmark string
+		queries  []rangeQuery
+		auctions []string
+		next     string
+		err      string
+	}{
+		{
+			name:     "first page",
+			pageSize: 2,
+			queries:  []rangeQuery{{pageSize: 2, next: "a2", results: []*queryresult.KV{auctionKV("a1", "painting", "open"), auctionKV("a2", "vase", "closed")}}},
+			auctions: []string{"a1", "a2"},
+			next:     "a2",
+		},
+		{
+			name:     "next page",
+			pageSize: 2,
+			bookmark: "a2",
+			queries:  []rangeQuery{{pageSize: 2, bookmark: "a2", results: []*queryresult.KV{auctionKV("a3", "painting", "ended")}}},
+			auctions: []string{"a3"},
+		},
+		{
+			name:     "filtered auctions read on",
+			status:   "open",
+			filter:   "painting",
+			pageSize: 2,
+			queries: []rangeQuery{
+				{pageSize: 2, next: "a2", results: []*queryresult.KV{auctionKV("a1", "painting", "open"), auctionKV("a2", "vase", "open")}},
+				{pageSize: 1, bookmark: "a2", next: "a3", results: []*queryresult.KV{auctionKV("a3", "painting", "closed")}},
+				{pageSize: 1, bookmark: "a3", next: "a4", results: []*queryresult.KV{auctionKV("a4", "old painting", "open")}},
+			},
+			auctions: []string{"a1", "a4"},
+			next:     "a4",
+		},
+		{
+			name:     "range exhausted",
+			status:   "closed",
+			pageSize: 5,
+			queries: []rangeQuery{
+				{pageSize: 5, next: "a2", results: []*queryresult.KV{auctionKV("a1", "painting", "open"), auctionKV("a2", "vase", "closed")}},
+				{pageSize: 4, bookmark: "a2"},
+			},
+			auctions: []string{"a2"},
+		},
+		{
+			name:     "other objects skipped",
+			pageSize: 2,
+			queries:  []rangeQuery{{pageSize: 2, results: []*queryresult.KV{{Key: "escrow", Value: []byte(`{"objectType":"deposit"}`)}, auctionKV("a1", "painting", "open")}}},
+			auctions: []string{"a1"},
+		},
+		{name: "page size too small", pageSize: 0, err: "the page size must be at least 1"},
+		{name: "unknown status", status: "sold", pageSize: 2, err: "unknown auction status sold, use open, closed or ended"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, ms := setupStub(seller, time.Now())
+			for _, query := range tt.queries {
+				metadata := &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(query.results)), Bookmark: query.next}
+				ms.On("GetStateByRangeWithPagination", "", "", query.pageSize, query.bookmark).Return(&MockIterator{results: query.results}, metadata, nil).Once()
+			}
+
+			auctionPage, err := new(SmartContract).ListAuctions(ctx, tt.status, tt.filter, tt.pageSize, tt.bookmark)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			auctionIDs := []string{}
+			for _, record := range auctionPage.Records {
+				auctionIDs = append(auctionIDs, record.AuctionID)
+			}
+			require.Equal(t, tt.auctions, auctionIDs)
+			require.Equal(t, int32(len(tt.auctions)), auctionPage.FetchedRecordsCount)
+			require.Equal(t, tt.next, auctionPage.Bookmark)
+			ms.AssertNumberOfCalls(t, "GetStateByRangeWithPagination", len(tt.queries))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// lockDeposit is an internal function that locks the deposit of a bid in an
+// escrow of the token chaincode, identified by the transaction ID of the bid.
+// The token chaincode is called with the identity of the bidder, so the
+// deposit is paid from the bidder's account
+func lockDeposit(ctx contractapi.TransactionContextInterface, auction *Auction, txID string) error {
+	return invokeTokenChaincode(ctx, auction.TokenChaincode, "LockEscrow", txID, strconv.Itoa(auction.Deposit))
+}
+
+// refundDeposit is an internal function that returns the deposit of a bid to
+// the bidder
+func refundDeposit(ctx contractapi.TransactionContextInterface, auction *Auction, txID string) error {
+	return invokeTokenChaincode(ctx, auction.TokenChaincode, "RefundEscrow", txID)
+}
+
+// settleUnrevealedDeposits is an internal function that pays the deposits of
+// the bids that were not revealed to the seller if forfeit is true, or returns
+// them to the bidders otherwise
+func settleUnrevealedDeposits(ctx contractapi.TransactionContextInterface, auction *Auction, forfeit bool) error {
+
+	// the token chaincode identifies accounts by the base64 encoded client ID
+	sellerAccount := base64.StdEncoding.EncodeToString([]byte(auction.Seller))
+
+	// settle the deposits in the order of the bid keys, so that every peer
+	// calls the token chaincode in the same order
+	var bidKeys []string
+	for bidKey := range auction.PrivateBids {
+		if _, revealed := auction.RevealedBids[bidKey]; !revealed {
+			bidKeys = append(bidKeys, bidKey)
+		}
+	}
+	sort.Strings(bidKeys)
+
+	for _, bidKey := range bidKeys {
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(bidKey)
+		if err != nil {
+			return fmt.Errorf("failed to split composite key: %v", err)
+		}
+		txID := keyParts[1]
+
+		if forfeit {
+			err = invokeTokenChaincode(ctx, auction.TokenChaincode, "ReleaseEscrow", txID, sellerAccount)
+		} else {
+			err = refundDeposit(ctx, auction, txID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// invokeTokenChaincode is an internal function that calls a function of the
+// ERC-20 token chaincode on the channel of the auction
+func invokeTokenChaincode(ctx contractapi.TransactionContextInterface, chaincodeName string, function string, args ...string) error {
+
+	invokeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+
+	response := ctx.GetStub().InvokeChaincode(chaincodeName, invokeArgs, "")
+	if response.Status != shim.OK {
+		return fmt.Errorf("failed to call %s on token chaincode %s: %s", function, chaincodeName, response.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// The settlement rules of an auction. Under both rules the highest bid wins. The
+// winner pays their own bid under firstPrice, and the second highest bid under
+// secondPrice
+const (
+	firstPrice  = "firstPrice"
+	secondPrice = "secondPrice"
+)
+
+const reserveKeyType = "reserve"
+
+// ReservePrice is the structure of the reserve price that a seller commits to
+// in private when creating an auction. The salt makes the hash of the reserve
+// price impossible to guess
+type ReservePrice struct {
+	Type  string `json:"objectType"`
+	Price int    `json:"price"`
+	Salt  string `json:"salt"`
+}
+
+// RevealReserve is used by the seller to reveal the reserve price that they
+// committed to when creating the auction. The reserve price can be revealed
+// after the auction is closed, until the reveal deadline. An auction whose
+// reserve price is not revealed ends without a sale
+func (s *SmartContract) RevealReserve(ctx contractapi.TransactionContextInterface, auctionID string) error {
+
+	// get reserve price from transient map
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient: %v", err)
+	}
+
+	transientReserveJSON, ok := transientMap["reserve"]
+	if !ok {
+		return errors.New("reserve key not found in the transient map")
+	}
+
+	// get auction from public state
+	auction, err := s.QueryAuction(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to get auction from public state %v", err)
+	}
+
+	// get ID of submitting client
+	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	if auction.Seller != clientID {
+		return errors.New("reserve price can only be revealed by seller")
+	}
+
+	if auction.ReserveHash == "" {
+		return errors.New("auction has no reserve price")
+	}
+	if auction.Status != "closed" {
+		return errors.New("cannot reveal reserve price for open or ended auction")
+	}
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !now.Before(auction.RevealDeadline) {
+		return fmt.Errorf("cannot reveal reserve price after the reveal deadline %v", auction.RevealDeadline)
+	}
+
+	// check that the hash of the revealed reserve price matches the hash that
+	// was committed to when the auction was created
+	hash := sha256.Sum256(transientReserveJSON)
+	if fmt.Sprintf("%x", hash) != auction.ReserveHash {
+		return fmt.Errorf("hash %x for reserve JSON %s does not match hash in auction: %s",
+			hash,
+			transientReserveJSON,
+			auction.ReserveHash,
+		)
+	}
+
+	var reserve ReservePrice
+	err = json.Unmarshal(transientReserveJSON, &reserve)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+
+	auction.Reserve = reserve.Price
+	auction.ReserveRevealed = true
+
+	newAuctionJSON, _ := json.Marshal(auction)
+
+	err = ctx.GetStub().PutState(auctionID, newAuctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to update auction: %v", err)
+	}
+
+	return nil
+}
+
+// QueryReserve allows the seller to read the reserve price of their auction
+// from private state
+func (s *SmartContract) QueryReserve(ctx contractapi.TransactionContextInterface, auctionID string) (*ReservePrice, error) {
+
+	err := verifyClientOrgMatchesPeerOrg(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get implicit collection name: %v", err)
+	}
+
+	auction, err := s.QueryAuction(ctx, auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auction from public state %v", err)
+	}
+
+	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity %v", err)
+	}
+	if auction.Seller != clientID {
+		return nil, fmt.Errorf("permission denied, client id %v is not the seller of the auction", clientID)
+	}
+
+	collection, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get implicit collection name: %v", err)
+	}
+
+	reserveKey, err := ctx.GetStub().CreateCompositeKey(reserveKeyType, []string{auctionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+
+	reserveJSON, err := ctx.GetStub().GetPrivateData(collection, reserveKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserve price %v: %v", reserveKey, err)
+	}
+	if reserveJSON == nil {
+		return nil, fmt.Errorf("reserve price %v does not exist", reserveKey)
+	}
+
+	var reserve *ReservePrice
+	err = json.Unmarshal(reserveJSON, &reserve)
+	if err != nil {
+		return nil, err
+	}
+
+	return reserve, nil
+}
+
+// commitReserve is an internal function that stores the reserve price of a new
+// auction in the implicit data collection of the seller's organization, and
+// returns its hash for the public auction
+func commitReserve(ctx contractapi.TransactionContextInterface, auctionID string, reserveJSON []byte) (string, error) {
+
+	var reserve ReservePrice
+	err := json.Unmarshal(reserveJSON, &reserve)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+	if reserve.Price < 0 {
+		return "", errors.New("the reserve price must not be negative")
+	}
+	if len(reserve.Salt) < minSaltLength {
+		return "", fmt.Errorf("the reserve price needs a random salt of at least %d characters", minSaltLength)
+	}
+
+	// the seller has to target their peer to store the reserve price
+	err = verifyClientOrgMatchesPeerOrg(ctx)
+	if err != nil {
+		return "", fmt.Errorf("cannot store reserve price on this peer, not a member of this org: Error %v", err)
+	}
+
+	collection, err := getCollectionName(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get implicit collection name: %v", err)
+	}
+
+	reserveKey, err := ctx.GetStub().CreateCompositeKey(reserveKeyType, []string{auctionID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create composite key: %v", err)
+	}
+
+	err = ctx.GetStub().PutPrivateData(collection, reserveKey, reserveJSON)
+	if err != nil {
+		return "", fmt.Errorf("failed to input reserve price into collection: %v", err)
+	}
+
+	hash := sha256.Sum256(reserveJSON)
+	return fmt.Sprintf("%x", hash), nil
+}
+
+// settleAuction is an internal function that determines the winner of the
+// auction and the price they pay under the settlement rule of the auction. A
+// revealed reserve price is the lowest price at which the item is sold. It
+// returns the price that an unrevealed bid would need to exceed to change the
+// outcome of the auction
+func settleAuction(auction *Auction) int {
+
+	// the item is not sold if the seller committed to a reserve price and did
+	// not reveal it, so that the seller cannot choose whether the reserve
+	// price applies once they have seen the bids. No bid changes the outcome
+	if reserveWithheld(auction) {
+		auction.Winner = ""
+		auction.Price = 0
+		return math.MaxInt
+	}
+
+	// sort the revealed bids by price. Bids are visited in the order of their
+	// keys, so that every peer breaks ties the same way
+	var bidKeys []string
+	for bidKey := range auction.RevealedBids {
+		bidKeys = append(bidKeys, bidKey)
+	}
+	sort.Strings(bidKeys)
+
+	bids := make([]FullBid, len(bidKeys))
+	for i, bidKey := range bidKeys {
+		bids[i] = auction.RevealedBids[bidKey]
+	}
+	sort.SliceStable(bids, func(p, q int) bool {
+		return bids[p].Price > bids[q].Price
+	})
+
+	reserve := auction.Reserve
+
+	// the item is not sold if no bid meets the reserve price
+	if len(bids) == 0 || bids[0].Price < reserve {
+		auction.Winner = ""
+		auction.Price = 0
+		return reserve - 1
+	}
+
+	auction.Winner = bids[0].Bidder
+	auction.Price = bids[0].Price
+
+	// under the second price rule, the winner pays the second highest bid, or
+	// the reserve price if it is higher. A single bidder pays the reserve
+	// price, or their own bid if there is none
+	if auction.Settlement == secondPrice {
+		if len(bids) > 1 {
+			auction.Price = max(bids[1].Price, reserve)
+		} else if auction.ReserveHash != "" {
+			auction.Price = reserve
+		}
+	}
+
+	return auction.Price
+}
+
+// reserveWithheld is an internal function that reports whether the seller
+// committed to a reserve price that they have not revealed
+func reserveWithheld(auction *Auction) bool {
+	return auction.ReserveHash != "" && !auction.ReserveRevealed
+}
@@ -0,0 +1,78 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettleAuction(t *testing.T) {
+	bids := func(prices ...int) map[string]FullBid {
+		revealedBids := make(map[string]FullBid)
+		for i, price := range prices {
+			bidder := string(rune('a' + i))
+			revealedBids["bid"+bidder] = FullBid{Price: price, Bidder: bidder}
+		}
+		return revealedBids
+	}
+
+	tests := []struct {
+		name      string
+		auction   Auction
+		winner    string
+		price     int
+		threshold int
+	}{
+		{name: "first price", auction: Auction{Settlement: firstPrice, RevealedBids: bids(500, 800, 600)}, winner: "b", price: 800, threshold: 800},
+		{name: "second price", auction: Auction{Settlement: secondPrice, RevealedBids: bids(500, 800, 600)}, winner: "b", price: 600, threshold: 600},
+		{name: "second price single bid", auction: Auction{Settlement: secondPrice, RevealedBids: bids(500)}, winner: "a", price: 500, threshold: 500},
+		{name: "tie broken by bid key", auction: Auction{Settlement: firstPrice, RevealedBids: bids(700, 700)}, winner: "a", price: 700, threshold: 700},
+		{name: "no bids", auction: Auction{Settlement: firstPrice, RevealedBids: bids()}, threshold: -1},
+		{
+			name:      "reserve met",
+			auction:   Auction{Settlement: firstPrice, RevealedBids: bids(500, 800), ReserveHash: "hash", Reserve: 700, ReserveRevealed: true},
+			winner:    "b",
+			price:     800,
+			threshold: 800,
+		},
+		{
+			name:      "reserve not met",
+			auction:   Auction{Settlement: firstPrice, RevealedBids: bids(500, 600), ReserveHash: "hash", Reserve: 700, ReserveRevealed: true},
+			threshold: 699,
+		},
+		{
+			name:      "second price raised to reserve",
+			auction:   Auction{Settlement: secondPrice, RevealedBids: bids(500, 800), ReserveHash: "hash", Reserve: 700, ReserveRevealed: true},
+			winner:    "b",
+			price:     700,
+			threshold: 700,
+		},
+		{
+			name:      "second price single bid pays reserve",
+			auction:   Auction{Settlement: secondPrice, RevealedBids: bids(800), ReserveHash: "hash", Reserve: 700, ReserveRevealed: true},
+			winner:    "a",
+			price:     700,
+			threshold: 700,
+		},
+		{
+			name:      "reserve withheld",
+			auction:   Auction{Settlement: secondPrice, RevealedBids: bids(500, 800), ReserveHash: "hash"},
+			threshold: math.MaxInt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auction := tt.auction
+			threshold := settleAuction(&auction)
+			require.Equal(t, tt.winner, auction.Winner)
+			require.Equal(t, tt.price, auction.Price)
+			require.Equal(t, tt.threshold, threshold)
+		})
+	}
+}
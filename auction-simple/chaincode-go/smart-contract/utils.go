@@ -6,7 +6,9 @@ package auction
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/statebased"
 	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
@@ -111,6 +113,72 @@ func verifyClientOrgMatchesPeerOrg(ctx contractapi.TransactionContextInterface)
 	return nil
 }
 
+// verifyClientOrgIsParticipant is an internal function used to verify that the
+// client belongs to one of the organizations participating in the auction
+func verifyClientOrgIsParticipant(ctx contractapi.TransactionContextInterface, orgs []string) error {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed getting the client's MSPID: %v", err)
+	}
+
+	if !contains(orgs, clientMSPID) {
+		return fmt.Errorf("participant %s is not a member of the auction", clientMSPID)
+	}
+
+	return nil
+}
+
+// getTxTime is an internal function that returns the timestamp of the
+// transaction. The timestamp is set by the client that creates the transaction,
+// so that all endorsing peers use the same time
+func getTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+
+	return timestamp.AsTime(), nil
+}
+
+// parseDeadlines is an internal function that parses the bidding and reveal
+// deadlines of a new auction, and checks that they are in order and in the future
+func parseDeadlines(now time.Time, biddingDeadline string, revealDeadline string) (time.Time, time.Time, error) {
+	biddingTime, err := time.Parse(time.RFC3339, biddingDeadline)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("bidding deadline %q is not an RFC 3339 timestamp", biddingDeadline)
+	}
+	revealTime, err := time.Parse(time.RFC3339, revealDeadline)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("reveal deadline %q is not an RFC 3339 timestamp", revealDeadline)
+	}
+
+	if !biddingTime.After(now) {
+		return time.Time{}, time.Time{}, fmt.Errorf("bidding deadline %v has already passed", biddingDeadline)
+	}
+	if !revealTime.After(biddingTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("reveal deadline %v must be after the bidding deadline %v", revealDeadline, biddingDeadline)
+	}
+
+	return biddingTime.UTC(), revealTime.UTC(), nil
+}
+
+// setAuctionEvent is an internal function that sets the chaincode event of the
+// transaction, so that clients can follow the auction without polling it. A
+// transaction only emits the last event that it sets
+func setAuctionEvent(ctx contractapi.TransactionContextInterface, eventName string, auctionID string, auction *Auction) error {
+	eventJSON, err := json.Marshal(AuctionRecord{AuctionID: auctionID, Auction: auction})
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetEvent(eventName, eventJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set event %v: %v", eventName, err)
+	}
+
+	return nil
+}
+
 func contains(sli []string, str string) bool {
 	for _, a := range sli {
 		if a == str {
@@ -0,0 +1,319 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// Define objectType names for prefix
+const escrowPrefix = "escrow"
+const escrowAccountPrefix = "escrowAccount"
+
+// Key of the list of chaincodes that can lock and release escrows
+const escrowChaincodesKey = "escrowChaincodes"
+
+// Escrow holds tokens that a chaincode locked on behalf of their owner, for example the deposit of a bid
+// in an auction. The tokens are kept in the escrow account of the chaincode, and only that chaincode can
+// release them
+type Escrow struct {
+	ID        string `json:"id"`
+	Owner     string `json:"owner"`
+	Value     int    `json:"value"`
+	Chaincode string `json:"chaincode"`
+}
+
+// LockEscrow moves value tokens from the client account into an escrow identified by escrowID
+// LockEscrow can only be called by an escrow chaincode, which becomes the only chaincode that can release the escrow
+func (s *SmartContract) LockEscrow(ctx contractapi.TransactionContextInterface, escrowID string, value int) error {
+
+	// Check if contract has been intilized first
+	initialized, err := checkInitialized(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check if contract is already initialized: %v", err)
+	}
+	if !initialized {
+		return fmt.Errorf("Contract options need to be set before calling any function, call Initialize() to initialize contract")
+	}
+
+	if value <= 0 {
+		return fmt.Errorf("escrow value must be a positive integer")
+	}
+
+	chaincodeName, err := escrowChaincode(ctx)
+	if err != nil {
+		return err
+	}
+
+	escrowKey, err := ctx.GetStub().CreateCompositeKey(escrowPrefix, []string{chaincodeName, escrowID})
+	if err != nil {
+		return fmt.Errorf("failed to create the composite key for prefix %s: %v", escrowPrefix, err)
+	}
+	existingEscrowJSON, err := ctx.GetStub().GetState(escrowKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existingEscrowJSON != nil {
+		return fmt.Errorf("the escrow %s already exists", escrowID)
+	}
+
+	// Get ID of submitting client identity
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client id: %v", err)
+	}
+
+	escrowAccount, err := ctx.GetStub().CreateCompositeKey(escrowAccountPrefix, []string{chaincodeName})
+	if err != nil {
+		return fmt.Errorf("failed to create the composite key for prefix %s: %v", escrowAccountPrefix, err)
+	}
+	err = transferHelper(ctx, clientID, escrowAccount, value)
+	if err != nil {
+		return fmt.Errorf("failed to transfer: %v", err)
+	}
+
+	escrow := Escrow{
+		ID:        escrowID,
+		Owner:     clientID,
+		Value:     value,
+		Chaincode: chaincodeName,
+	}
+	escrowJSON, err := json.Marshal(escrow)
+	if err != nil {
+		return fmt.Errorf("failed to obtain JSON encoding: %v", err)
+	}
+	err = ctx.GetStub().PutState(escrowKey, escrowJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put escrow to world state: %v", err)
+	}
+
+	log.Printf("chaincode %s locked %d of client %s in escrow %s", chaincodeName, value, clientID, escrowID)
+
+	return nil
+}
+
+// ReleaseEscrow pays the tokens of an escrow to the recipient account and removes the escrow
+// ReleaseEscrow can only be called by the chaincode that locked the escrow
+func (s *SmartContract) ReleaseEscrow(ctx contractapi.TransactionContextInterface, escrowID string, recipient string) error {
+	return releaseEscrow(ctx, escrowID, recipient, -1)
+}
+
+// RefundEscrow returns the tokens of an escrow to their owner and removes the escrow
+// RefundEscrow can only be called by the chaincode that locked the escrow
+func (s *SmartContract) RefundEscrow(ctx contractapi.TransactionContextInterface, escrowID string) error {
+	return releaseEscrow(ctx, escrowID, "", 0)
+}
+
+// SettleEscrow pays value tokens of an escrow to the recipient account, returns the rest to their owner
+// and removes the escrow, for example to pay the price of a winning bid from the funds locked with the bid
+// SettleEscrow can only be called by the chaincode that locked the escrow
+func (s *SmartContract) SettleEscrow(ctx contractapi.TransactionContextInterface, escrowID string, recipient string, value int) error {
+
+	if value < 0 {
+		return fmt.Errorf("settled value must not be negative")
+	}
+
+	return releaseEscrow(ctx, escrowID, recipient, value)
+}
+
+// SetEscrowChaincodes sets the chaincodes that can lock and release escrows, such as the auction chaincodes of
+// the channel, and replaces the chaincodes set before. An escrow belongs to the chaincode that the transaction
+// proposal invoked, which has to be one of them
+func (s *SmartContract) SetEscrowChaincodes(ctx contractapi.TransactionContextInterface, chaincodes []string) error {
+
+	// Check if contract has been intilized first
+	initialized, err := checkInitialized(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check if contract is already initialized: %v", err)
+	}
+	if !initialized {
+		return fmt.Errorf("Contract options need to be set before calling any function, call Initialize() to initialize contract")
+	}
+
+	// Check minter authorization - this sample assumes Org1 is the central banker with privilege to name the escrow chaincodes
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	if clientMSPID != "Org1MSP" {
+		return fmt.Errorf("client is not authorized to set the escrow chaincodes")
+	}
+
+	chaincodesJSON, err := json.Marshal(chaincodes)
+	if err != nil {
+		return fmt.Errorf("failed to obtain JSON encoding: %v", err)
+	}
+	err = ctx.GetStub().PutState(escrowChaincodesKey, chaincodesJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put escrow chaincodes to world state: %v", err)
+	}
+
+	log.Printf("escrow chaincodes set to %v", chaincodes)
+
+	return nil
+}
+
+// GetEscrowChaincodes returns the chaincodes that can lock and release escrows
+func (s *SmartContract) GetEscrowChaincodes(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	return readEscrowChaincodes(ctx)
+}
+
+// GetEscrow returns the escrow that the given chaincode locked under escrowID
+func (s *SmartContract) GetEscrow(ctx contractapi.TransactionContextInterface, chaincodeName string, escrowID string) (*Escrow, error) {
+
+	escrowKey, err := ctx.GetStub().CreateCompositeKey(escrowPrefix, []string{chaincodeName, escrowID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the composite key for prefix %s: %v", escrowPrefix, err)
+	}
+
+	escrowJSON, err := ctx.GetStub().GetState(escrowKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if escrowJSON == nil {
+		return nil, fmt.Errorf("the escrow %s does not exist", escrowID)
+	}
+
+	var escrow Escrow
+	err = json.Unmarshal(escrowJSON, &escrow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain JSON decoding: %v", err)
+	}
+
+	return &escrow, nil
+}
+
+// releaseEscrow is a helper function that pays value tokens of an escrow to the recipient, and the rest to
+// the owner of the escrow. A negative value pays all tokens of the escrow to the recipient
+func releaseEscrow(ctx contractapi.TransactionContextInterface, escrowID string, recipient string, value int) error {
+
+	// Check if contract has been intilized first
+	initialized, err := checkInitialized(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check if contract is already initialized: %v", err)
+	}
+	if !initialized {
+		return fmt.Errorf("Contract options need to be set before calling any function, call Initialize() to initialize contract")
+	}
+
+	chaincodeName, err := escrowChaincode(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The escrow is looked up under the invoking chaincode, so a chaincode can only release its own escrows
+	escrowKey, err := ctx.GetStub().CreateCompositeKey(escrowPrefix, []string{chaincodeName, escrowID})
+	if err != nil {
+		return fmt.Errorf("failed to create the composite key for prefix %s: %v", escrowPrefix, err)
+	}
+	escrowJSON, err := ctx.GetStub().GetState(escrowKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if escrowJSON == nil {
+		return fmt.Errorf("the escrow %s does not exist", escrowID)
+	}
+
+	var escrow Escrow
+	err = json.Unmarshal(escrowJSON, &escrow)
+	if err != nil {
+		return fmt.Errorf("failed to obtain JSON decoding: %v", err)
+	}
+	if value < 0 {
+		value = escrow.Value
+	}
+	if value > escrow.Value {
+		return fmt.Errorf("the escrow %s holds %d, which is less than %d", escrowID, escrow.Value, value)
+	}
+
+	escrowAccount, err := ctx.GetStub().CreateCompositeKey(escrowAccountPrefix, []string{chaincodeName})
+	if err != nil {
+		return fmt.Errorf("failed to create the composite key for prefix %s: %v", escrowAccountPrefix, err)
+	}
+	if value > 0 {
+		err = transferHelper(ctx, escrowAccount, recipient, value)
+		if err != nil {
+			return fmt.Errorf("failed to transfer: %v", err)
+		}
+	}
+	if value < escrow.Value {
+		err = transferHelper(ctx, escrowAccount, escrow.Owner, escrow.Value-value)
+		if err != nil {
+			return fmt.Errorf("failed to transfer: %v", err)
+		}
+	}
+
+	err = ctx.GetStub().DelState(escrowKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete escrow from world state: %v", err)
+	}
+
+	log.Printf("chaincode %s released %d of escrow %s to %s and %d to %s", chaincodeName, value, escrowID, recipient, escrow.Value-value, escrow.Owner)
+
+	return nil
+}
+
+// escrowChaincode returns the name of the chaincode that the transaction proposal invoked, which owns the
+// escrows that the transaction locks and releases. The chaincode has to be one of the escrow chaincodes set
+// with SetEscrowChaincodes, so that clients cannot lock or release escrows by invoking this contract directly,
+// or through a chaincode that is not trusted to hold escrows. A chaincode on the list answers for the escrows
+// of every chaincode it calls on the way to this contract.
+func escrowChaincode(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	signedProposal, err := ctx.GetStub().GetSignedProposal()
+	if err != nil {
+		return "", fmt.Errorf("failed to get signed proposal: %v", err)
+	}
+
+	proposal := &peer.Proposal{}
+	err = proto.Unmarshal(signedProposal.GetProposalBytes(), proposal)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal proposal: %v", err)
+	}
+	payload := &peer.ChaincodeProposalPayload{}
+	err = proto.Unmarshal(proposal.GetPayload(), payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal proposal payload: %v", err)
+	}
+	invocationSpec := &peer.ChaincodeInvocationSpec{}
+	err = proto.Unmarshal(payload.GetInput(), invocationSpec)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal chaincode invocation spec: %v", err)
+	}
+	chaincodeName := invocationSpec.GetChaincodeSpec().GetChaincodeId().GetName()
+
+	chaincodes, err := readEscrowChaincodes(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range chaincodes {
+		if name == chaincodeName {
+			return chaincodeName, nil
+		}
+	}
+
+	return "", fmt.Errorf("chaincode %s is not allowed to lock and release escrows", chaincodeName)
+}
+
+// readEscrowChaincodes is a helper function that returns the chaincodes that can lock and release escrows
+func readEscrowChaincodes(ctx contractapi.TransactionContextInterface) ([]string, error) {
+
+	chaincodesJSON, err := ctx.GetStub().GetState(escrowChaincodesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	chaincodes := []string{}
+	if chaincodesJSON == nil {
+		return chaincodes, nil
+	}
+	err = json.Unmarshal(chaincodesJSON, &chaincodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain JSON decoding: %v", err)
+	}
+
+	return chaincodes, nil
+}
@@ -0,0 +1,291 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+)
+
+const bidder = "x509::CN=bidder,OU=client,O=Hyperledger,ST=North Carolina,C=US::CN=ca.org1.example.com,O=org1.example.com,L=Durham,ST=North Carolina,C=US"
+const seller = "x509::CN=seller,OU=client,O=Hyperledger,ST=North Carolina,C=US::CN=ca.org2.example.com,O=org2.example.com,L=Hursley,ST=Hampshire,C=UK"
+
+// MockStub keeps the world state in a map, so that the balances moved by an escrow can be checked
+type MockStub struct {
+	shim.ChaincodeStubInterface
+	mock.Mock
+	state map[string][]byte
+}
+
+func (ms *MockStub) GetState(key string) ([]byte, error) {
+	return ms.state[key], nil
+}
+
+func (ms *MockStub) PutState(key string, value []byte) error {
+	ms.state[key] = value
+	return nil
+}
+
+func (ms *MockStub) DelState(key string) error {
+	delete(ms.state, key)
+	return nil
+}
+
+func (ms *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (ms *MockStub) GetSignedProposal() (*peer.SignedProposal, error) {
+	args := ms.Called()
+	return args.Get(0).(*peer.SignedProposal), args.Error(1)
+}
+
+type MockClientIdentity struct {
+	cid.ClientIdentity
+	mock.Mock
+}
+
+func (mci *MockClientIdentity) GetID() (string, error) {
+	args := mci.Called()
+	return args.Get(0).(string), args.Error(1)
+}
+
+func (mci *MockClientIdentity) GetMSPID() (string, error) {
+	args := mci.Called()
+	return args.Get(0).(string), args.Error(1)
+}
+
+type MockContext struct {
+	contractapi.TransactionContextInterface
+	mock.Mock
+}
+
+func (mc *MockContext) GetStub() shim.ChaincodeStubInterface {
+	args := mc.Called()
+	return args.Get(0).(*MockStub)
+}
+
+func (mc *MockContext) GetClientIdentity() cid.ClientIdentity {
+	args := mc.Called()
+	return args.Get(0).(*MockClientIdentity)
+}
+
+// signedProposal returns a proposal that invokes the chaincode with the given arguments
+func signedProposal(t *testing.T, chaincodeName string, args ...string) *peer.SignedProposal {
+	input := &peer.ChaincodeInput{}
+	for _, arg := range args {
+		input.Args = append(input.Args, []byte(arg))
+	}
+	invocationSpec, err := proto.Marshal(&peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{ChaincodeId: &peer.ChaincodeID{Name: chaincodeName}, Input: input},
+	})
+	assert.NoError(t, err)
+	payload, err := proto.Marshal(&peer.ChaincodeProposalPayload{Input: invocationSpec})
+	assert.NoError(t, err)
+	proposal, err := proto.Marshal(&peer.Proposal{Payload: payload})
+	assert.NoError(t, err)
+	return &peer.SignedProposal{ProposalBytes: proposal}
+}
+
+// setupEscrowStub returns a context of the bidder calling this contract from a proposal that invoked
+// chaincodeName with proposalArgs. The auction and market chaincodes can hold escrows, and the bidder holds
+// 150 tokens, besides the 100 tokens that the auction chaincode locked in the escrow bid1
+func setupEscrowStub(t *testing.T, chaincodeName string, proposalArgs ...string) (*MockContext, *MockStub) {
+	ms := &MockStub{state: map[string][]byte{}}
+	ms.state[nameKey] = []byte("token")
+	ms.state[escrowChaincodesKey] = []byte(`["auction","market"]`)
+	ms.state[bidder] = []byte("150")
+
+	escrowKey, err := shim.CreateCompositeKey(escrowPrefix, []string{"auction", "bid1"})
+	assert.NoError(t, err)
+	escrowJSON, err := json.Marshal(Escrow{ID: "bid1", Owner: bidder, Value: 100, Chaincode: "auction"})
+	assert.NoError(t, err)
+	ms.state[escrowKey] = escrowJSON
+	ms.state[escrowAccount(t, "auction")] = []byte("100")
+
+	ms.On("GetSignedProposal").Return(signedProposal(t, chaincodeName, proposalArgs...), nil)
+
+	mci := new(MockClientIdentity)
+	mci.On("GetID").Return(bidder, nil)
+	mci.On("GetMSPID").Return("Org2MSP", nil)
+
+	ctx := new(MockContext)
+	ctx.On("GetStub").Return(ms)
+	ctx.On("GetClientIdentity").Return(mci)
+	return ctx, ms
+}
+
+// escrowAccount returns the account that holds the escrows of the chaincode
+func escrowAccount(t *testing.T, chaincodeName string) string {
+	account, err := shim.CreateCompositeKey(escrowAccountPrefix, []string{chaincodeName})
+	assert.NoError(t, err)
+	return account
+}
+
+func TestLockEscrow(t *testing.T) {
+	ctx, ms := setupEscrowStub(t, "auction", "SubmitBid", "auction1")
+	c := new(SmartContract)
+
+	err := c.LockEscrow(ctx, "bid2", 40)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("110"), ms.state[bidder])
+	assert.Equal(t, []byte("140"), ms.state[escrowAccount(t, "auction")])
+
+	escrow, err := c.GetEscrow(ctx, "auction", "bid2")
+	assert.NoError(t, err)
+	assert.Equal(t, &Escrow{ID: "bid2", Owner: bidder, Value: 40, Chaincode: "auction"}, escrow)
+
+	err = c.LockEscrow(ctx, "bid1", 40)
+	assert.EqualError(t, err, "the escrow bid1 already exists")
+
+	err = c.LockEscrow(ctx, "bid3", 0)
+	assert.EqualError(t, err, "escrow value must be a positive integer")
+}
+
+func TestEscrowDirectCall(t *testing.T) {
+	c := new(SmartContract)
+	const directCall = "chaincode token_erc20 is not allowed to lock and release escrows"
+
+	ctx, ms := setupEscrowStub(t, "token_erc20", "LockEscrow", "bid2", "40")
+	err := c.LockEscrow(ctx, "bid2", 40)
+	assert.EqualError(t, err, directCall)
+	assert.Equal(t, []byte("150"), ms.state[bidder])
+
+	ctx, ms = setupEscrowStub(t, "token_erc20", "ReleaseEscrow", "bid1", seller)
+	err = c.ReleaseEscrow(ctx, "bid1", seller)
+	assert.EqualError(t, err, directCall)
+	assert.Nil(t, ms.state[seller])
+
+	ctx, _ = setupEscrowStub(t, "token_erc20", "SettleEscrow", "bid1", seller, "60")
+	err = c.SettleEscrow(ctx, "bid1", seller, 60)
+	assert.EqualError(t, err, directCall)
+
+	ctx, _ = setupEscrowStub(t, "token_erc20", "RefundEscrow", "bid1")
+	err = c.RefundEscrow(ctx, "bid1")
+	assert.EqualError(t, err, directCall)
+}
+
+func TestEscrowThroughProxy(t *testing.T) {
+	c := new(SmartContract)
+
+	// A proxy that is not an escrow chaincode cannot lock escrows, nor release them on behalf of the auction
+	ctx, ms := setupEscrowStub(t, "proxy", "Forward", "auction", "ReleaseEscrow", "bid1", seller)
+	err := c.LockEscrow(ctx, "bid2", 40)
+	assert.EqualError(t, err, "chaincode proxy is not allowed to lock and release escrows")
+	err = c.ReleaseEscrow(ctx, "bid1", seller)
+	assert.EqualError(t, err, "chaincode proxy is not allowed to lock and release escrows")
+	assert.Nil(t, ms.state[seller])
+	assert.Equal(t, []byte("100"), ms.state[escrowAccount(t, "auction")])
+
+	// The auction calling the token through a proxy owns the escrows, not the proxy
+	ctx, ms = setupEscrowStub(t, "auction", "SubmitBid", "auction1")
+	err = c.LockEscrow(ctx, "bid2", 40)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("140"), ms.state[escrowAccount(t, "auction")])
+	_, err = c.GetEscrow(ctx, "proxy", "bid2")
+	assert.EqualError(t, err, "the escrow bid2 does not exist")
+}
+
+func TestReleaseEscrowByOtherChaincode(t *testing.T) {
+	ctx, ms := setupEscrowStub(t, "market", "Buy", "item1")
+	c := new(SmartContract)
+
+	err := c.ReleaseEscrow(ctx, "bid1", seller)
+	assert.EqualError(t, err, "the escrow bid1 does not exist")
+	assert.Nil(t, ms.state[seller])
+	assert.Equal(t, []byte("100"), ms.state[escrowAccount(t, "auction")])
+
+	_, err = c.GetEscrow(ctx, "auction", "bid1")
+	assert.NoError(t, err)
+}
+
+func TestReleaseEscrow(t *testing.T) {
+	ctx, ms := setupEscrowStub(t, "auction", "EndAuction", "auction1")
+	c := new(SmartContract)
+
+	err := c.ReleaseEscrow(ctx, "bid1", seller)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("100"), ms.state[seller])
+	assert.Equal(t, []byte("150"), ms.state[bidder])
+	assert.Equal(t, []byte("0"), ms.state[escrowAccount(t, "auction")])
+
+	_, err = c.GetEscrow(ctx, "auction", "bid1")
+	assert.EqualError(t, err, "the escrow bid1 does not exist")
+}
+
+func TestSettleEscrow(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         int
+		sellerBalance []byte
+		bidderBalance []byte
+		err           string
+	}{
+		{name: "partial", value: 60, sellerBalance: []byte("60"), bidderBalance: []byte("190")},
+		{name: "whole", value: 100, sellerBalance: []byte("100"), bidderBalance: []byte("150")},
+		{name: "nothing", value: 0, bidderBalance: []byte("250")},
+		{name: "more than locked", value: 120, bidderBalance: []byte("150"), err: "the escrow bid1 holds 100, which is less than 120"},
+		{name: "negative", value: -1, bidderBalance: []byte("150"), err: "settled value must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, ms := setupEscrowStub(t, "auction", "EndAuction", "auction1")
+			c := new(SmartContract)
+
+			err := c.SettleEscrow(ctx, "bid1", seller, tt.value)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				assert.Equal(t, []byte("100"), ms.state[escrowAccount(t, "auction")])
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("0"), ms.state[escrowAccount(t, "auction")])
+			}
+			assert.Equal(t, tt.sellerBalance, ms.state[seller])
+			assert.Equal(t, tt.bidderBalance, ms.state[bidder])
+		})
+	}
+}
+
+func TestRefundEscrow(t *testing.T) {
+	ctx, ms := setupEscrowStub(t, "auction", "EndAuction", "auction1")
+	c := new(SmartContract)
+
+	err := c.RefundEscrow(ctx, "bid1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("250"), ms.state[bidder])
+	assert.Equal(t, []byte("0"), ms.state[escrowAccount(t, "auction")])
+
+	err = c.RefundEscrow(ctx, "bid1")
+	assert.EqualError(t, err, "the escrow bid1 does not exist")
+}
+
+func TestSetEscrowChaincodes(t *testing.T) {
+	ctx, ms := setupEscrowStub(t, "token_erc20", "SetEscrowChaincodes", `["auction"]`)
+	c := new(SmartContract)
+
+	err := c.SetEscrowChaincodes(ctx, []string{"auction"})
+	assert.EqualError(t, err, "client is not authorized to set the escrow chaincodes")
+
+	mci := new(MockClientIdentity)
+	mci.On("GetMSPID").Return("Org1MSP", nil)
+	ctx = new(MockContext)
+	ctx.On("GetStub").Return(ms)
+	ctx.On("GetClientIdentity").Return(mci)
+
+	err = c.SetEscrowChaincodes(ctx, []string{"auction"})
+	assert.NoError(t, err)
+	chaincodes, err := c.GetEscrowChaincodes(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"auction"}, chaincodes)
+
+	delete(ms.state, escrowChaincodesKey)
+	chaincodes, err = c.GetEscrowChaincodes(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, chaincodes)
+}
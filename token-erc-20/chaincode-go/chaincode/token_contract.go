@@ -18,9 +18,20 @@ const totalSupplyKey = "totalSupply"
 
 // Define objectType names for prefix
 const allowancePrefix = "allowance"
+const paymentPrefix = "payment"
 
 // Define key names for options
 
+// Payment is the receipt of a transfer made with Pay, kept so other contracts can check what a
+// transaction paid and for what
+type Payment struct {
+	TxID      string `json:"txID"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Value     int    `json:"value"`
+	Reference string `json:"reference"`
+}
+
 // SmartContract provides functions for transferring tokens between accounts
 type SmartContract struct {
 	contractapi.Contract
@@ -534,6 +545,79 @@ func (s *SmartContract) TransferFrom(ctx contractapi.TransactionContextInterface
 	return nil
 }
 
+// Pay transfers tokens from client account to recipient account like Transfer, and keeps a receipt
+// of the payment under the transaction ID together with a reference, such as the number of the bill paid
+// This function triggers a Transfer event
+func (s *SmartContract) Pay(ctx contractapi.TransactionContextInterface, recipient string, amount int, reference string) error {
+
+	if reference == "" {
+		return fmt.Errorf("payment reference must not be empty")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("payment amount must be a positive integer")
+	}
+
+	err := s.Transfer(ctx, recipient, amount)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client id: %v", err)
+	}
+
+	payment := Payment{
+		TxID:      ctx.GetStub().GetTxID(),
+		From:      clientID,
+		To:        recipient,
+		Value:     amount,
+		Reference: reference,
+	}
+	paymentJSON, err := json.Marshal(payment)
+	if err != nil {
+		return fmt.Errorf("failed to obtain JSON encoding: %v", err)
+	}
+
+	paymentKey, err := ctx.GetStub().CreateCompositeKey(paymentPrefix, []string{payment.TxID})
+	if err != nil {
+		return fmt.Errorf("failed to create the composite key for prefix %s: %v", paymentPrefix, err)
+	}
+	err = ctx.GetStub().PutState(paymentKey, paymentJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put payment to world state: %v", err)
+	}
+
+	log.Printf("client %s paid %d to %s for %s", clientID, amount, recipient, reference)
+
+	return nil
+}
+
+// GetPayment returns the receipt of the payment made with Pay in the given transaction
+func (s *SmartContract) GetPayment(ctx contractapi.TransactionContextInterface, txID string) (*Payment, error) {
+
+	paymentKey, err := ctx.GetStub().CreateCompositeKey(paymentPrefix, []string{txID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the composite key for prefix %s: %v", paymentPrefix, err)
+	}
+
+	paymentJSON, err := ctx.GetStub().GetState(paymentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if paymentJSON == nil {
+		return nil, fmt.Errorf("the payment %s does not exist", txID)
+	}
+
+	var payment Payment
+	err = json.Unmarshal(paymentJSON, &payment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain JSON decoding: %v", err)
+	}
+
+	return &payment, nil
+}
+
 // Name returns a descriptive name for fungible tokens in this contract
 // returns {String} Returns the name of the token
 
@@ -57,6 +57,70 @@ func _nftExists(ctx contractapi.TransactionContextInterface, tokenId string) boo
 	return len(nftBytes) > 0
 }
 
+// _transferNFT assigns a non-fungible token to a new owner, moves it between the balances of the owners and
+// emits the Transfer event. The caller checks that the transfer is authorized
+func _transferNFT(ctx contractapi.TransactionContextInterface, nft *Nft, from string, to string) error {
+	tokenId := nft.TokenId
+
+	// Clear the approved client for this non-fungible token
+	nft.Approved = ""
+
+	// Overwrite a non-fungible token to assign a new owner.
+	nft.Owner = to
+	nftKey, err := ctx.GetStub().CreateCompositeKey(nftPrefix, []string{tokenId})
+	if err != nil {
+		return fmt.Errorf("failed to CreateCompositeKey: %v", err)
+	}
+
+	nftBytes, err := json.Marshal(nft)
+	if err != nil {
+		return fmt.Errorf("failed to marshal approval: %v", err)
+	}
+
+	err = ctx.GetStub().PutState(nftKey, nftBytes)
+	if err != nil {
+		return fmt.Errorf("failed to PutState nftBytes %s: %v", nftBytes, err)
+	}
+
+	// Remove a composite key from the balance of the current owner
+	balanceKeyFrom, err := ctx.GetStub().CreateCompositeKey(balancePrefix, []string{from, tokenId})
+	if err != nil {
+		return fmt.Errorf("failed to CreateCompositeKey from: %v", err)
+	}
+
+	err = ctx.GetStub().DelState(balanceKeyFrom)
+	if err != nil {
+		return fmt.Errorf("failed to DelState balanceKeyFrom %s: %v", nftBytes, err)
+	}
+
+	// Save a composite key to count the balance of a new owner
+	balanceKeyTo, err := ctx.GetStub().CreateCompositeKey(balancePrefix, []string{to, tokenId})
+	if err != nil {
+		return fmt.Errorf("failed to CreateCompositeKey to: %v", err)
+	}
+	err = ctx.GetStub().PutState(balanceKeyTo, []byte{0})
+	if err != nil {
+		return fmt.Errorf("failed to PutState balanceKeyTo %s: %v", balanceKeyTo, err)
+	}
+
+	// Emit the Transfer event
+	transferEvent := new(Transfer)
+	transferEvent.From = from
+	transferEvent.To = to
+	transferEvent.TokenId = tokenId
+
+	transferEventBytes, err := json.Marshal(transferEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transferEventBytes: %v", err)
+	}
+
+	err = ctx.GetStub().SetEvent("Transfer", transferEventBytes)
+	if err != nil {
+		return fmt.Errorf("failed to SetEvent transferEventBytes %s: %v", transferEventBytes, err)
+	}
+	return nil
+}
+
 // BalanceOf counts all non-fungible tokens assigned to an owner
 // param owner {String} An owner for whom to query the balance
 // returns {int} The number of non-fungible tokens owned by the owner, possibly zero
@@ -341,62 +405,11 @@ func (c *TokenERC721Contract) TransferFrom(ctx contractapi.TransactionContextInt
 		return false, errors.New("the from is not the current owner")
 	}
 
-	// Clear the approved client for this non-fungible token
-	nft.Approved = ""
-
-	// Overwrite a non-fungible token to assign a new owner.
-	nft.Owner = to
-	nftKey, err := ctx.GetStub().CreateCompositeKey(nftPrefix, []string{tokenId})
-	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey: %v", err)
-	}
-
-	nftBytes, err := json.Marshal(nft)
-	if err != nil {
-		return false, fmt.Errorf("failed to marshal approval: %v", err)
-	}
-
-	err = ctx.GetStub().PutState(nftKey, nftBytes)
-	if err != nil {
-		return false, fmt.Errorf("failed to PutState nftBytes %s: %v", nftBytes, err)
-	}
-
-	// Remove a composite key from the balance of the current owner
-	balanceKeyFrom, err := ctx.GetStub().CreateCompositeKey(balancePrefix, []string{from, tokenId})
-	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey from: %v", err)
-	}
-
-	err = ctx.GetStub().DelState(balanceKeyFrom)
-	if err != nil {
-		return false, fmt.Errorf("failed to DelState balanceKeyFrom %s: %v", nftBytes, err)
-	}
-
-	// Save a composite key to count the balance of a new owner
-	balanceKeyTo, err := ctx.GetStub().CreateCompositeKey(balancePrefix, []string{to, tokenId})
-	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey to: %v", err)
-	}
-	err = ctx.GetStub().PutState(balanceKeyTo, []byte{0})
-	if err != nil {
-		return false, fmt.Errorf("failed to PutState balanceKeyTo %s: %v", balanceKeyTo, err)
-	}
-
-	// Emit the Transfer event
-	transferEvent := new(Transfer)
-	transferEvent.From = from
-	transferEvent.To = to
-	transferEvent.TokenId = tokenId
-
-	transferEventBytes, err := json.Marshal(transferEvent)
+	err = _transferNFT(ctx, nft, from, to)
 	if err != nil {
-		return false, fmt.Errorf("failed to marshal transferEventBytes: %v", err)
+		return false, err
 	}
 
-	err = ctx.GetStub().SetEvent("Transfer", transferEventBytes)
-	if err != nil {
-		return false, fmt.Errorf("failed to SetEvent transferEventBytes %s: %v", transferEventBytes, err)
-	}
 	return true, nil
 }
 
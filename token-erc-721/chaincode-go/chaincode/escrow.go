@@ -0,0 +1,323 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package chaincode
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// Define objectType names for prefix
+const escrowPrefix = "escrow"
+const escrowAccountPrefix = "escrowAccount"
+
+// Key of the list of chaincodes that can lock and release escrows
+const escrowChaincodesKey = "escrowChaincodes"
+
+// Escrow holds a non-fungible token that a chaincode locked on behalf of its owner, for example the item
+// sold in an auction. The token is owned by the escrow account of the chaincode, and only that chaincode
+// can release it
+type Escrow struct {
+	ID        string `json:"id"`
+	Owner     string `json:"owner"`
+	TokenId   string `json:"tokenId"`
+	Chaincode string `json:"chaincode"`
+}
+
+// LockEscrow moves a non-fungible token into an escrow identified by escrowID
+// LockEscrow can only be called by an escrow chaincode, which becomes the only chaincode that can release the escrow
+// param {String} escrowID The identifier of the escrow, unique among the escrows of the calling chaincode
+// param {String} tokenId The non-fungible token to lock, which the client owns or is an authorized operator of
+// returns {Escrow} Return the escrow that was created
+func (c *TokenERC721Contract) LockEscrow(ctx contractapi.TransactionContextInterface, escrowID string, tokenId string) (*Escrow, error) {
+
+	// Check if contract has been intilized first
+	initialized, err := checkInitialized(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if contract is already initialized: %v", err)
+	}
+	if !initialized {
+		return nil, errors.New("contract options need to be set before calling any function, call Initialize() to initialize contract")
+	}
+
+	chaincodeName, err := escrowChaincode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	escrowKey, err := ctx.GetStub().CreateCompositeKey(escrowPrefix, []string{chaincodeName, escrowID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to CreateCompositeKey %s: %v", escrowID, err)
+	}
+	existingEscrowBytes, err := ctx.GetStub().GetState(escrowKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetState %s: %v", escrowID, err)
+	}
+	if len(existingEscrowBytes) > 0 {
+		return nil, fmt.Errorf("the escrow %s already exists", escrowID)
+	}
+
+	sender64, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetClientIdentity: %v", err)
+	}
+
+	senderBytes, err := base64.StdEncoding.DecodeString(sender64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to DecodeString sender: %v", err)
+	}
+	sender := string(senderBytes)
+
+	nft, err := _readNFT(ctx, tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to _readNFT : %v", err)
+	}
+
+	// Check if the sender is the current owner, an authorized operator,
+	// or the approved client for this non-fungible token
+	owner := nft.Owner
+	operatorApproval, err := c.IsApprovedForAll(ctx, owner, sender)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IsApprovedForAll : %v", err)
+	}
+	if owner != sender && nft.Approved != sender && !operatorApproval {
+		return nil, errors.New("the sender is not the current owner nor an authorized operator")
+	}
+
+	escrowAccount, err := ctx.GetStub().CreateCompositeKey(escrowAccountPrefix, []string{chaincodeName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to CreateCompositeKey %s: %v", chaincodeName, err)
+	}
+	err = _transferNFT(ctx, nft, owner, escrowAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	escrow := &Escrow{
+		ID:        escrowID,
+		Owner:     owner,
+		TokenId:   tokenId,
+		Chaincode: chaincodeName,
+	}
+	escrowBytes, err := json.Marshal(escrow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal escrow: %v", err)
+	}
+	err = ctx.GetStub().PutState(escrowKey, escrowBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to PutState escrowBytes %s: %v", escrowBytes, err)
+	}
+
+	return escrow, nil
+}
+
+// ReleaseEscrow transfers the non-fungible token of an escrow to the recipient and removes the escrow
+// ReleaseEscrow can only be called by the chaincode that locked the escrow
+// param {String} escrowID The identifier of the escrow
+// param {String} recipient The new owner of the non-fungible token
+// returns {Boolean} Return whether the release was successful or not
+func (c *TokenERC721Contract) ReleaseEscrow(ctx contractapi.TransactionContextInterface, escrowID string, recipient string) (bool, error) {
+	return _releaseEscrow(ctx, escrowID, recipient)
+}
+
+// RefundEscrow returns the non-fungible token of an escrow to its owner and removes the escrow
+// RefundEscrow can only be called by the chaincode that locked the escrow
+// param {String} escrowID The identifier of the escrow
+// returns {Boolean} Return whether the refund was successful or not
+func (c *TokenERC721Contract) RefundEscrow(ctx contractapi.TransactionContextInterface, escrowID string) (bool, error) {
+	return _releaseEscrow(ctx, escrowID, "")
+}
+
+// SetEscrowChaincodes sets the chaincodes that can lock and release escrows, such as the auction chaincodes of
+// the channel, and replaces the chaincodes set before. An escrow belongs to the chaincode that the transaction
+// proposal invoked, which has to be one of them
+// param {Array} chaincodes The names of the escrow chaincodes
+// returns {Boolean} Return whether the chaincodes were set or not
+func (c *TokenERC721Contract) SetEscrowChaincodes(ctx contractapi.TransactionContextInterface, chaincodes []string) (bool, error) {
+
+	// Check if contract has been intilized first
+	initialized, err := checkInitialized(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if contract is already initialized: %v", err)
+	}
+	if !initialized {
+		return false, errors.New("contract options need to be set before calling any function, call Initialize() to initialize contract")
+	}
+
+	// Check minter authorization - this sample assumes Org1 is the issuer with privilege to name the escrow chaincodes
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, fmt.Errorf("failed to get clientMSPID: %v", err)
+	}
+	if clientMSPID != "Org1MSP" {
+		return false, errors.New("client is not authorized to set the escrow chaincodes")
+	}
+
+	chaincodesBytes, err := json.Marshal(chaincodes)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal chaincodes: %v", err)
+	}
+	err = ctx.GetStub().PutState(escrowChaincodesKey, chaincodesBytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to PutState escrowChaincodesKey %s: %v", escrowChaincodesKey, err)
+	}
+
+	return true, nil
+}
+
+// GetEscrowChaincodes returns the chaincodes that can lock and release escrows
+// returns {Array} Return the names of the escrow chaincodes
+func (c *TokenERC721Contract) GetEscrowChaincodes(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	return _readEscrowChaincodes(ctx)
+}
+
+// GetEscrow returns the escrow that a chaincode locked
+// param {String} chaincodeName The name of the chaincode that locked the escrow
+// param {String} escrowID The identifier of the escrow
+// returns {Escrow} Return the escrow
+func (c *TokenERC721Contract) GetEscrow(ctx contractapi.TransactionContextInterface, chaincodeName string, escrowID string) (*Escrow, error) {
+	return _readEscrow(ctx, chaincodeName, escrowID)
+}
+
+func _readEscrow(ctx contractapi.TransactionContextInterface, chaincodeName string, escrowID string) (*Escrow, error) {
+	escrowKey, err := ctx.GetStub().CreateCompositeKey(escrowPrefix, []string{chaincodeName, escrowID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to CreateCompositeKey %s: %v", escrowID, err)
+	}
+
+	escrowBytes, err := ctx.GetStub().GetState(escrowKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetState %s: %v", escrowID, err)
+	}
+	if len(escrowBytes) == 0 {
+		return nil, fmt.Errorf("the escrow %s does not exist", escrowID)
+	}
+
+	escrow := new(Escrow)
+	err = json.Unmarshal(escrowBytes, escrow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Unmarshal escrowBytes: %v", err)
+	}
+
+	return escrow, nil
+}
+
+// _releaseEscrow transfers the non-fungible token of an escrow to the recipient, or back to its owner when
+// the recipient is empty, and removes the escrow
+func _releaseEscrow(ctx contractapi.TransactionContextInterface, escrowID string, recipient string) (bool, error) {
+
+	// Check if contract has been intilized first
+	initialized, err := checkInitialized(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if contract is already initialized: %v", err)
+	}
+	if !initialized {
+		return false, errors.New("contract options need to be set before calling any function, call Initialize() to initialize contract")
+	}
+
+	// The escrow is looked up under the invoking chaincode, so a chaincode can only release its own escrows
+	chaincodeName, err := escrowChaincode(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	escrow, err := _readEscrow(ctx, chaincodeName, escrowID)
+	if err != nil {
+		return false, err
+	}
+	if recipient == "" {
+		recipient = escrow.Owner
+	}
+
+	nft, err := _readNFT(ctx, escrow.TokenId)
+	if err != nil {
+		return false, fmt.Errorf("failed to _readNFT : %v", err)
+	}
+
+	escrowAccount, err := ctx.GetStub().CreateCompositeKey(escrowAccountPrefix, []string{chaincodeName})
+	if err != nil {
+		return false, fmt.Errorf("failed to CreateCompositeKey %s: %v", chaincodeName, err)
+	}
+	err = _transferNFT(ctx, nft, escrowAccount, recipient)
+	if err != nil {
+		return false, err
+	}
+
+	escrowKey, err := ctx.GetStub().CreateCompositeKey(escrowPrefix, []string{chaincodeName, escrowID})
+	if err != nil {
+		return false, fmt.Errorf("failed to CreateCompositeKey %s: %v", escrowID, err)
+	}
+	err = ctx.GetStub().DelState(escrowKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to DelState escrow %s: %v", escrowID, err)
+	}
+
+	return true, nil
+}
+
+// escrowChaincode returns the name of the chaincode that the transaction proposal invoked, which owns the
+// escrows that the transaction locks and releases. The chaincode has to be one of the escrow chaincodes set
+// with SetEscrowChaincodes, so that clients cannot lock or release escrows by invoking this contract directly,
+// or through a chaincode that is not trusted to hold escrows. A chaincode on the list answers for the escrows
+// of every chaincode it calls on the way to this contract.
+func escrowChaincode(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	signedProposal, err := ctx.GetStub().GetSignedProposal()
+	if err != nil {
+		return "", fmt.Errorf("failed to GetSignedProposal: %v", err)
+	}
+
+	proposal := &peer.Proposal{}
+	err = proto.Unmarshal(signedProposal.GetProposalBytes(), proposal)
+	if err != nil {
+		return "", fmt.Errorf("failed to Unmarshal proposal: %v", err)
+	}
+	payload := &peer.ChaincodeProposalPayload{}
+	err = proto.Unmarshal(proposal.GetPayload(), payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to Unmarshal proposal payload: %v", err)
+	}
+	invocationSpec := &peer.ChaincodeInvocationSpec{}
+	err = proto.Unmarshal(payload.GetInput(), invocationSpec)
+	if err != nil {
+		return "", fmt.Errorf("failed to Unmarshal chaincode invocation spec: %v", err)
+	}
+	chaincodeName := invocationSpec.GetChaincodeSpec().GetChaincodeId().GetName()
+
+	chaincodes, err := _readEscrowChaincodes(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range chaincodes {
+		if name == chaincodeName {
+			return chaincodeName, nil
+		}
+	}
+
+	return "", fmt.Errorf("chaincode %s is not allowed to lock and release escrows", chaincodeName)
+}
+
+func _readEscrowChaincodes(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	chaincodesBytes, err := ctx.GetStub().GetState(escrowChaincodesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetState %s: %v", escrowChaincodesKey, err)
+	}
+	chaincodes := []string{}
+	if len(chaincodesBytes) == 0 {
+		return chaincodes, nil
+	}
+	err = json.Unmarshal(chaincodesBytes, &chaincodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Unmarshal chaincodesBytes: %v", err)
+	}
+
+	return chaincodes, nil
+}
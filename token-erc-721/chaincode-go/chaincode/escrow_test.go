@@ -0,0 +1,98 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func (ms *MockStub) GetSignedProposal() (*peer.SignedProposal, error) {
+	args := ms.Called()
+	return args.Get(0).(*peer.SignedProposal), args.Error(1)
+}
+
+// signedProposal returns a proposal that invokes the chaincode with the given arguments
+func signedProposal(t *testing.T, chaincodeName string, args ...string) *peer.SignedProposal {
+	input := &peer.ChaincodeInput{}
+	for _, arg := range args {
+		input.Args = append(input.Args, []byte(arg))
+	}
+	invocationSpec, err := proto.Marshal(&peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{ChaincodeId: &peer.ChaincodeID{Name: chaincodeName}, Input: input},
+	})
+	assert.NoError(t, err)
+	payload, err := proto.Marshal(&peer.ChaincodeProposalPayload{Input: invocationSpec})
+	assert.NoError(t, err)
+	proposal, err := proto.Marshal(&peer.Proposal{Payload: payload})
+	assert.NoError(t, err)
+	return &peer.SignedProposal{ProposalBytes: proposal}
+}
+
+func TestLockEscrow(t *testing.T) {
+	ctx, ms := setupStub()
+	c := new(TokenERC721Contract)
+
+	ms.On("GetSignedProposal").Return(signedProposal(t, "auction", "CreateAuction", "auction1"), nil)
+	ms.On("GetState", escrowChaincodesKey).Return([]byte(`["auction"]`), nil)
+	ms.On("CreateCompositeKey", escrowPrefix, []string{"auction", "101"}).Return("escrowauction101", nil)
+	ms.On("CreateCompositeKey", escrowAccountPrefix, []string{"auction"}).Return("escrowAccountauction", nil)
+	ms.On("CreateCompositeKey", balancePrefix, []string{"escrowAccountauction", "101"}).Return(balancePrefix+"escrowAccountauction101", nil)
+	ms.On("GetState", "escrowauction101").Return([]byte{}, nil)
+
+	escrow, err := c.LockEscrow(ctx, "101", "101")
+	assert.NoError(t, err)
+	assert.Equal(t, &Escrow{ID: "101", Owner: owner, TokenId: "101", Chaincode: "auction"}, escrow)
+	ms.AssertCalled(t, "PutState", balancePrefix+"escrowAccountauction101", []byte{0})
+	ms.AssertCalled(t, "DelState", balancePrefix+owner+"101")
+}
+
+func TestLockEscrowDirectCall(t *testing.T) {
+	ctx, ms := setupStub()
+	c := new(TokenERC721Contract)
+
+	ms.On("GetSignedProposal").Return(signedProposal(t, "nft", "LockEscrow", "101", "101"), nil)
+	ms.On("GetState", escrowChaincodesKey).Return([]byte(`["auction"]`), nil)
+
+	_, err := c.LockEscrow(ctx, "101", "101")
+	assert.EqualError(t, err, "chaincode nft is not allowed to lock and release escrows")
+}
+
+func TestEscrowThroughProxy(t *testing.T) {
+	ctx, ms := setupStub()
+	c := new(TokenERC721Contract)
+
+	// A proxy that is not an escrow chaincode cannot lock escrows, nor release them on behalf of the auction
+	ms.On("GetSignedProposal").Return(signedProposal(t, "proxy", "Forward", "auction", "ReleaseEscrow", "101", owner), nil)
+	ms.On("GetState", escrowChaincodesKey).Return([]byte(`["auction"]`), nil)
+
+	_, err := c.LockEscrow(ctx, "101", "101")
+	assert.EqualError(t, err, "chaincode proxy is not allowed to lock and release escrows")
+	_, err = c.ReleaseEscrow(ctx, "101", owner)
+	assert.EqualError(t, err, "chaincode proxy is not allowed to lock and release escrows")
+	ms.AssertNotCalled(t, "DelState", balancePrefix+owner+"101")
+}
+
+func TestSetEscrowChaincodes(t *testing.T) {
+	ctx, ms := setupStub()
+	c := new(TokenERC721Contract)
+
+	ms.On("GetState", escrowChaincodesKey).Return([]byte(nil), nil).Once()
+	chaincodes, err := c.GetEscrowChaincodes(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, chaincodes)
+
+	ok, err := c.SetEscrowChaincodes(ctx, []string{"auction"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ms.AssertCalled(t, "PutState", escrowChaincodesKey, []byte(`["auction"]`))
+
+	mci := new(MockClientIdentity)
+	mci.On("GetMSPID").Return("Org2MSP", nil)
+	ctx = new(MockContext)
+	ctx.On("GetStub").Return(ms)
+	ctx.On("GetClientIdentity").Return(mci)
+	_, err = c.SetEscrowChaincodes(ctx, []string{"auction"})
+	assert.EqualError(t, err, "client is not authorized to set the escrow chaincodes")
+}